@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/muraduiurie/gpt/pkg/ai"
@@ -13,10 +14,13 @@ import (
 )
 
 func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	opts := &ai.AIOpts{Logger: logger}
+
 	// chatgpt
-	openai, err := ai.NewAIAgent(ai.ChatGPTAgent)
+	openai, err := ai.NewAIAgent(ai.ModelChatGPT, opts)
 	if err != nil {
-		fmt.Printf(err.Error())
+		logger.Error(err.Error())
 		os.Exit(1)
 	}
 
@@ -27,7 +31,7 @@ func main() {
 		},
 	})
 	if err != nil {
-		fmt.Printf(err.Error())
+		logger.Error(err.Error())
 		os.Exit(1)
 	}
 
@@ -39,9 +43,9 @@ func main() {
 	fmt.Println(string(b))
 
 	// deepseek
-	ds, err := ai.NewAIAgent(ai.DeepSeekAgent)
+	ds, err := ai.NewAIAgent(ai.ModelDeepSeek, opts)
 	if err != nil {
-		fmt.Printf(err.Error())
+		logger.Error(err.Error())
 		os.Exit(1)
 	}
 
@@ -57,7 +61,7 @@ func main() {
 		},
 	})
 	if err != nil {
-		fmt.Printf(err.Error())
+		logger.Error(err.Error())
 		os.Exit(1)
 	}
 
@@ -69,9 +73,9 @@ func main() {
 	fmt.Println(string(b))
 
 	// claude
-	cl, err := ai.NewAIAgent(ai.ClaudeAgent)
+	cl, err := ai.NewAIAgent(ai.ModelClaude, opts)
 	if err != nil {
-		fmt.Printf(err.Error())
+		logger.Error(err.Error())
 		os.Exit(1)
 	}
 
@@ -88,7 +92,7 @@ func main() {
 		},
 	})
 	if err != nil {
-		fmt.Printf(err.Error())
+		logger.Error(err.Error())
 		os.Exit(1)
 	}
 
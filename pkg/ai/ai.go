@@ -3,11 +3,15 @@ package ai
 import (
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
 
-	"github.com/muraduiurie/gpt/pkg/ai/providers/chatgpt"
-	"github.com/muraduiurie/gpt/pkg/ai/providers/claude"
-	"github.com/muraduiurie/gpt/pkg/ai/providers/deepseek"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/logging"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
 	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
 )
 
@@ -15,91 +19,193 @@ type AIAgent interface {
 	AskAI(opts *union.Request) (*union.Response, error)
 }
 
+// StreamingAIAgent is an AIAgent that can also stream its answer as it is
+// generated.
+type StreamingAIAgent interface {
+	AIAgent
+	StreamAI(opts *union.Request) (union.Stream, error)
+}
+
+// Embedder turns texts into vectors. Inputs are batched to the provider
+// limits, so any number of them may be passed at once.
+type Embedder interface {
+	EmbedAI(opts *union.EmbedRequest) (*union.EmbedResponse, error)
+}
+
 type Model string
 
 const (
 	ModelChatGPT  Model = "chatgpt"
 	ModelDeepSeek Model = "deepseek"
 	ModelClaude   Model = "claude"
+	ModelGemini   Model = "gemini"
+	ModelMistral  Model = "mistral"
+	// ModelOpenAICompatible is any server speaking OpenAI's chat
+	// completions API; see package openaicompat.
+	ModelOpenAICompatible Model = "openai-compatible"
+	// ModelAzure is Azure OpenAI; see package azure.
+	ModelAzure Model = "azure"
+	// ModelOllama is the native API of a local Ollama server; see package
+	// ollama.
+	ModelOllama Model = "ollama"
 )
 
 type AIOpts struct {
 	ApiToken          string
 	TextInputEndpoint string
+	// ApiTokens is a pool of keys to spread requests across. When set, it is
+	// used instead of ApiToken.
+	ApiTokens []string
+	// KeyStrategy selects keys from ApiTokens. Defaults to round-robin.
+	KeyStrategy keypool.Strategy
+	// HTTPClient is passed to the provider client, e.g. to install a custom
+	// transport. Defaults to a client with a 300 second timeout.
+	HTTPClient *http.Client
+	// Middleware wraps the returned agent; the first middleware is the
+	// outermost.
+	Middleware []Middleware
+	// Logger, when set, logs every provider call (see package logging).
+	Logger *slog.Logger
+	// LogOptions configure the Logger, e.g. to log bodies or mask personal
+	// data.
+	LogOptions logging.Options
+	// Observers are notified around every provider call, e.g. for tracing
+	// or metrics.
+	Observers []observe.Observer
+	// Settings are provider-specific settings, named like the `config.yaml`
+	// keys without the provider prefix (see ProviderConfig.Settings).
+	Settings map[string]string
 }
 
 // NewAIAgent initializes and returns an AI agent implementation based on the
-// provided agent type. It reads configuration from `config.yaml` using Viper
-// and currently supports the `chatgpt` agent. Returns an error if the agent
-// type is unknown or required configuration (e.g., API token) is missing.
+// provided agent type, which must be registered (see Register and Models).
+// Credentials are taken from conf, or read from `config.yaml` using Viper
+// when conf is nil or carries no API token; the other conf fields apply
+// either way. Returns an error if the agent type is unknown or required
+// configuration (e.g., API token) is missing. When conf carries middlewares,
+// the agent is wrapped with them.
 func NewAIAgent(model Model, conf *AIOpts) (AIAgent, error) {
+	agent, err := newAIAgent(model, conf)
+	if err != nil {
+		return nil, err
+	}
+	if conf != nil && len(conf.Middleware) > 0 {
+		agent = Chain(agent, conf.Middleware...)
+	}
+
+	return agent, nil
+}
+
+// NewEmbedder returns the Embedder of a provider with the
+// CapabilityEmbeddings capability, configured like NewAIAgent. Middleware
+// in conf does not apply to embeddings and is ignored.
+func NewEmbedder(model Model, conf *AIOpts) (Embedder, error) {
+	agent, err := newAIAgent(model, conf)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := agent.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("ai model %s does not support embeddings", model)
+	}
+
+	return embedder, nil
+}
+
+func newAIAgent(model Model, conf *AIOpts) (AIAgent, error) {
+	p, ok := LookupProvider(model)
+	if !ok {
+		return nil, fmt.Errorf("unknown ai model: %s (available: %s)", model, modelList())
+	}
 
 	var token, endpoint string
-	if conf == nil {
-		v := viper.New()
+	var tokens []string
+	var strategy keypool.Strategy
+	settings := map[string]string{}
+	if conf != nil {
+		token = conf.ApiToken
+		endpoint = conf.TextInputEndpoint
+		tokens = conf.ApiTokens
+		strategy = conf.KeyStrategy
+	}
 
-		// base config: `config.yaml` (optional)
+	// credentials come from `config.yaml` unless conf carries them
+	if token == "" && len(tokens) == 0 {
+		v := viper.New()
 		v.SetConfigName("config")
 		v.SetConfigType("yaml")
 		v.AddConfigPath(".")
 		err := v.ReadInConfig()
-		if err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if err != nil && (conf == nil || !errors.As(err, &notFound)) {
 			return nil, err
 		}
 
-		switch model {
-		case ModelChatGPT:
-			token = v.GetString("openai_api_token")
-			endpoint = v.GetString("openai_text_input_endpoint")
-		case ModelDeepSeek:
-			token = v.GetString("deepseek_api_token")
-			endpoint = v.GetString("deepseek_text_input_endpoint")
-		case ModelClaude:
-			token = v.GetString("claude_api_token")
-			endpoint = v.GetString("claude_text_input_endpoint")
-		default:
-			return nil, fmt.Errorf("unknown ai model: %s", model)
+		prefix := p.ConfigPrefix + "_"
+		for _, key := range v.AllKeys() {
+			if value := settingValue(v.Get(key)); strings.HasPrefix(key, prefix) && value != "" {
+				settings[strings.TrimPrefix(key, prefix)] = value
+			}
 		}
-	} else {
-		token = conf.ApiToken
-		endpoint = conf.TextInputEndpoint
-	}
 
-	switch model {
-	case ModelChatGPT:
-		c := &chatgpt.Client{
-			ApiToken:          token,
-			TextInputEndpoint: endpoint,
+		token = settings["api_token"]
+		tokens = v.GetStringSlice(prefix + "api_tokens")
+		if endpoint == "" {
+			endpoint = settings["text_input_endpoint"]
 		}
-
-		if c.ApiToken == "" {
-			return nil, errors.New("missing API token: set `openai_api_token` in `config.yaml`")
+		if strategy == "" {
+			strategy = keypool.Strategy(settings["key_strategy"])
 		}
+	}
 
-		return c, nil
-	case ModelDeepSeek:
-		c := &deepseek.Client{
-			ApiToken:          token,
-			TextInputEndpoint: endpoint,
+	cfg := ProviderConfig{
+		ApiToken:          token,
+		TextInputEndpoint: endpoint,
+		Settings:          settings,
+	}
+	if len(tokens) > 0 {
+		keys, err := keypool.New(tokens, strategy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `%s_api_tokens`: %w", p.ConfigPrefix, err)
 		}
-
-		if c.ApiToken == "" {
-			return nil, errors.New("missing API token: set `deepseek_api_token` in `config.yaml`")
+		cfg.Keys = keys
+	}
+	if conf != nil {
+		cfg.HTTPClient = conf.HTTPClient
+		var observers []observe.Observer
+		if conf.Logger != nil {
+			observers = append(observers, logging.New(conf.Logger, conf.LogOptions))
 		}
-
-		return c, nil
-	case ModelClaude:
-		c := &claude.Client{
-			ApiToken:          token,
-			TextInputEndpoint: endpoint,
+		cfg.Observer = observe.Multi(append(observers, conf.Observers...)...)
+		for k, v := range conf.Settings {
+			cfg.Settings[k] = v
 		}
+	}
 
-		if c.ApiToken == "" {
-			return nil, errors.New("missing API token: set `claude_api_token` in `config.yaml`")
-		}
+	if cfg.ApiToken == "" && cfg.Keys == nil && !p.OptionalToken {
+		return nil, fmt.Errorf("missing API token: set `%s_api_token` in `config.yaml`", p.ConfigPrefix)
+	}
+
+	return p.New(cfg)
+}
 
-		return c, nil
-	default:
-		return nil, fmt.Errorf("unknown ai model: %s", model)
+func modelList() string {
+	var names []string
+	for _, m := range Models() {
+		names = append(names, string(m))
+	}
+	return strings.Join(names, ", ")
+}
+
+// settingValue returns a config value as a setting; lists are joined with
+// commas.
+func settingValue(v interface{}) string {
+	if list, ok := v.([]interface{}); ok {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
 	}
+	return cast.ToString(v)
 }
@@ -0,0 +1,260 @@
+// Package aitest provides an in-process fake of ai.AIAgent for unit tests.
+//
+// A fake Agent serves scripted replies instead of calling a provider. Replies
+// are either queued (served in order to any request) or attached to a rule
+// that matches requests with a predicate; rules are checked first. Every
+// request the agent receives is recorded for assertions.
+//
+//	fake := aitest.New()
+//	fake.When(aitest.ContainsText("weather")).Reply(aitest.ChatGPTText("Sunny."))
+//	fake.Enqueue(aitest.Error(aitest.APIError(500, "overloaded")))
+//
+//	resp, err := fake.AskAI(req)
+//	if len(fake.Requests()) != 1 { ... }
+package aitest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// ErrNoReply is returned when a request matches no rule and the queue is
+// empty.
+var ErrNoReply = errors.New("aitest: no reply scripted for request")
+
+// Reply is a scripted answer to a request.
+type Reply struct {
+	// Response is returned by AskAI. Its Provider defaults to the agent's.
+	Response *union.Response
+	// Err is returned instead of a response.
+	Err error
+	// Delay simulates latency before the reply (or the first stream event).
+	// It is cut short if the request context is done.
+	Delay time.Duration
+
+	// Chunks are the text deltas served by StreamAI.
+	Chunks []string
+	// ChunkDelay is waited between stream events.
+	ChunkDelay time.Duration
+	// StreamErr, if set, is returned by Recv after the chunks instead of
+	// io.EOF, simulating a stream that breaks mid-way.
+	StreamErr error
+}
+
+// Agent is a scriptable fake that implements ai.AIAgent and
+// ai.StreamingAIAgent. It is safe for concurrent use.
+type Agent struct {
+	// Name is returned by Provider. Defaults to "aitest".
+	Name string
+
+	mu       sync.Mutex
+	rules    []*Rule
+	queue    []Reply
+	requests []*union.Request
+}
+
+// New returns an Agent with no scripted replies.
+func New() *Agent {
+	return &Agent{Name: "aitest"}
+}
+
+// Provider returns the agent's Name.
+func (a *Agent) Provider() string {
+	return a.Name
+}
+
+// Enqueue adds replies that are served in order to requests no rule matches.
+func (a *Agent) Enqueue(replies ...Reply) *Agent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.queue = append(a.queue, replies...)
+	return a
+}
+
+// When adds a rule answering the requests that match pred.
+func (a *Agent) When(pred Predicate) *Rule {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r := &Rule{pred: pred}
+	a.rules = append(a.rules, r)
+	return r
+}
+
+// Requests returns every request received so far, in order.
+func (a *Agent) Requests() []*union.Request {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]*union.Request(nil), a.requests...)
+}
+
+// LastRequest returns the most recent request, or nil.
+func (a *Agent) LastRequest() *union.Request {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.requests) == 0 {
+		return nil
+	}
+	return a.requests[len(a.requests)-1]
+}
+
+// Reset drops all rules, queued replies and recorded requests.
+func (a *Agent) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rules = nil
+	a.queue = nil
+	a.requests = nil
+}
+
+// AskAI records the request and serves the next matching reply.
+func (a *Agent) AskAI(opts *union.Request) (*union.Response, error) {
+	reply, err := a.next(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = wait(opts, reply.Delay); err != nil {
+		return nil, err
+	}
+	if reply.Err != nil {
+		return nil, reply.Err
+	}
+	if reply.Response == nil {
+		return nil, fmt.Errorf("aitest: reply has neither Response nor Err")
+	}
+
+	resp := *reply.Response
+	if resp.Provider == "" {
+		resp.Provider = a.Name
+	}
+	return &resp, nil
+}
+
+// StreamAI records the request and streams the Chunks of the next matching
+// reply. The last event carries the usage of the reply's Response, if any.
+func (a *Agent) StreamAI(opts *union.Request) (union.Stream, error) {
+	reply, err := a.next(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = wait(opts, reply.Delay); err != nil {
+		return nil, err
+	}
+	if reply.Err != nil {
+		return nil, reply.Err
+	}
+
+	return newStream(opts, reply), nil
+}
+
+func (a *Agent) next(opts *union.Request) (Reply, error) {
+	if opts == nil {
+		return Reply{}, errors.New("nil opts")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.requests = append(a.requests, opts)
+
+	for _, r := range a.rules {
+		if reply, ok := r.take(opts); ok {
+			return reply, nil
+		}
+	}
+	if len(a.queue) > 0 {
+		reply := a.queue[0]
+		a.queue = a.queue[1:]
+		return reply, nil
+	}
+
+	return Reply{}, ErrNoReply
+}
+
+// wait sleeps for d, returning early with the context error if the request
+// context is done first.
+func wait(opts *union.Request, d time.Duration) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type stream struct {
+	opts   *union.Request
+	events []*union.StreamEvent
+	delay  time.Duration
+	err    error
+	pos    int
+	closed bool
+}
+
+func newStream(opts *union.Request, reply Reply) *stream {
+	s := &stream{
+		opts:  opts,
+		delay: reply.ChunkDelay,
+		err:   reply.StreamErr,
+	}
+	for _, c := range reply.Chunks {
+		s.events = append(s.events, &union.StreamEvent{Delta: c})
+	}
+	if reply.StreamErr != nil {
+		return s
+	}
+
+	// the last event ends the generation, as with the real providers
+	if len(s.events) == 0 {
+		s.events = append(s.events, &union.StreamEvent{})
+	}
+	last := s.events[len(s.events)-1]
+	last.FinishReason = "stop"
+	if reply.Response != nil {
+		last.Usage = reply.Response.Usage
+	}
+
+	return s
+}
+
+func (s *stream) Recv() (*union.StreamEvent, error) {
+	if s.closed {
+		return nil, io.EOF
+	}
+	if s.pos > 0 && s.pos < len(s.events) {
+		if err := wait(s.opts, s.delay); err != nil {
+			return nil, err
+		}
+	}
+	if s.pos < len(s.events) {
+		ev := s.events[s.pos]
+		s.pos++
+		return ev, nil
+	}
+
+	s.closed = true
+	if s.err != nil {
+		return nil, s.err
+	}
+	return nil, io.EOF
+}
+
+func (s *stream) Close() error {
+	s.closed = true
+	return nil
+}
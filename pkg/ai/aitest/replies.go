@@ -0,0 +1,158 @@
+package aitest
+
+import (
+	"net/http"
+	"reflect"
+	"time"
+
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	cltypes "github.com/muraduiurie/gpt/pkg/ai/types/claude"
+	dstypes "github.com/muraduiurie/gpt/pkg/ai/types/deepseek"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Response returns a reply with the given provider response.
+func Response(textResponse union.Responser, usage *union.Usage) Reply {
+	return Reply{
+		Response: &union.Response{
+			TextResponse: textResponse,
+			Usage:        usage,
+			Metadata:     &union.Metadata{StatusCode: http.StatusOK},
+		},
+	}
+}
+
+// Error returns a reply failing with err.
+func Error(err error) Reply {
+	return Reply{Err: err}
+}
+
+// APIError returns the error a provider client returns for a non-2xx status.
+func APIError(statusCode int, body string) *union.APIError {
+	return &union.APIError{
+		StatusCode: statusCode,
+		Body:       []byte(body),
+		Metadata:   &union.Metadata{StatusCode: statusCode},
+	}
+}
+
+// RateLimited returns a 429 error asking to retry after d.
+func RateLimited(d time.Duration) *union.APIError {
+	err := APIError(http.StatusTooManyRequests, `{"error":{"type":"rate_limit_error"}}`)
+	err.Metadata.RateLimit.RetryAfter = d
+	return err
+}
+
+// usageFor makes up token counts for canned replies: one token per four
+// characters, at least one.
+func usageFor(text string) *union.Usage {
+	out := len(text)/4 + 1
+	return &union.Usage{
+		InputTokens:  10,
+		OutputTokens: out,
+		TotalTokens:  10 + out,
+	}
+}
+
+// ChatGPTText returns a reply shaped like a ChatGPT Responses API answer.
+func ChatGPTText(text string) Reply {
+	u := usageFor(text)
+	r := Response(&cgtypes.TextInputResponse{
+		Id:     "resp_aitest",
+		Object: "response",
+		Status: "completed",
+		Model:  cgtypes.AiModelGpt4_1,
+		Output: []cgtypes.TextInputResponseOutput{{
+			Type:   "message",
+			Id:     "msg_aitest",
+			Status: "completed",
+			Role:   cgtypes.ChatGPTAIRoleAssistant,
+			Content: []cgtypes.TextInputResponseOutputContent{{
+				Type: "output_text",
+				Text: text,
+			}},
+		}},
+		Usage: cgtypes.ResponseUsage{
+			InputTokens:  u.InputTokens,
+			OutputTokens: u.OutputTokens,
+			TotalTokens:  u.TotalTokens,
+		},
+	}, u).WithOutput(text)
+	r.Response.Provider = "chatgpt"
+	r.Response.FinishReason = "completed"
+	r.Chunks = []string{text}
+	return r
+}
+
+// ClaudeText returns a reply shaped like a Claude Messages API answer.
+func ClaudeText(text string) Reply {
+	u := usageFor(text)
+	r := Response(&cltypes.TextInputResponse{
+		Id:         "msg_aitest",
+		Type:       "message",
+		Role:       cltypes.ClaudeAIRoleAssistant,
+		Model:      cltypes.ClaudeAIModelSonnet4_20250514,
+		Content:    []cltypes.TextInputResponseContent{{Type: "text", Text: text}},
+		StopReason: "end_turn",
+		Usage: cltypes.TextInputResponseUsage{
+			InputTokens:  u.InputTokens,
+			OutputTokens: u.OutputTokens,
+		},
+	}, u).WithOutput(text)
+	r.Response.Provider = "claude"
+	r.Response.FinishReason = "end_turn"
+	r.Chunks = []string{text}
+	return r
+}
+
+// DeepSeekText returns a reply shaped like a DeepSeek chat completion.
+func DeepSeekText(text string) Reply {
+	u := usageFor(text)
+	r := Response(&dstypes.TextInputResponse{
+		Id:     "chatcmpl-aitest",
+		Object: "chat.completion",
+		Model:  dstypes.DeepSeekAIModelChat,
+		Choices: []dstypes.TextInputResponseChoice{{
+			Message: dstypes.TextInputResponseChoiceMessage{
+				Role:    dstypes.DeepSeekAIRoleAssistant,
+				Content: text,
+			},
+			FinishReason: "stop",
+		}},
+		Usage: dstypes.TextInputResponseUsage{
+			PromptTokens:     u.InputTokens,
+			CompletionTokens: u.OutputTokens,
+			TotalTokens:      u.TotalTokens,
+		},
+	}, u).WithOutput(text)
+	r.Response.Provider = "deepseek"
+	r.Response.FinishReason = "stop"
+	r.Chunks = []string{text}
+	return r
+}
+
+// WithDelay returns r with a simulated latency.
+func (r Reply) WithDelay(d time.Duration) Reply {
+	r.Delay = d
+	return r
+}
+
+// WithOutput returns r with the given generated text.
+func (r Reply) WithOutput(output string) Reply {
+	if r.Response != nil {
+		resp := *r.Response
+		resp.Output = output
+		r.Response = &resp
+	}
+	return r
+}
+
+// WithChunks returns r streaming the given deltas.
+func (r Reply) WithChunks(chunks ...string) Reply {
+	r.Chunks = chunks
+	return r
+}
+
+func sameType(a, b interface{}) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
@@ -0,0 +1,116 @@
+package aitest
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Predicate matches requests.
+type Predicate func(opts *union.Request) bool
+
+// Rule answers the requests matching a predicate. Its replies are served in
+// order; the last one is repeated unless Times limited the rule.
+type Rule struct {
+	pred    Predicate
+	replies []Reply
+	served  int
+	limit   int
+}
+
+// Reply sets the replies of the rule.
+func (r *Rule) Reply(replies ...Reply) *Rule {
+	r.replies = append(r.replies, replies...)
+	return r
+}
+
+// Times limits the number of requests the rule answers.
+func (r *Rule) Times(n int) *Rule {
+	r.limit = n
+	return r
+}
+
+// take is called with the agent lock held.
+func (r *Rule) take(opts *union.Request) (Reply, bool) {
+	if len(r.replies) == 0 || (r.limit > 0 && r.served >= r.limit) {
+		return Reply{}, false
+	}
+	if r.pred != nil && !r.pred(opts) {
+		return Reply{}, false
+	}
+
+	i := r.served
+	if i >= len(r.replies) {
+		i = len(r.replies) - 1
+	}
+	r.served++
+
+	return r.replies[i], true
+}
+
+// Any matches every request.
+func Any() Predicate {
+	return func(*union.Request) bool { return true }
+}
+
+// ContainsText matches requests whose marshalled body contains s.
+func ContainsText(s string) Predicate {
+	return func(opts *union.Request) bool {
+		body, ok := marshal(opts)
+		if !ok {
+			return false
+		}
+		// compare against the JSON-escaped form, as it appears in the body
+		needle, _ := json.Marshal(s)
+		return bytes.Contains(body, needle[1:len(needle)-1])
+	}
+}
+
+// ModelIs matches requests for the given model.
+func ModelIs(model string) Predicate {
+	return func(opts *union.Request) bool {
+		body, ok := marshal(opts)
+		if !ok {
+			return false
+		}
+		var fields struct {
+			Model string `json:"model"`
+		}
+		return json.Unmarshal(body, &fields) == nil && fields.Model == model
+	}
+}
+
+// RequestIs matches requests whose TextRequest has the same type as v, e.g.
+// RequestIs(&cltypes.TextInputRequest{}).
+func RequestIs(v union.Requester) Predicate {
+	return func(opts *union.Request) bool {
+		return opts.TextRequest != nil && sameType(opts.TextRequest, v)
+	}
+}
+
+// All matches requests matching every predicate.
+func All(preds ...Predicate) Predicate {
+	return func(opts *union.Request) bool {
+		for _, p := range preds {
+			if !p(opts) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func marshal(opts *union.Request) ([]byte, bool) {
+	var r union.Requester
+	switch {
+	case opts.TextRequest != nil:
+		r = opts.TextRequest
+	case opts.Prompt != nil:
+		r = opts.Prompt
+	default:
+		return nil, false
+	}
+	body, err := r.Marshal()
+	return body, err == nil
+}
@@ -0,0 +1,341 @@
+package aitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultAPIKey is the credential the test servers accept unless Server.APIKey
+// is changed.
+const DefaultAPIKey = "aitest-key"
+
+// Server is a local stand-in for a provider's HTTP API, built on
+// httptest.Server. It implements the subset of the wire protocol the
+// provider clients use: authentication headers, JSON and SSE responses,
+// provider-shaped error bodies and rate-limit headers. Point a client at
+// it with ai.AIOpts{ApiToken: aitest.DefaultAPIKey, TextInputEndpoint:
+// srv.Endpoint()}.
+type Server struct {
+	*httptest.Server
+
+	// APIKey is the only credential the server accepts.
+	APIKey string
+	// Respond produces the answer to the last user message. Defaults to
+	// echoing it back.
+	Respond func(prompt string) string
+	// RequestLimit is the number of requests allowed per RateLimitWindow.
+	// Further requests get a 429. Zero means no limit; the rate-limit
+	// headers are sent either way.
+	RequestLimit    int
+	RateLimitWindow time.Duration
+
+	wire wire
+
+	mu          sync.Mutex
+	failures    []failure
+	requests    []ServerRequest
+	windowStart time.Time
+	windowCount int
+	seq         atomic.Int64
+}
+
+// ServerRequest is a request received by a Server.
+type ServerRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+type failure struct {
+	status int
+	times  int
+}
+
+// NewOpenAIServer starts a server emulating the OpenAI Responses API
+// (`/v1/responses`) with Bearer authentication.
+func NewOpenAIServer() *Server {
+	return newServer(openAIWire{})
+}
+
+// NewAnthropicServer starts a server emulating the Anthropic Messages API
+// (`/v1/messages`), which requires the `x-api-key` and `anthropic-version`
+// headers.
+func NewAnthropicServer() *Server {
+	return newServer(anthropicWire{})
+}
+
+// NewDeepSeekServer starts a server emulating the DeepSeek chat completions
+// API (`/chat/completions`) with Bearer authentication.
+func NewDeepSeekServer() *Server {
+	return newServer(chatCompletionsWire{})
+}
+
+// NewBedrockServer starts a server emulating Claude on AWS Bedrock
+// (`/model/{modelId}/invoke` and `/model/{modelId}/invoke-with-response-stream`,
+// which streams AWS event stream messages). APIKey is the AWS access key ID
+// requests must be signed with; the server checks the shape of the SigV4
+// Authorization header, not the signature. Endpoint returns the base URL.
+func NewBedrockServer() *Server {
+	return newServer(bedrockWire{})
+}
+
+// NewVertexServer starts a server emulating Claude on Google Vertex AI
+// (`:rawPredict` and `:streamRawPredict` under `/v1/projects/...`). APIKey
+// is the bearer token it accepts. Endpoint returns the base URL, ending in
+// `/v1`.
+func NewVertexServer() *Server {
+	return newServer(vertexWire{})
+}
+
+func newServer(w wire) *Server {
+	s := &Server{
+		APIKey:          DefaultAPIKey,
+		RateLimitWindow: time.Minute,
+		wire:            w,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Endpoint returns the full URL of the emulated endpoint.
+func (s *Server) Endpoint() string {
+	return s.URL + s.wire.path()
+}
+
+// FailNext makes the next n requests fail with the given status and a
+// provider-shaped error body.
+func (s *Server) FailNext(status, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{status: status, times: n})
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []ServerRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ServerRequest(nil), s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, ServerRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	s.mu.Unlock()
+
+	w.Header().Set(s.wire.requestIDHeader(), fmt.Sprintf("req_aitest_%d", s.seq.Add(1)))
+
+	route, routed := s.route(r.URL.Path)
+	if r.Method != http.MethodPost || !routed {
+		s.fail(w, http.StatusNotFound, "not_found_error", fmt.Sprintf("unknown endpoint %s %s", r.Method, r.URL.Path))
+		return
+	}
+	if status, errType, msg := s.wire.authorize(r, s.APIKey); status != 0 {
+		s.fail(w, status, errType, msg)
+		return
+	}
+
+	if limited := s.rateLimit(w); limited {
+		s.fail(w, http.StatusTooManyRequests, "rate_limit_error", "rate limit exceeded")
+		return
+	}
+	if status := s.nextFailure(); status != 0 {
+		s.fail(w, status, errorType(status), http.StatusText(status))
+		return
+	}
+
+	p, err := s.wire.decode(body)
+	if err != nil {
+		s.fail(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if _, ok := s.wire.(urlWire); ok {
+		p.model, p.stream = route.model, route.stream
+	}
+
+	respond := s.Respond
+	if respond == nil {
+		respond = func(prompt string) string { return prompt }
+	}
+	text := respond(p.text)
+
+	if p.stream {
+		contentType := "text/event-stream"
+		if uw, ok := s.wire.(urlWire); ok {
+			contentType = uw.streamContentType()
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		s.wire.stream(w, p, text)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(s.wire.response(p, text))
+}
+
+// route matches the path of a request against the emulated endpoint.
+func (s *Server) route(path string) (route, bool) {
+	if uw, ok := s.wire.(urlWire); ok {
+		return uw.route(path)
+	}
+	return route{}, path == s.wire.path()
+}
+
+// rateLimit counts the request against the current window, writes the
+// rate-limit headers and reports whether the request is over the limit.
+func (s *Server) rateLimit(w http.ResponseWriter) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	window := s.RateLimitWindow
+	if window <= 0 {
+		window = time.Minute
+	}
+	if s.windowStart.IsZero() || now.Sub(s.windowStart) >= window {
+		s.windowStart = now
+		s.windowCount = 0
+	}
+	s.windowCount++
+
+	limit := s.RequestLimit
+	if limit <= 0 {
+		limit = 1_000_000
+	}
+	remaining := limit - s.windowCount
+	if remaining < 0 {
+		remaining = 0
+	}
+	reset := s.windowStart.Add(window)
+	s.wire.rateLimitHeaders(w.Header(), limit, remaining, reset.Sub(now), reset)
+
+	if s.RequestLimit > 0 && s.windowCount > s.RequestLimit {
+		retry := int(reset.Sub(now).Seconds()) + 1
+		w.Header().Set("retry-after", fmt.Sprint(retry))
+		return true
+	}
+	return false
+}
+
+func (s *Server) nextFailure() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.failures) == 0 {
+		return 0
+	}
+	f := &s.failures[0]
+	f.times--
+	status := f.status
+	if f.times <= 0 {
+		s.failures = s.failures[1:]
+	}
+	return status
+}
+
+func (s *Server) fail(w http.ResponseWriter, status int, errType, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(s.wire.errorBody(status, errType, msg))
+}
+
+func errorType(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "authentication_error"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status == 529:
+		return "overloaded_error"
+	case status >= 500:
+		return "api_error"
+	default:
+		return "invalid_request_error"
+	}
+}
+
+// prompt is the part of a request the servers care about.
+type prompt struct {
+	model  string
+	text   string
+	stream bool
+}
+
+// wire implements the specifics of one provider API.
+type wire interface {
+	path() string
+	requestIDHeader() string
+	// authorize returns a non-zero status when the request is rejected.
+	authorize(r *http.Request, key string) (status int, errType, msg string)
+	decode(body []byte) (prompt, error)
+	response(p prompt, text string) []byte
+	stream(w io.Writer, p prompt, text string)
+	errorBody(status int, errType, msg string) []byte
+	rateLimitHeaders(h http.Header, limit, remaining int, resetIn time.Duration, resetAt time.Time)
+}
+
+// route is what the URL of a request says about it.
+type route struct {
+	model  string
+	stream bool
+}
+
+// urlWire is implemented by the wires of APIs that take the model and the
+// choice to stream from the URL rather than the body. For them, path is the
+// prefix Endpoint returns.
+type urlWire interface {
+	route(path string) (route, bool)
+	streamContentType() string
+}
+
+func bearer(r *http.Request, key string) (int, string, string) {
+	if r.Header.Get("Authorization") != "Bearer "+key {
+		return http.StatusUnauthorized, "invalid_request_error", "Incorrect API key provided"
+	}
+	return 0, "", ""
+}
+
+// chunks splits text into word-sized stream deltas, keeping the spaces.
+func chunks(text string) []string {
+	var out []string
+	for len(text) > 0 {
+		i := strings.IndexByte(text[1:], ' ')
+		if i < 0 {
+			out = append(out, text)
+			break
+		}
+		out = append(out, text[:i+1])
+		text = text[i+1:]
+	}
+	return out
+}
+
+// tokens is the servers' stand-in for a tokenizer: one token per word.
+func tokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+func mustJSON(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
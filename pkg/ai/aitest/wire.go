@@ -0,0 +1,467 @@
+package aitest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/eventstream"
+	"github.com/muraduiurie/gpt/pkg/ai/sse"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	cltypes "github.com/muraduiurie/gpt/pkg/ai/types/claude"
+	dstypes "github.com/muraduiurie/gpt/pkg/ai/types/deepseek"
+)
+
+// openAIWire emulates POST /v1/responses.
+type openAIWire struct{}
+
+func (openAIWire) path() string            { return "/v1/responses" }
+func (openAIWire) requestIDHeader() string { return "x-request-id" }
+
+func (openAIWire) authorize(r *http.Request, key string) (int, string, string) {
+	return bearer(r, key)
+}
+
+func (openAIWire) decode(body []byte) (prompt, error) {
+	var req cgtypes.TextInputRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return prompt{}, err
+	}
+	if req.Input == "" {
+		return prompt{}, errors.New("'input' is required")
+	}
+	return prompt{model: string(req.Model), text: req.Input, stream: req.Stream}, nil
+}
+
+func (openAIWire) textResponse(p prompt, text string) *cgtypes.TextInputResponse {
+	in, out := tokens(p.text), tokens(text)
+	return &cgtypes.TextInputResponse{
+		Id:        "resp_aitest",
+		Object:    "response",
+		CreatedAt: int(time.Now().Unix()),
+		Status:    "completed",
+		Model:     cgtypes.ChatGPTAIModel(p.model),
+		Output: []cgtypes.TextInputResponseOutput{{
+			Type:   "message",
+			Id:     "msg_aitest",
+			Status: "completed",
+			Role:   cgtypes.ChatGPTAIRoleAssistant,
+			Content: []cgtypes.TextInputResponseOutputContent{{
+				Type:        "output_text",
+				Text:        text,
+				Annotations: []interface{}{},
+			}},
+		}},
+		Usage: cgtypes.ResponseUsage{
+			InputTokens:  in,
+			OutputTokens: out,
+			TotalTokens:  in + out,
+		},
+	}
+}
+
+func (w openAIWire) response(p prompt, text string) []byte {
+	return mustJSON(w.textResponse(p, text))
+}
+
+func (w openAIWire) stream(out io.Writer, p prompt, text string) {
+	send := func(typ string, v map[string]interface{}) {
+		v["type"] = typ
+		sse.Write(out, sse.Event{Event: typ, Data: mustJSON(v)})
+	}
+
+	resp := w.textResponse(p, text)
+	created := *resp
+	created.Status = "in_progress"
+	created.Output = nil
+	send("response.created", map[string]interface{}{"response": created})
+	for _, c := range chunks(text) {
+		send("response.output_text.delta", map[string]interface{}{
+			"item_id":       "msg_aitest",
+			"output_index":  0,
+			"content_index": 0,
+			"delta":         c,
+		})
+	}
+	send("response.output_text.done", map[string]interface{}{"text": text})
+	send("response.completed", map[string]interface{}{"response": resp})
+}
+
+func (openAIWire) errorBody(status int, errType, msg string) []byte {
+	return mustJSON(map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": msg,
+			"type":    errType,
+			"param":   nil,
+			"code":    nil,
+		},
+	})
+}
+
+func (openAIWire) rateLimitHeaders(h http.Header, limit, remaining int, resetIn time.Duration, _ time.Time) {
+	h.Set("x-ratelimit-limit-requests", fmt.Sprint(limit))
+	h.Set("x-ratelimit-remaining-requests", fmt.Sprint(remaining))
+	h.Set("x-ratelimit-reset-requests", resetIn.Round(time.Millisecond).String())
+	h.Set("openai-processing-ms", "1")
+}
+
+// anthropicWire emulates POST /v1/messages.
+type anthropicWire struct{}
+
+func (anthropicWire) path() string            { return "/v1/messages" }
+func (anthropicWire) requestIDHeader() string { return "request-id" }
+
+func (anthropicWire) authorize(r *http.Request, key string) (int, string, string) {
+	if r.Header.Get("x-api-key") != key {
+		return http.StatusUnauthorized, "authentication_error", "invalid x-api-key"
+	}
+	if r.Header.Get("anthropic-version") == "" {
+		return http.StatusBadRequest, "invalid_request_error", "anthropic-version: header is required"
+	}
+	return 0, "", ""
+}
+
+func (anthropicWire) decode(body []byte) (prompt, error) {
+	var req cltypes.TextInputRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return prompt{}, err
+	}
+	if req.MaxTokens == 0 {
+		return prompt{}, errors.New("max_tokens: Field required")
+	}
+	if len(req.Messages) == 0 {
+		return prompt{}, errors.New("messages: Field required")
+	}
+	last := req.Messages[len(req.Messages)-1]
+	return prompt{model: string(req.Model), text: last.Content, stream: req.Stream}, nil
+}
+
+func (anthropicWire) message(p prompt, text string) *cltypes.TextInputResponse {
+	return &cltypes.TextInputResponse{
+		Id:         "msg_aitest",
+		Type:       "message",
+		Role:       cltypes.ClaudeAIRoleAssistant,
+		Model:      cltypes.ClaudeAIModel(p.model),
+		Content:    []cltypes.TextInputResponseContent{{Type: "text", Text: text}},
+		StopReason: "end_turn",
+		Usage: cltypes.TextInputResponseUsage{
+			InputTokens:  tokens(p.text),
+			OutputTokens: tokens(text),
+			ServiceTier:  "standard",
+		},
+	}
+}
+
+func (w anthropicWire) response(p prompt, text string) []byte {
+	return mustJSON(w.message(p, text))
+}
+
+func (w anthropicWire) stream(out io.Writer, p prompt, text string) {
+	w.events(p, text, func(typ string, v map[string]interface{}) {
+		sse.Write(out, sse.Event{Event: typ, Data: mustJSON(v)})
+	})
+}
+
+// events produces the stream events of a message, in order.
+func (w anthropicWire) events(p prompt, text string, emit func(typ string, v map[string]interface{})) {
+	send := func(typ string, v map[string]interface{}) {
+		v["type"] = typ
+		emit(typ, v)
+	}
+
+	msg := w.message(p, text)
+	start := *msg
+	start.Content = []cltypes.TextInputResponseContent{}
+	start.StopReason = ""
+	start.Usage.OutputTokens = 1
+	send("message_start", map[string]interface{}{"message": start})
+	send("content_block_start", map[string]interface{}{
+		"index":         0,
+		"content_block": map[string]string{"type": "text", "text": ""},
+	})
+	send("ping", map[string]interface{}{})
+	for _, c := range chunks(text) {
+		send("content_block_delta", map[string]interface{}{
+			"index": 0,
+			"delta": map[string]string{"type": "text_delta", "text": c},
+		})
+	}
+	send("content_block_stop", map[string]interface{}{"index": 0})
+	send("message_delta", map[string]interface{}{
+		"delta": map[string]interface{}{"stop_reason": msg.StopReason, "stop_sequence": nil},
+		"usage": map[string]int{"output_tokens": msg.Usage.OutputTokens},
+	})
+	send("message_stop", map[string]interface{}{})
+}
+
+func (anthropicWire) errorBody(_ int, errType, msg string) []byte {
+	return mustJSON(map[string]interface{}{
+		"type": "error",
+		"error": map[string]string{
+			"type":    errType,
+			"message": msg,
+		},
+	})
+}
+
+func (anthropicWire) rateLimitHeaders(h http.Header, limit, remaining int, _ time.Duration, resetAt time.Time) {
+	h.Set("anthropic-ratelimit-requests-limit", fmt.Sprint(limit))
+	h.Set("anthropic-ratelimit-requests-remaining", fmt.Sprint(remaining))
+	h.Set("anthropic-ratelimit-requests-reset", resetAt.UTC().Format(time.RFC3339))
+}
+
+// chatCompletionsWire emulates POST /chat/completions as served by DeepSeek.
+type chatCompletionsWire struct{}
+
+func (chatCompletionsWire) path() string            { return "/chat/completions" }
+func (chatCompletionsWire) requestIDHeader() string { return "x-request-id" }
+
+func (chatCompletionsWire) authorize(r *http.Request, key string) (int, string, string) {
+	if status, _, _ := bearer(r, key); status != 0 {
+		return status, "authentication_error", "Authentication Fails, Your api key is invalid"
+	}
+	return 0, "", ""
+}
+
+func (chatCompletionsWire) decode(body []byte) (prompt, error) {
+	var req dstypes.TextInputRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return prompt{}, err
+	}
+	if len(req.Messages) == 0 {
+		return prompt{}, errors.New("messages: field required")
+	}
+	last := req.Messages[len(req.Messages)-1]
+	return prompt{model: string(req.Model), text: last.Content, stream: req.Stream}, nil
+}
+
+func (chatCompletionsWire) usage(p prompt, text string) dstypes.TextInputResponseUsage {
+	in, out := tokens(p.text), tokens(text)
+	return dstypes.TextInputResponseUsage{
+		PromptTokens:          in,
+		CompletionTokens:      out,
+		TotalTokens:           in + out,
+		PromptCacheMissTokens: in,
+	}
+}
+
+func (w chatCompletionsWire) response(p prompt, text string) []byte {
+	return mustJSON(&dstypes.TextInputResponse{
+		Id:      "chatcmpl-aitest",
+		Object:  "chat.completion",
+		Created: int(time.Now().Unix()),
+		Model:   dstypes.DeepSeekAIModel(p.model),
+		Choices: []dstypes.TextInputResponseChoice{{
+			Message: dstypes.TextInputResponseChoiceMessage{
+				Role:    dstypes.DeepSeekAIRoleAssistant,
+				Content: text,
+			},
+			FinishReason: "stop",
+		}},
+		Usage: w.usage(p, text),
+	})
+}
+
+func (w chatCompletionsWire) stream(out io.Writer, p prompt, text string) {
+	created := time.Now().Unix()
+	send := func(delta map[string]string, finish interface{}, usage interface{}) {
+		choices := []interface{}{}
+		if delta != nil {
+			choices = append(choices, map[string]interface{}{
+				"index":         0,
+				"delta":         delta,
+				"finish_reason": finish,
+			})
+		}
+		sse.Write(out, sse.Event{Data: mustJSON(map[string]interface{}{
+			"id":      "chatcmpl-aitest",
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   p.model,
+			"choices": choices,
+			"usage":   usage,
+		})})
+	}
+
+	send(map[string]string{"role": "assistant", "content": ""}, nil, nil)
+	for _, c := range chunks(text) {
+		send(map[string]string{"content": c}, nil, nil)
+	}
+	send(map[string]string{"content": ""}, "stop", nil)
+	send(nil, nil, w.usage(p, text))
+	sse.Write(out, sse.Event{Data: []byte("[DONE]")})
+}
+
+func (chatCompletionsWire) errorBody(_ int, errType, msg string) []byte {
+	return mustJSON(map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": msg,
+			"type":    errType,
+			"param":   nil,
+			"code":    "invalid_request_error",
+		},
+	})
+}
+
+func (chatCompletionsWire) rateLimitHeaders(h http.Header, limit, remaining int, resetIn time.Duration, _ time.Time) {
+	h.Set("x-ratelimit-limit-requests", fmt.Sprint(limit))
+	h.Set("x-ratelimit-remaining-requests", fmt.Sprint(remaining))
+	h.Set("x-ratelimit-reset-requests", resetIn.Round(time.Millisecond).String())
+}
+
+// bedrockWire emulates Claude on AWS Bedrock: POST
+// /model/{modelId}/invoke and /model/{modelId}/invoke-with-response-stream.
+type bedrockWire struct{ anthropicWire }
+
+func (bedrockWire) path() string            { return "" }
+func (bedrockWire) requestIDHeader() string { return "x-amzn-RequestId" }
+
+func (bedrockWire) route(path string) (route, bool) {
+	rest, ok := strings.CutPrefix(path, "/model/")
+	if !ok {
+		return route{}, false
+	}
+	if model, ok := strings.CutSuffix(rest, "/invoke"); ok {
+		return route{model: model}, true
+	}
+	if model, ok := strings.CutSuffix(rest, "/invoke-with-response-stream"); ok {
+		return route{model: model, stream: true}, true
+	}
+	return route{}, false
+}
+
+func (bedrockWire) streamContentType() string { return "application/vnd.amazon.eventstream" }
+
+// authorize checks the shape of the SigV4 signature and that it was made
+// with the access key ID key for the bedrock service; the signature itself
+// is not verified.
+func (bedrockWire) authorize(r *http.Request, key string) (int, string, string) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential="+key+"/") ||
+		!strings.Contains(auth, "/bedrock/aws4_request") ||
+		!strings.Contains(auth, "Signature=") ||
+		r.Header.Get("X-Amz-Date") == "" {
+		return http.StatusForbidden, "UnrecognizedClientException", "The security token included in the request is invalid."
+	}
+	return 0, "", ""
+}
+
+func (w bedrockWire) decode(body []byte) (prompt, error) {
+	return platformDecode(body, "bedrock-2023-05-31", "model", "stream")
+}
+
+func (w bedrockWire) stream(out io.Writer, p prompt, text string) {
+	w.events(p, text, func(typ string, v map[string]interface{}) {
+		eventstream.Write(out, eventstream.Message{
+			Headers: map[string]string{
+				":event-type":   "chunk",
+				":content-type": "application/json",
+				":message-type": "event",
+			},
+			Payload: mustJSON(map[string][]byte{"bytes": mustJSON(v)}),
+		})
+	})
+}
+
+func (bedrockWire) errorBody(_ int, _, msg string) []byte {
+	return mustJSON(map[string]string{"message": msg})
+}
+
+func (bedrockWire) rateLimitHeaders(http.Header, int, int, time.Duration, time.Time) {}
+
+// vertexWire emulates Claude on Google Vertex AI: POST
+// /v1/projects/{project}/locations/{region}/publishers/anthropic/models/{model}:rawPredict
+// and :streamRawPredict.
+type vertexWire struct{ anthropicWire }
+
+func (vertexWire) path() string            { return "/v1" }
+func (vertexWire) requestIDHeader() string { return "x-request-id" }
+
+func (vertexWire) route(path string) (route, bool) {
+	rest, ok := strings.CutPrefix(path, "/v1/projects/")
+	if !ok {
+		return route{}, false
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 7 || parts[1] != "locations" || parts[3] != "publishers" || parts[4] != "anthropic" || parts[5] != "models" {
+		return route{}, false
+	}
+	model, method, ok := strings.Cut(parts[6], ":")
+	switch {
+	case !ok:
+		return route{}, false
+	case method == "rawPredict":
+		return route{model: model}, true
+	case method == "streamRawPredict":
+		return route{model: model, stream: true}, true
+	}
+	return route{}, false
+}
+
+func (vertexWire) streamContentType() string { return "text/event-stream" }
+
+func (vertexWire) authorize(r *http.Request, key string) (int, string, string) {
+	if r.Header.Get("Authorization") != "Bearer "+key {
+		return http.StatusUnauthorized, "UNAUTHENTICATED", "Request had invalid authentication credentials."
+	}
+	return 0, "", ""
+}
+
+func (vertexWire) decode(body []byte) (prompt, error) {
+	return platformDecode(body, "vertex-2023-10-16", "model")
+}
+
+func (vertexWire) errorBody(status int, _, msg string) []byte {
+	return mustJSON(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": msg,
+			"status":  googleStatus(status),
+		},
+	})
+}
+
+func (vertexWire) rateLimitHeaders(http.Header, int, int, time.Duration, time.Time) {}
+
+// platformDecode decodes a Messages API request as sent to Bedrock or
+// Vertex: with the given anthropic_version and without the forbidden keys.
+func platformDecode(body []byte, version string, forbidden ...string) (prompt, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return prompt{}, err
+	}
+	for _, k := range forbidden {
+		if _, ok := keys[k]; ok {
+			return prompt{}, fmt.Errorf("extraneous key [%s] is not permitted", k)
+		}
+	}
+	var v string
+	json.Unmarshal(keys["anthropic_version"], &v)
+	if v != version {
+		return prompt{}, fmt.Errorf("anthropic_version: expected %q, got %q", version, v)
+	}
+	return anthropicWire{}.decode(body)
+}
+
+func googleStatus(status int) string {
+	switch {
+	case status == http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case status == http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case status == http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case status == http.StatusNotFound:
+		return "NOT_FOUND"
+	case status == http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case status == http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	default:
+		return "INTERNAL"
+	}
+}
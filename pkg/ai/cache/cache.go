@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Agent is the subset of ai.AIAgent the cache decorates.
+type Agent interface {
+	AskAI(opts *union.Request) (*union.Response, error)
+}
+
+// Entry is a cached response. TextResponse is stored as JSON together with
+// its Go type name so it can be decoded back into the provider type.
+type Entry struct {
+	Provider  string          `json:"provider"`
+	Type      string          `json:"type"`
+	Body      json.RawMessage `json:"body"`
+	Output    string          `json:"output,omitempty"`
+	Finish    string          `json:"finish_reason,omitempty"`
+	Usage     *union.Usage    `json:"usage,omitempty"`
+	Metadata  *union.Metadata `json:"metadata,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	ExpiresAt time.Time       `json:"expires_at,omitzero"`
+}
+
+// Expired reports whether the entry is past its TTL.
+func (e *Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
+
+// Store persists cache entries.
+type Store interface {
+	// Get returns the entry for key; ok is false on a miss.
+	Get(key string) (e *Entry, ok bool, err error)
+	Set(key string, e *Entry) error
+	Delete(key string) error
+}
+
+type Options struct {
+	// TTL is how long an entry stays valid. Zero means entries never expire.
+	TTL time.Duration
+	// CacheNonDeterministic caches requests that sample: those with a
+	// temperature above zero, or with none, as providers default to 1. By
+	// default only requests with a temperature of zero are cached.
+	CacheNonDeterministic bool
+	// Provider and Endpoint override the key parts normally read from the
+	// agent's Provider() and Endpoint() methods.
+	Provider string
+	Endpoint string
+	// DefaultModel overrides the agent's DefaultModel method, which returns
+	// the model a request without one is sent to. It is part of the key so
+	// that a change of default model is not served stale answers.
+	DefaultModel func(opts *union.Request) string
+	// OnError is called when the store fails. The request then bypasses the
+	// cache rather than failing.
+	OnError func(err error)
+}
+
+// Cache is an AIAgent decorator that serves identical requests from a Store.
+// Requests are keyed on a hash of the provider, the endpoint and the
+// canonical JSON of the marshalled request body, with the default model
+// filled in.
+type Cache struct {
+	next  Agent
+	store Store
+	opts  Options
+}
+
+// New wraps next with a cache backed by store.
+func New(next Agent, store Store, opts Options) *Cache {
+	if opts.Provider == "" {
+		if p, ok := next.(interface{ Provider() string }); ok {
+			opts.Provider = p.Provider()
+		}
+	}
+	if opts.Endpoint == "" {
+		if e, ok := next.(interface{ Endpoint() string }); ok {
+			opts.Endpoint = e.Endpoint()
+		}
+	}
+	if opts.DefaultModel == nil {
+		if m, ok := next.(interface{ DefaultModel(*union.Request) string }); ok {
+			opts.DefaultModel = m.DefaultModel
+		}
+	}
+
+	return &Cache{
+		next:  next,
+		store: store,
+		opts:  opts,
+	}
+}
+
+// Provider returns the provider name of the wrapped agent.
+func (c *Cache) Provider() string {
+	return c.opts.Provider
+}
+
+// AskAI returns the cached response for the request if there is a valid
+// one, and otherwise forwards the request and caches a successful answer.
+// Cached responses have Metadata.Cached set. Store failures are reported to
+// OnError and do not fail the request.
+func (c *Cache) AskAI(opts *union.Request) (*union.Response, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+
+	body, err := requestBody(opts)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := c.canonicalRequest(opts, body)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize request: %w", err)
+	}
+	if !c.opts.CacheNonDeterministic && nonDeterministic(canonical) {
+		return c.next.AskAI(opts)
+	}
+
+	key := c.key(canonical)
+	e, ok, err := c.store.Get(key)
+	if err != nil {
+		c.onError(fmt.Errorf("cache get: %w", err))
+		return c.next.AskAI(opts)
+	}
+	if ok && !e.Expired(time.Now()) {
+		resp, err := e.response()
+		if err == nil {
+			return resp, nil
+		}
+		// undecodable entries are dropped and refreshed
+		_ = c.store.Delete(key)
+	}
+
+	resp, err := c.next.AskAI(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if e, err = newEntry(resp, c.opts.TTL); err != nil {
+		c.onError(err)
+	} else if err = c.store.Set(key, e); err != nil {
+		c.onError(fmt.Errorf("cache set: %w", err))
+	}
+
+	return resp, nil
+}
+
+func (c *Cache) onError(err error) {
+	if c.opts.OnError != nil {
+		c.opts.OnError(err)
+	}
+}
+
+// canonicalRequest returns the canonical JSON of the request body, with
+// the default model of the agent filled in when the request has none.
+func (c *Cache) canonicalRequest(opts *union.Request, body []byte) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	if fields, ok := v.(map[string]interface{}); ok && c.opts.DefaultModel != nil {
+		if model, _ := fields["model"].(string); model == "" {
+			if model = c.opts.DefaultModel(opts); model != "" {
+				fields["model"] = model
+			}
+		}
+	}
+	return json.Marshal(v)
+}
+
+func (c *Cache) key(canonical []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.opts.Provider))
+	h.Write([]byte{0})
+	h.Write([]byte(c.opts.Endpoint))
+	h.Write([]byte{0})
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func requestBody(opts *union.Request) ([]byte, error) {
+	var r union.Requester
+	switch {
+	case opts.TextRequest != nil:
+		r = opts.TextRequest
+	case opts.Prompt != nil:
+		r = opts.Prompt
+	default:
+		return nil, errors.New("request has neither TextRequest nor Prompt")
+	}
+
+	body, err := r.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	return body, nil
+}
+
+// nonDeterministic reports whether the request samples: its temperature,
+// at the top level, in the generationConfig of Gemini requests or in the
+// options of Ollama requests, is above zero or unset.
+func nonDeterministic(canonical []byte) bool {
+	var fields struct {
+		Temperature      *float64 `json:"temperature"`
+		GenerationConfig struct {
+			Temperature *float64 `json:"temperature"`
+		} `json:"generationConfig"`
+		Options struct {
+			Temperature *float64 `json:"temperature"`
+		} `json:"options"`
+	}
+	if err := json.Unmarshal(canonical, &fields); err != nil {
+		return true
+	}
+	for _, t := range []*float64{fields.Temperature, fields.GenerationConfig.Temperature, fields.Options.Temperature} {
+		if t != nil {
+			return *t > 0
+		}
+	}
+	return true
+}
@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	cltypes "github.com/muraduiurie/gpt/pkg/ai/types/claude"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func init() {
+	RegisterResponse(func() union.Responser { return &cltypes.TextInputResponse{} })
+}
+
+func request(model string, temperature *float64) *union.Request {
+	return &union.Request{TextRequest: &cltypes.TextInputRequest{
+		Model:       cltypes.ClaudeAIModel(model),
+		Temperature: temperature,
+		Messages:    []cltypes.TextInputRequestMessage{{Role: cltypes.ClaudeAIRoleUser, Content: "hello"}},
+	}}
+}
+
+func zero() *float64 {
+	t := 0.0
+	return &t
+}
+
+// modelAgent is an agent with a default model.
+type modelAgent struct {
+	*aitest.Agent
+	model string
+}
+
+func (a *modelAgent) DefaultModel(opts *union.Request) string {
+	return a.model
+}
+
+func TestCacheMissThenHit(t *testing.T) {
+	next := aitest.New().Enqueue(aitest.ClaudeText("hi"), aitest.ClaudeText("again"))
+	c := New(next, NewMemoryStore(0), Options{})
+
+	first, err := c.AskAI(request("claude-sonnet-4-20250514", zero()))
+	if err != nil {
+		t.Fatalf("first AskAI: %v", err)
+	}
+	if first.Metadata.Cached {
+		t.Error("first response is marked cached")
+	}
+
+	second, err := c.AskAI(request("claude-sonnet-4-20250514", zero()))
+	if err != nil {
+		t.Fatalf("second AskAI: %v", err)
+	}
+	if !second.Metadata.Cached {
+		t.Error("second response is not marked cached")
+	}
+	if second.Output != "hi" || second.Provider != "claude" {
+		t.Errorf("cached response = %q from %q, want hi from claude", second.Output, second.Provider)
+	}
+	if _, ok := second.TextResponse.(*cltypes.TextInputResponse); !ok {
+		t.Errorf("TextResponse = %T, want *cltypes.TextInputResponse", second.TextResponse)
+	}
+	if n := len(next.Requests()); n != 1 {
+		t.Errorf("agent got %d requests, want 1", n)
+	}
+}
+
+func TestCacheKeysOnRequest(t *testing.T) {
+	next := aitest.New().Enqueue(aitest.ClaudeText("a"), aitest.ClaudeText("b"))
+	c := New(next, NewMemoryStore(0), Options{})
+
+	if _, err := c.AskAI(request("model-a", zero())); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.AskAI(request("model-b", zero()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Metadata.Cached || resp.Output != "b" {
+		t.Errorf("response = %q (cached %v), want a fresh b", resp.Output, resp.Metadata.Cached)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	next := aitest.New().Enqueue(aitest.ClaudeText("old"), aitest.ClaudeText("new"))
+	c := New(next, NewMemoryStore(0), Options{TTL: 20 * time.Millisecond})
+
+	if _, err := c.AskAI(request("m", zero())); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	resp, err := c.AskAI(request("m", zero()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Metadata.Cached || resp.Output != "new" {
+		t.Errorf("response = %q (cached %v), want a fresh answer after the TTL", resp.Output, resp.Metadata.Cached)
+	}
+}
+
+func TestCacheSkipsNonDeterministic(t *testing.T) {
+	warm := 0.7
+	for name, temperature := range map[string]*float64{"unset": nil, "above zero": &warm} {
+		t.Run(name, func(t *testing.T) {
+			next := aitest.New().Enqueue(aitest.ClaudeText("a"), aitest.ClaudeText("b"))
+			c := New(next, NewMemoryStore(0), Options{})
+
+			for range 2 {
+				if _, err := c.AskAI(request("m", temperature)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if n := len(next.Requests()); n != 2 {
+				t.Errorf("agent got %d requests, want 2", n)
+			}
+		})
+	}
+}
+
+func TestCacheNonDeterministicOption(t *testing.T) {
+	next := aitest.New().Enqueue(aitest.ClaudeText("a"))
+	c := New(next, NewMemoryStore(0), Options{CacheNonDeterministic: true})
+
+	for range 2 {
+		if _, err := c.AskAI(request("m", nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(next.Requests()); n != 1 {
+		t.Errorf("agent got %d requests, want 1", n)
+	}
+}
+
+func TestCacheDefaultModel(t *testing.T) {
+	store := NewMemoryStore(0)
+	next := &modelAgent{Agent: aitest.New().Enqueue(aitest.ClaudeText("a"), aitest.ClaudeText("b")), model: "model-a"}
+
+	// a request naming the default model shares the entry of one without
+	if _, err := New(next, store, Options{}).AskAI(request("", zero())); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := New(next, store, Options{}).AskAI(request("model-a", zero()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Metadata.Cached {
+		t.Error("request naming the default model missed the cache")
+	}
+
+	// a new default model is not served the answers of the old one
+	next.model = "model-b"
+	resp, err = New(next, store, Options{}).AskAI(request("", zero()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Metadata.Cached {
+		t.Error("request was served the answer of the previous default model")
+	}
+}
+
+func TestCacheDoesNotStoreErrors(t *testing.T) {
+	next := aitest.New().Enqueue(
+		aitest.Error(aitest.APIError(http.StatusInternalServerError, "boom")),
+		aitest.ClaudeText("hi"),
+	)
+	c := New(next, NewMemoryStore(0), Options{})
+
+	if _, err := c.AskAI(request("m", zero())); err == nil {
+		t.Fatal("error was not returned")
+	}
+	resp, err := c.AskAI(request("m", zero()))
+	if err != nil || resp.Metadata.Cached {
+		t.Errorf("AskAI = %v, %v; want a fresh answer", resp, err)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Get(string) (*Entry, bool, error) { return nil, false, errors.New("get failed") }
+func (failingStore) Set(string, *Entry) error         { return errors.New("set failed") }
+func (failingStore) Delete(string) error              { return errors.New("delete failed") }
+
+func TestCacheBypassesFailingStore(t *testing.T) {
+	next := aitest.New().Enqueue(aitest.ClaudeText("hi"))
+	var errs []error
+	c := New(next, failingStore{}, Options{OnError: func(err error) { errs = append(errs, err) }})
+
+	resp, err := c.AskAI(request("m", zero()))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "hi" {
+		t.Errorf("Output = %q, want hi", resp.Output)
+	}
+	if len(errs) != 1 {
+		t.Errorf("OnError got %v, want the get error", errs)
+	}
+}
+
+type setFailingStore struct {
+	*MemoryStore
+}
+
+func (setFailingStore) Set(string, *Entry) error { return errors.New("set failed") }
+
+func TestCacheReturnsResponseWhenSetFails(t *testing.T) {
+	next := aitest.New().Enqueue(aitest.ClaudeText("hi"))
+	var errs []error
+	c := New(next, setFailingStore{NewMemoryStore(0)}, Options{OnError: func(err error) { errs = append(errs, err) }})
+
+	resp, err := c.AskAI(request("m", zero()))
+	if err != nil || resp.Output != "hi" {
+		t.Fatalf("AskAI = %v, %v; want hi", resp, err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("OnError got %v, want the set error", errs)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := aitest.New().Enqueue(aitest.ClaudeText("hi"))
+	if _, err = New(next, store, Options{}).AskAI(request("m", zero())); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new store over the same directory serves the entry
+	store, err = NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := New(aitest.New(), store, Options{}).AskAI(request("m", zero()))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if !resp.Metadata.Cached || resp.Output != "hi" {
+		t.Errorf("response = %q (cached %v), want the cached hi", resp.Output, resp.Metadata.Cached)
+	}
+}
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewMemoryStore(2)
+	for _, k := range []string{"a", "b"} {
+		if err := s.Set(k, &Entry{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok, _ := s.Get("a"); !ok {
+		t.Fatal("a missing")
+	}
+	if err := s.Set("c", &Entry{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, _ := s.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	if _, ok, _ := s.Get("a"); !ok {
+		t.Error("a was evicted although recently used")
+	}
+}
+
+func TestCacheCopiesUsage(t *testing.T) {
+	next := aitest.New().Enqueue(aitest.ClaudeText("hi"))
+	c := New(next, NewMemoryStore(0), Options{})
+
+	first, err := c.AskAI(request("m", zero()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := *first.Usage
+	first.Usage.InputTokens = 1000
+	first.Metadata.RequestID = "changed"
+
+	second, err := c.AskAI(request("m", zero()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *second.Usage != want || second.Metadata.RequestID == "changed" {
+		t.Errorf("hit = %+v %+v, want the response as stored", *second.Usage, *second.Metadata)
+	}
+	second.Usage.OutputTokens = 1000
+
+	third, err := c.AskAI(request("m", zero()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *third.Usage != want {
+		t.Errorf("hit Usage = %+v, want %+v unchanged by the previous hit", *third.Usage, want)
+	}
+}
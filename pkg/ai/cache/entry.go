@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+var (
+	typesMu sync.RWMutex
+	types   = map[string]func() union.Responser{}
+)
+
+// RegisterResponse makes a response type decodable from the cache. Each
+// provider package registers the response types of its client.
+func RegisterResponse(newFn func() union.Responser) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	types[typeName(newFn())] = newFn
+}
+
+func typeName(r union.Responser) string {
+	return reflect.TypeOf(r).String()
+}
+
+func newEntry(resp *union.Response, ttl time.Duration) (*Entry, error) {
+	body, err := json.Marshal(resp.TextResponse)
+	if err != nil {
+		return nil, fmt.Errorf("marshal response: %w", err)
+	}
+
+	now := time.Now()
+	e := &Entry{
+		Provider:  resp.Provider,
+		Type:      typeName(resp.TextResponse),
+		Body:      body,
+		Output:    resp.Output,
+		Finish:    resp.FinishReason,
+		Usage:     copyUsage(resp.Usage),
+		CreatedAt: now,
+	}
+	if resp.Metadata != nil {
+		meta := *resp.Metadata
+		e.Metadata = &meta
+	}
+	if ttl > 0 {
+		e.ExpiresAt = now.Add(ttl)
+	}
+
+	return e, nil
+}
+
+func (e *Entry) response() (*union.Response, error) {
+	typesMu.RLock()
+	newFn, ok := types[e.Type]
+	typesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unregistered response type %s", e.Type)
+	}
+
+	textResponse := newFn()
+	if err := textResponse.Unmarshal(e.Body); err != nil {
+		return nil, fmt.Errorf("unmarshal cached response: %w", err)
+	}
+
+	resp := &union.Response{
+		TextResponse: textResponse,
+		Output:       e.Output,
+		FinishReason: e.Finish,
+		Usage:        copyUsage(e.Usage),
+		Provider:     e.Provider,
+	}
+	if e.Metadata != nil {
+		meta := *e.Metadata
+		meta.Cached = true
+		resp.Metadata = &meta
+	} else {
+		resp.Metadata = &union.Metadata{Cached: true}
+	}
+
+	return resp, nil
+}
+
+// copyUsage returns a copy of u, so that entries share no usage with the
+// responses they are stored from or served as.
+func copyUsage(u *union.Usage) *union.Usage {
+	if u == nil {
+		return nil
+	}
+	c := *u
+	return &c
+}
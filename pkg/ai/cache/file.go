@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore is a Store that keeps one JSON file per entry in a directory.
+// File modification times track recency, so the least recently used
+// entries are evicted first when the store is full.
+type FileStore struct {
+	dir        string
+	maxEntries int
+
+	mu sync.Mutex
+}
+
+// NewFileStore returns a FileStore in dir, creating the directory if needed.
+// maxEntries bounds the number of files; zero means no limit.
+func NewFileStore(dir string, maxEntries int) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+	return &FileStore{
+		dir:        dir,
+		maxEntries: maxEntries,
+	}, nil
+}
+
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+func (s *FileStore) Get(key string) (*Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var e Entry
+	if err = json.Unmarshal(b, &e); err != nil {
+		// a corrupt file is a miss, it gets overwritten on the next Set
+		return nil, false, nil
+	}
+	now := time.Now()
+	if e.Expired(now) {
+		_ = os.Remove(s.path(key))
+		return nil, false, nil
+	}
+	_ = os.Chtimes(s.path(key), now, now)
+
+	return &e, true, nil
+}
+
+func (s *FileStore) Set(key string, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so readers never see a partial entry
+	tmp, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), s.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return s.evict()
+}
+
+func (s *FileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// evict removes the least recently used files above maxEntries.
+func (s *FileStore) evict() error {
+	if s.maxEntries <= 0 {
+		return nil
+	}
+
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	type file struct {
+		name    string
+		modTime time.Time
+	}
+	var files []file
+	for _, de := range dirEntries {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), ".json") {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{name: de.Name(), modTime: info.ModTime()})
+	}
+	if len(files) <= s.maxEntries {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files[:len(files)-s.maxEntries] {
+		if err := os.Remove(filepath.Join(s.dir, f.name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
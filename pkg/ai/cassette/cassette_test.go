@@ -0,0 +1,125 @@
+package cassette_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/cassette"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/claude"
+	cltypes "github.com/muraduiurie/gpt/pkg/ai/types/claude"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func ask(c *claude.Client, content string) (*union.Response, error) {
+	return c.AskAI(&union.Request{TextRequest: &cltypes.TextInputRequest{
+		Messages: []cltypes.TextInputRequestMessage{{Content: content}},
+	}})
+}
+
+func TestReplayExampleCassette(t *testing.T) {
+	rec, err := cassette.New("testdata/claude.yaml", cassette.ModeReplay)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c := &claude.Client{ApiToken: "test", HTTPClient: rec.Client()}
+
+	resp, err := ask(c, "Say hello")
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "Hello! How can I help you today?" {
+		t.Errorf("Output = %q", resp.Output)
+	}
+	if resp.Metadata.RequestID != "req_aitest_1" {
+		t.Errorf("RequestID = %q, want req_aitest_1", resp.Metadata.RequestID)
+	}
+	if resp.Usage.InputTokens != 10 || resp.Usage.OutputTokens != 12 {
+		t.Errorf("Usage = %+v, want 10 input and 12 output tokens", resp.Usage)
+	}
+}
+
+func TestReplayNoMatch(t *testing.T) {
+	rec, err := cassette.New("testdata/claude.yaml", cassette.ModeReplay)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c := &claude.Client{ApiToken: "test", HTTPClient: rec.Client()}
+
+	if _, err = ask(c, "Say goodbye"); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("err = %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestRecordRedactsAndReplays(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"call":%d}`, calls)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := cassette.New(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	send := func(client *http.Client, key string) string {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat?key="+key, strings.NewReader(`{"b":1, "a":2}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("X-Amz-Security-Token", key)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	send(rec.Client(), "secret")
+	send(rec.Client(), "secret")
+	if err = rec.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(c.Interactions) != 2 {
+		t.Fatalf("recorded %d interactions, want 2", len(c.Interactions))
+	}
+	in := c.Interactions[0].Request
+	for _, h := range []string{"Authorization", "X-Amz-Security-Token"} {
+		if got := in.Headers[h]; len(got) != 1 || got[0] != cassette.Redacted {
+			t.Errorf("%s = %v, want it redacted", h, got)
+		}
+	}
+	if strings.Contains(in.URL, "secret") {
+		t.Errorf("URL %s leaks the key", in.URL)
+	}
+
+	// replay matches whatever the key, in recorded order, without the server
+	srv.Close()
+	rec, err = cassette.New(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i, want := range []string{`{"call":1}`, `{"call":2}`} {
+		if got := send(rec.Client(), "other"); got != want {
+			t.Errorf("replay %d = %s, want %s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// Matcher reports whether a live request, whose body has already been read,
+// matches a recorded one.
+type Matcher func(r *http.Request, body []byte, rec Request) bool
+
+// MatchMethod matches on the HTTP method.
+func MatchMethod(r *http.Request, _ []byte, rec Request) bool {
+	return r.Method == rec.Method
+}
+
+// MatchURL matches on the full URL. Query parameters that were redacted in
+// the recording match any value.
+func MatchURL(r *http.Request, _ []byte, rec Request) bool {
+	recURL, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+
+	var redacted []string
+	for k, vs := range recURL.Query() {
+		if len(vs) == 1 && vs[0] == Redacted {
+			redacted = append(redacted, k)
+		}
+	}
+
+	return redactURL(r.URL, redacted) == rec.URL
+}
+
+// MatchBodyJSON matches bodies that decode to equal JSON values, so key order
+// and whitespace do not matter. Non-JSON bodies are compared byte for byte.
+func MatchBodyJSON(_ *http.Request, body []byte, rec Request) bool {
+	var a, b interface{}
+	if json.Unmarshal(body, &a) != nil || json.Unmarshal([]byte(rec.Body), &b) != nil {
+		return bytes.Equal(body, []byte(rec.Body))
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// MatchAll combines matchers; all of them must match.
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(r *http.Request, body []byte, rec Request) bool {
+		for _, m := range matchers {
+			if !m(r, body, rec) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// DefaultMatcher matches on method, URL and JSON body.
+var DefaultMatcher = MatchAll(MatchMethod, MatchURL, MatchBodyJSON)
@@ -0,0 +1,230 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and never touches the
+	// network.
+	ModeReplay Mode = iota
+	// ModeRecord forwards requests to the real transport and records every
+	// interaction. The cassette is written by Save.
+	ModeRecord
+)
+
+// Recorder is an http.RoundTripper that records or replays HTTP interactions.
+// Install it on a provider client through its HTTPClient field (or
+// ai.AIOpts.HTTPClient), e.g. `HTTPClient: rec.Client()`.
+type Recorder struct {
+	// Matcher selects the recorded interaction for a request in replay mode.
+	// Defaults to DefaultMatcher.
+	Matcher Matcher
+	// Transport sends requests in record mode. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// RedactHeaders and RedactQuery list the header names and query
+	// parameters whose values are replaced by Redacted before recording.
+	RedactHeaders []string
+	RedactQuery   []string
+
+	path string
+	mode Mode
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New returns a Recorder for the cassette at path. In replay mode the
+// cassette is loaded immediately and must exist.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		RedactHeaders: DefaultRedactHeaders,
+		RedactQuery:   DefaultRedactQuery,
+		path:          path,
+		mode:          mode,
+		cassette:      &Cassette{},
+	}
+
+	switch mode {
+	case ModeReplay:
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	case ModeRecord:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode: %d", mode)
+	}
+
+	return r, nil
+}
+
+// Client returns an *http.Client that uses the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Save writes the recorded interactions to the cassette file. It is a no-op
+// in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	matcher := r.Matcher
+	if matcher == nil {
+		matcher = DefaultMatcher
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// prefer interactions that have not been served yet, so repeated
+	// identical requests replay in recorded order
+	found := -1
+	for i, in := range r.cassette.Interactions {
+		if matcher(req, body, in.Request) {
+			if !r.used[i] {
+				found = i
+				break
+			}
+			if found == -1 {
+				found = i
+			}
+		}
+	}
+	if found == -1 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, redactURL(req.URL, r.RedactQuery))
+	}
+	r.used[found] = true
+
+	rec := r.cassette.Interactions[found].Response
+	header := http.Header{}
+	for k, vs := range rec.Headers {
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     redactURL(req.URL, r.RedactQuery),
+			Headers: r.redactHeaders(req.Header),
+			Body:    string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    headerMap(resp.Header),
+			Body:       string(respBody),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) redactHeaders(h http.Header) map[string][]string {
+	m := headerMap(h)
+	for _, name := range r.RedactHeaders {
+		key := http.CanonicalHeaderKey(name)
+		if _, ok := m[key]; ok {
+			m[key] = []string{Redacted}
+		}
+	}
+	return m
+}
+
+// redactURL returns u as a string with the values of the given query
+// parameters replaced by Redacted.
+func redactURL(u *url.URL, keys []string) string {
+	if len(keys) == 0 || u.RawQuery == "" {
+		return u.String()
+	}
+
+	q := u.Query()
+	changed := false
+	for _, k := range keys {
+		if q.Has(k) {
+			q.Set(k, Redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+
+	redacted := *u
+	redacted.RawQuery = q.Encode()
+	return redacted.String()
+}
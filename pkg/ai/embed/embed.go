@@ -0,0 +1,165 @@
+// Package embed splits embedding inputs into batches that fit provider
+// limits and sends them concurrently. Provider clients use it to implement
+// ai.Embedder.
+package embed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/muraduiurie/gpt/pkg/ai/tokens"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Limits bound the requests made for one embedding call. Zero values mean
+// no limit, and a Concurrency of one.
+type Limits struct {
+	// MaxInputs is the number of inputs per request.
+	MaxInputs int
+	// MaxTokens is the estimated number of tokens per request; see
+	// tokens.Estimate. An input above it is sent alone.
+	MaxTokens int
+	// Concurrency is the number of requests in flight.
+	Concurrency int
+}
+
+// Or returns l with its zero or negative fields taken from defaults.
+func (l Limits) Or(defaults Limits) Limits {
+	if l.MaxInputs <= 0 {
+		l.MaxInputs = defaults.MaxInputs
+	}
+	if l.MaxTokens <= 0 {
+		l.MaxTokens = defaults.MaxTokens
+	}
+	if l.Concurrency <= 0 {
+		l.Concurrency = defaults.Concurrency
+	}
+	return l
+}
+
+// Func embeds one batch of inputs.
+type Func func(ctx context.Context, batch []string) (*union.EmbedResponse, error)
+
+// Batches splits inputs into consecutive batches within l.
+func Batches(inputs []string, l Limits) [][]string {
+	var (
+		out    [][]string
+		start  int
+		budget int
+	)
+	for i, in := range inputs {
+		n := tokens.Estimate(in)
+		full := l.MaxInputs > 0 && i-start >= l.MaxInputs
+		over := l.MaxTokens > 0 && i > start && budget+n > l.MaxTokens
+		if full || over {
+			out = append(out, inputs[start:i])
+			start, budget = i, 0
+		}
+		budget += n
+	}
+	if start < len(inputs) {
+		out = append(out, inputs[start:])
+	}
+	return out
+}
+
+// Run embeds inputs in batches within l, with up to l.Concurrency requests
+// in flight, and merges the responses in input order. The first error
+// cancels the requests still running and is returned.
+func Run(ctx context.Context, inputs []string, l Limits, fn Func) (*union.EmbedResponse, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	batches := Batches(inputs, l)
+	if len(batches) == 1 {
+		resp, err := fn(ctx, batches[0])
+		if err != nil {
+			return nil, err
+		}
+		return resp, check(resp, len(inputs))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := l.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, concurrency)
+		responses = make([]*union.EmbedResponse, len(batches))
+		errOnce   sync.Once
+		firstErr  error
+	)
+	for i, batch := range batches {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, batch []string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := fn(ctx, batch)
+			if err == nil {
+				err = check(resp, len(batch))
+			}
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("batch %d of %d: %w", i+1, len(batches), err)
+					cancel()
+				})
+				return
+			}
+			responses[i] = resp
+		}(i, batch)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return merge(responses, len(inputs)), nil
+}
+
+// check reports a response without one vector per input.
+func check(resp *union.EmbedResponse, n int) error {
+	if len(resp.Embeddings) != n {
+		return fmt.Errorf("got %d embeddings for %d inputs", len(resp.Embeddings), n)
+	}
+	return nil
+}
+
+// merge concatenates the vectors of the responses and sums their usage.
+func merge(responses []*union.EmbedResponse, n int) *union.EmbedResponse {
+	out := &union.EmbedResponse{
+		Embeddings: make([][]float32, 0, n),
+	}
+	for _, resp := range responses {
+		out.Embeddings = append(out.Embeddings, resp.Embeddings...)
+		out.Model = resp.Model
+		out.Provider = resp.Provider
+		out.Metadata = resp.Metadata
+		if resp.Usage != nil {
+			if out.Usage == nil {
+				out.Usage = &union.Usage{}
+			}
+			out.Usage.InputTokens += resp.Usage.InputTokens
+			out.Usage.OutputTokens += resp.Usage.OutputTokens
+			out.Usage.TotalTokens += resp.Usage.TotalTokens
+			out.Usage.CachedInputTokens += resp.Usage.CachedInputTokens
+		}
+	}
+	return out
+}
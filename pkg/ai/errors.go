@@ -0,0 +1,52 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// IsRetryable reports whether err is worth retrying, possibly against another
+// provider: timeouts, transport failures, 408, 409, 429 and 5xx responses,
+// and key pools with every key cooling down. Validation errors and other
+// 4xx responses are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, keypool.ErrNoKeys) {
+		return true
+	}
+
+	var apiErr *union.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusRequestTimeout,
+			apiErr.StatusCode == http.StatusConflict,
+			apiErr.StatusCode == http.StatusTooManyRequests,
+			apiErr.StatusCode >= 500:
+			return true
+		}
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// connection refused, DNS failures, resets: the provider is unreachable
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled)
+}
+
+// ErrStreamingUnsupported is returned when streaming is requested from an
+// agent that cannot stream.
+var ErrStreamingUnsupported = errors.New("agent does not support streaming")
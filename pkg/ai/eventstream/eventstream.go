@@ -0,0 +1,172 @@
+// Package eventstream reads and writes the AWS event stream encoding
+// (`application/vnd.amazon.eventstream`), the format Bedrock uses to stream
+// responses.
+package eventstream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+)
+
+const (
+	preludeLen = 12
+	crcLen     = 4
+	// maxMessageLen is the largest message accepted, as in the AWS SDKs.
+	maxMessageLen = 16 * 1024 * 1024
+
+	headerTypeString = 7
+)
+
+// Message is a single event stream message. Only string headers are
+// decoded; headers of other types are skipped.
+type Message struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// EventType returns the `:event-type` header, e.g. "chunk".
+func (m *Message) EventType() string {
+	return m.Headers[":event-type"]
+}
+
+// MessageType returns the `:message-type` header: "event", "exception" or
+// "error".
+func (m *Message) MessageType() string {
+	return m.Headers[":message-type"]
+}
+
+// Reader decodes messages from a stream.
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Next returns the next message, after checking its checksums. It returns
+// io.EOF at the end of the stream and io.ErrUnexpectedEOF when the stream
+// ends inside a message.
+func (r *Reader) Next() (*Message, error) {
+	prelude := make([]byte, preludeLen)
+	if _, err := io.ReadFull(r.r, prelude); err != nil {
+		return nil, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, errors.New("eventstream: prelude checksum mismatch")
+	}
+	if totalLen > maxMessageLen || totalLen < preludeLen+crcLen {
+		return nil, fmt.Errorf("eventstream: invalid message length %d", totalLen)
+	}
+	if headersLen > totalLen-preludeLen-crcLen {
+		return nil, fmt.Errorf("eventstream: invalid headers length %d", headersLen)
+	}
+
+	msg := make([]byte, totalLen)
+	copy(msg, prelude)
+	if _, err := io.ReadFull(r.r, msg[preludeLen:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	end := totalLen - crcLen
+	if crc32.ChecksumIEEE(msg[:end]) != binary.BigEndian.Uint32(msg[end:]) {
+		return nil, errors.New("eventstream: message checksum mismatch")
+	}
+
+	headers, err := decodeHeaders(msg[preludeLen : preludeLen+headersLen])
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		Headers: headers,
+		Payload: msg[preludeLen+headersLen : end],
+	}, nil
+}
+
+// decodeHeaders decodes the string headers of b.
+func decodeHeaders(b []byte) (map[string]string, error) {
+	headers := map[string]string{}
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, errors.New("eventstream: truncated header")
+		}
+		name := string(b[1 : 1+nameLen])
+		typ := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var valueLen int
+		switch typ {
+		case 0, 1: // bool true, bool false
+		case 2: // byte
+			valueLen = 1
+		case 3: // int16
+			valueLen = 2
+		case 4: // int32
+			valueLen = 4
+		case 5, 8: // int64, timestamp
+			valueLen = 8
+		case 9: // uuid
+			valueLen = 16
+		case 6, headerTypeString: // bytes, string
+			if len(b) < 2 {
+				return nil, errors.New("eventstream: truncated header")
+			}
+			valueLen = int(binary.BigEndian.Uint16(b))
+			b = b[2:]
+		default:
+			return nil, fmt.Errorf("eventstream: unknown header type %d", typ)
+		}
+		if len(b) < valueLen {
+			return nil, errors.New("eventstream: truncated header")
+		}
+		if typ == headerTypeString {
+			headers[name] = string(b[:valueLen])
+		}
+		b = b[valueLen:]
+	}
+	return headers, nil
+}
+
+// Write encodes m to w, with string headers, and flushes it if w is an
+// http.Flusher.
+func Write(w io.Writer, m Message) error {
+	var headers bytes.Buffer
+	for name, value := range m.Headers {
+		if len(name) > 255 || len(value) > 65535 {
+			return fmt.Errorf("eventstream: header %q too long", name)
+		}
+		headers.WriteByte(byte(len(name)))
+		headers.WriteString(name)
+		headers.WriteByte(headerTypeString)
+		binary.Write(&headers, binary.BigEndian, uint16(len(value)))
+		headers.WriteString(value)
+	}
+
+	totalLen := preludeLen + headers.Len() + len(m.Payload) + crcLen
+	msg := make([]byte, 0, totalLen)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(totalLen))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(headers.Len()))
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg[:8]))
+	msg = append(msg, headers.Bytes()...)
+	msg = append(msg, m.Payload...)
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package eventstream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	for _, payload := range []string{`{"a":1}`, `{"b":2}`} {
+		err := Write(&buf, Message{
+			Headers: map[string]string{":event-type": "chunk", ":message-type": "event"},
+			Payload: []byte(payload),
+		})
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	r := NewReader(&buf)
+	for _, want := range []string{`{"a":1}`, `{"b":2}`} {
+		m, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if string(m.Payload) != want || m.EventType() != "chunk" || m.MessageType() != "event" {
+			t.Errorf("message = %q %v, want %s", m.Payload, m.Headers, want)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next = %v, want io.EOF", err)
+	}
+}
+
+func TestTruncatedMessage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, Message{Payload: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if _, err := NewReader(bytes.NewReader(b[:len(b)-2])).Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Next = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, Message{Payload: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	b[preludeLen] ^= 0xff
+	if _, err := NewReader(bytes.NewReader(b)).Next(); err == nil {
+		t.Error("Next accepted a corrupted message")
+	}
+}
+
+func TestInvalidHeadersLength(t *testing.T) {
+	// a valid prelude whose headers would run past the end of the message,
+	// with a headers length large enough to overflow the length check
+	for _, headersLen := range []uint32{20, 0xfffffff8} {
+		msg := binary.BigEndian.AppendUint32(nil, 32)
+		msg = binary.BigEndian.AppendUint32(msg, headersLen)
+		msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+		msg = append(msg, make([]byte, 20)...)
+		binary.BigEndian.PutUint32(msg[28:], crc32.ChecksumIEEE(msg[:28]))
+
+		if _, err := NewReader(bytes.NewReader(msg)).Next(); err == nil {
+			t.Errorf("Next accepted headers length %d", headersLen)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package keypool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+type Strategy string
+
+const (
+	// StrategyRoundRobin cycles through the available keys in order.
+	StrategyRoundRobin Strategy = "round_robin"
+	// StrategyLeastLoaded picks the available key with the fewest in-flight
+	// requests.
+	StrategyLeastLoaded Strategy = "least_loaded"
+)
+
+const (
+	defaultAuthCooldown      = 10 * time.Minute
+	defaultRateLimitCooldown = 30 * time.Second
+)
+
+// ErrNoKeys is returned by Acquire when every key is cooling down.
+var ErrNoKeys = errors.New("no API key available")
+
+// Pool spreads requests across several API keys of the same provider. Keys
+// that are rejected (401) or rate limited (429) are taken out of rotation
+// for a cooldown period. A Pool is safe for concurrent use.
+type Pool struct {
+	// Strategy selects the next key. Defaults to StrategyRoundRobin.
+	Strategy Strategy
+	// AuthCooldown is how long a key is disabled after a 401.
+	AuthCooldown time.Duration
+	// RateLimitCooldown is how long a key is disabled after a 429 that came
+	// without a retry-after header.
+	RateLimitCooldown time.Duration
+
+	mu   sync.Mutex
+	keys []*key
+	next int
+}
+
+type key struct {
+	token         string
+	inFlight      int
+	disabledUntil time.Time
+	stats         Stats
+}
+
+// Stats is the accounting of a single key.
+type Stats struct {
+	// Key is a masked form of the token, safe to log.
+	Key           string          `json:"key"`
+	Requests      int64           `json:"requests"`
+	Failures      int64           `json:"failures"`
+	InFlight      int             `json:"in_flight"`
+	InputTokens   int64           `json:"input_tokens"`
+	OutputTokens  int64           `json:"output_tokens"`
+	LastStatus    int             `json:"last_status,omitempty"`
+	RateLimit     union.RateLimit `json:"rate_limit"`
+	DisabledUntil time.Time       `json:"disabled_until,omitzero"`
+}
+
+// New returns a Pool over the given tokens. Empty tokens are ignored; an
+// error is returned when no token is left or the strategy is unknown.
+func New(tokens []string, strategy Strategy) (*Pool, error) {
+	switch strategy {
+	case StrategyRoundRobin, StrategyLeastLoaded, "":
+	default:
+		return nil, fmt.Errorf("unknown key selection strategy: %s", strategy)
+	}
+
+	p := &Pool{Strategy: strategy}
+	for _, t := range tokens {
+		if t == "" {
+			continue
+		}
+		p.keys = append(p.keys, &key{
+			token: t,
+			stats: Stats{Key: mask(t)},
+		})
+	}
+	if len(p.keys) == 0 {
+		return nil, errors.New("no API token in pool")
+	}
+	return p, nil
+}
+
+// Len returns the number of keys in the pool.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.keys)
+}
+
+// Lease is a key checked out of the pool for one request. It must be
+// released with the outcome of the request.
+type Lease struct {
+	pool *Pool
+	key  *key
+	once sync.Once
+}
+
+// Token returns the API token of the leased key.
+func (l *Lease) Token() string {
+	return l.key.token
+}
+
+// Acquire checks out a key according to the pool strategy. It returns an
+// error wrapping ErrNoKeys when every key is cooling down.
+func (p *Pool) Acquire() (*Lease, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.keys) == 0 {
+		return nil, fmt.Errorf("%w: pool is empty", ErrNoKeys)
+	}
+
+	now := time.Now()
+	var picked *key
+	switch p.Strategy {
+	case StrategyLeastLoaded:
+		for i := range p.keys {
+			k := p.keys[(p.next+i)%len(p.keys)]
+			if k.disabledUntil.After(now) {
+				continue
+			}
+			if picked == nil || k.inFlight < picked.inFlight {
+				picked = k
+			}
+		}
+		p.next = (p.next + 1) % len(p.keys)
+	case StrategyRoundRobin, "":
+		for i := range p.keys {
+			idx := (p.next + i) % len(p.keys)
+			if k := p.keys[idx]; !k.disabledUntil.After(now) {
+				picked = k
+				p.next = (idx + 1) % len(p.keys)
+				break
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown key selection strategy: %s", p.Strategy)
+	}
+
+	if picked == nil {
+		soonest := p.keys[0].disabledUntil
+		for _, k := range p.keys[1:] {
+			if k.disabledUntil.Before(soonest) {
+				soonest = k.disabledUntil
+			}
+		}
+		return nil, fmt.Errorf("%w: next key available in %s", ErrNoKeys, soonest.Sub(now).Round(time.Second))
+	}
+
+	picked.inFlight++
+	picked.stats.Requests++
+	return &Lease{pool: p, key: picked}, nil
+}
+
+// Release returns the key to the pool and records the outcome of the
+// request. A 401 or 429 takes the key out of rotation; the rate-limit
+// headers of the exchange are kept for accounting. Calling Release more
+// than once has no effect.
+func (l *Lease) Release(resp *union.Response, err error) {
+	l.once.Do(func() {
+		l.pool.release(l.key, resp, err)
+	})
+}
+
+func (p *Pool) release(k *key, resp *union.Response, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	k.inFlight--
+
+	var meta *union.Metadata
+	if resp != nil {
+		meta = resp.Metadata
+		if resp.Usage != nil {
+			k.stats.InputTokens += int64(resp.Usage.InputTokens)
+			k.stats.OutputTokens += int64(resp.Usage.OutputTokens)
+		}
+	}
+
+	if m := union.MetadataOf(err); m != nil {
+		meta = m
+	}
+	if err != nil {
+		k.stats.Failures++
+	}
+	// requests that got no response say nothing about the key
+	if meta == nil || meta.StatusCode == 0 {
+		return
+	}
+
+	now := time.Now()
+	k.stats.LastStatus = meta.StatusCode
+	k.stats.RateLimit = meta.RateLimit
+
+	switch meta.StatusCode {
+	case http.StatusUnauthorized:
+		cooldown := p.AuthCooldown
+		if cooldown == 0 {
+			cooldown = defaultAuthCooldown
+		}
+		k.disabledUntil = now.Add(cooldown)
+	case http.StatusTooManyRequests:
+		cooldown := meta.RateLimit.RetryAfter
+		if cooldown == 0 {
+			cooldown = p.RateLimitCooldown
+		}
+		if cooldown == 0 {
+			cooldown = defaultRateLimitCooldown
+		}
+		k.disabledUntil = now.Add(cooldown)
+	default:
+		// the provider told us the key is exhausted until the window resets
+		rl := meta.RateLimit
+		if rl.LimitRequests > 0 && rl.RemainingRequests == 0 && rl.ResetRequests.After(now) {
+			k.disabledUntil = rl.ResetRequests
+		}
+	}
+	k.stats.DisabledUntil = k.disabledUntil
+}
+
+// Stats returns a snapshot of the accounting of every key, in pool order.
+func (p *Pool) Stats() []Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]Stats, 0, len(p.keys))
+	for _, k := range p.keys {
+		s := k.stats
+		s.InFlight = k.inFlight
+		if !k.disabledUntil.After(now) {
+			s.DisabledUntil = time.Time{}
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// mask keeps the last four characters of a token.
+func mask(token string) string {
+	if len(token) <= 4 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
+
+// Stream wraps a streamed response so that the lease is released when the
+// stream is closed, accounting for the usage the stream reported.
+func (l *Lease) Stream(s union.Stream, meta *union.Metadata) union.Stream {
+	return &leaseStream{Stream: s, lease: l, meta: meta}
+}
+
+type leaseStream struct {
+	union.Stream
+	lease *Lease
+	meta  *union.Metadata
+	usage *union.Usage
+	err   error
+}
+
+func (s *leaseStream) Recv() (*union.StreamEvent, error) {
+	ev, err := s.Stream.Recv()
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	if ev != nil && ev.Usage != nil {
+		s.usage = ev.Usage
+	}
+	return ev, err
+}
+
+func (s *leaseStream) Close() error {
+	err := s.Stream.Close()
+	s.lease.Release(&union.Response{Usage: s.usage, Metadata: s.meta}, s.err)
+	return err
+}
@@ -0,0 +1,231 @@
+package keypool
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func newPool(t *testing.T, strategy Strategy, tokens ...string) *Pool {
+	t.Helper()
+	p, err := New(tokens, strategy)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+func acquire(t *testing.T, p *Pool) *Lease {
+	t.Helper()
+	l, err := p.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	return l
+}
+
+func apiError(status int, rl union.RateLimit) error {
+	return &union.APIError{
+		StatusCode: status,
+		Metadata:   &union.Metadata{StatusCode: status, RateLimit: rl},
+	}
+}
+
+func TestNewRejectsUnknownStrategy(t *testing.T) {
+	if _, err := New([]string{"key-1"}, "random"); err == nil {
+		t.Error("New with an unknown strategy succeeded")
+	}
+}
+
+func TestNewRejectsEmptyPool(t *testing.T) {
+	for _, tokens := range [][]string{nil, {""}, {"", ""}} {
+		if _, err := New(tokens, StrategyRoundRobin); err == nil {
+			t.Errorf("New(%q) succeeded", tokens)
+		}
+	}
+}
+
+func TestNewIgnoresEmptyTokens(t *testing.T) {
+	p := newPool(t, "", "key-1", "", "key-2")
+	if p.Len() != 2 {
+		t.Errorf("Len = %d, want 2", p.Len())
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	p := newPool(t, StrategyRoundRobin, "key-1", "key-2", "key-3")
+
+	var got []string
+	for range 6 {
+		l := acquire(t, p)
+		got = append(got, l.Token())
+		l.Release(nil, nil)
+	}
+	want := []string{"key-1", "key-2", "key-3", "key-1", "key-2", "key-3"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tokens = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLeastLoaded(t *testing.T) {
+	p := newPool(t, StrategyLeastLoaded, "key-1", "key-2", "key-3")
+
+	// one lease on each key, then free key-1
+	held := []*Lease{acquire(t, p), acquire(t, p), acquire(t, p)}
+	held[0].Release(nil, nil)
+	first := acquire(t, p)
+	if first.Token() != "key-1" {
+		t.Fatalf("token = %s, want key-1", first.Token())
+	}
+	// key-1: 1, key-2: 1, key-3: 1 in flight; releasing key-3 makes it the
+	// least loaded
+	held[2].Release(nil, nil)
+	if l := acquire(t, p); l.Token() != "key-3" {
+		t.Errorf("token = %s, want key-3", l.Token())
+	}
+}
+
+func TestAuthCooldown(t *testing.T) {
+	p := newPool(t, StrategyRoundRobin, "key-1", "key-2")
+	p.AuthCooldown = time.Hour
+
+	l := acquire(t, p)
+	l.Release(nil, apiError(http.StatusUnauthorized, union.RateLimit{}))
+
+	for range 3 {
+		l := acquire(t, p)
+		if l.Token() != "key-2" {
+			t.Fatalf("token = %s, want key-2 while key-1 cools down", l.Token())
+		}
+		l.Release(nil, nil)
+	}
+
+	stats := p.Stats()
+	if stats[0].LastStatus != http.StatusUnauthorized || stats[0].Failures != 1 || stats[0].DisabledUntil.IsZero() {
+		t.Errorf("stats = %+v, want a disabled key after a 401", stats[0])
+	}
+}
+
+func TestRateLimitCooldownExpires(t *testing.T) {
+	p := newPool(t, StrategyRoundRobin, "key-1")
+
+	l := acquire(t, p)
+	l.Release(nil, apiError(http.StatusTooManyRequests, union.RateLimit{RetryAfter: 50 * time.Millisecond}))
+
+	if _, err := p.Acquire(); !errors.Is(err, ErrNoKeys) {
+		t.Fatalf("Acquire = %v, want ErrNoKeys", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	acquire(t, p).Release(nil, nil)
+}
+
+func TestExhaustedRateLimitWindow(t *testing.T) {
+	p := newPool(t, StrategyRoundRobin, "key-1", "key-2")
+
+	l := acquire(t, p)
+	l.Release(&union.Response{Metadata: &union.Metadata{
+		StatusCode: http.StatusOK,
+		RateLimit: union.RateLimit{
+			LimitRequests:     100,
+			RemainingRequests: 0,
+			ResetRequests:     time.Now().Add(time.Hour),
+		},
+	}}, nil)
+
+	if l := acquire(t, p); l.Token() != "key-2" {
+		t.Errorf("token = %s, want key-2 while key-1 waits for its window", l.Token())
+	}
+}
+
+func TestTransportErrorKeepsKey(t *testing.T) {
+	p := newPool(t, StrategyRoundRobin, "key-1")
+
+	l := acquire(t, p)
+	l.Release(nil, &union.RequestError{Err: errors.New("connection refused"), Metadata: &union.Metadata{Latency: time.Millisecond}})
+
+	acquire(t, p)
+	if s := p.Stats()[0]; s.Failures != 1 || s.LastStatus != 0 {
+		t.Errorf("stats = %+v, want one failure and no status", s)
+	}
+}
+
+func TestReleaseOnce(t *testing.T) {
+	p := newPool(t, StrategyRoundRobin, "key-1")
+
+	l := acquire(t, p)
+	l.Release(nil, nil)
+	l.Release(nil, nil)
+	if s := p.Stats()[0]; s.InFlight != 0 {
+		t.Errorf("InFlight = %d, want 0", s.InFlight)
+	}
+}
+
+func TestStats(t *testing.T) {
+	p := newPool(t, StrategyRoundRobin, "sk-secret-1234")
+
+	l := acquire(t, p)
+	l.Release(&union.Response{
+		Usage:    &union.Usage{InputTokens: 10, OutputTokens: 5},
+		Metadata: &union.Metadata{StatusCode: http.StatusOK},
+	}, nil)
+
+	s := p.Stats()[0]
+	if s.Key != "****1234" {
+		t.Errorf("Key = %q, want ****1234", s.Key)
+	}
+	if s.Requests != 1 || s.InputTokens != 10 || s.OutputTokens != 5 || s.LastStatus != http.StatusOK {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+type fakeStream struct {
+	events []*union.StreamEvent
+	closed bool
+}
+
+func (s *fakeStream) Recv() (*union.StreamEvent, error) {
+	if len(s.events) == 0 {
+		return nil, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *fakeStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestStreamReleasesOnClose(t *testing.T) {
+	p := newPool(t, StrategyRoundRobin, "key-1")
+
+	l := acquire(t, p)
+	inner := &fakeStream{events: []*union.StreamEvent{
+		{Delta: "hi"},
+		{Usage: &union.Usage{InputTokens: 3, OutputTokens: 1}},
+	}}
+	s := l.Stream(inner, &union.Metadata{StatusCode: http.StatusOK})
+	for {
+		if _, err := s.Recv(); err != nil {
+			break
+		}
+	}
+	if p.Stats()[0].InFlight != 1 {
+		t.Fatal("lease released before Close")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	st := p.Stats()[0]
+	if !inner.closed || st.InFlight != 0 || st.InputTokens != 3 || st.OutputTokens != 1 {
+		t.Errorf("stats = %+v, want the lease released with the stream usage", st)
+	}
+}
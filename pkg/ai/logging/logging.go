@@ -0,0 +1,131 @@
+// Package logging logs provider calls with log/slog.
+//
+// Credentials are never logged: the Authorization, x-api-key and similar
+// headers are set by the clients after observers run, extra headers are
+// masked by name, and bodies and errors go through a redact.Redactor that
+// always masks API keys and bearer tokens.
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/redact"
+)
+
+const defaultMaxBodyBytes = 4096
+
+type Options struct {
+	// LogBodies logs the sanitized request and response bodies at debug
+	// level.
+	LogBodies bool
+	// MaxBodyBytes truncates logged bodies. Defaults to 4096.
+	MaxBodyBytes int
+	// Redactor masks personal data in bodies and errors, e.g.
+	// redact.New(redact.Email, redact.Phone). Secrets are masked regardless.
+	Redactor *redact.Redactor
+}
+
+// Observer logs the start and the end of every provider call.
+type Observer struct {
+	logger *slog.Logger
+	opts   Options
+}
+
+// New returns an Observer logging to logger.
+func New(logger *slog.Logger, opts Options) *Observer {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	return &Observer{
+		logger: logger,
+		opts:   opts,
+	}
+}
+
+// Start logs the request at debug level.
+func (o *Observer) Start(ctx context.Context, call *observe.Call) context.Context {
+	if !o.logger.Enabled(ctx, slog.LevelDebug) {
+		return ctx
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", call.OperationName()),
+		slog.String("provider", call.Provider),
+		slog.String("model", call.Model),
+		slog.String("endpoint", call.Endpoint),
+		slog.Bool("stream", call.Stream),
+	}
+	if len(call.Header) > 0 {
+		attrs = append(attrs, slog.Any("header", o.opts.Redactor.Header(call.Header)))
+	}
+	if o.opts.LogBodies {
+		attrs = append(attrs, slog.String("body", o.body(call.Body)))
+	}
+	o.logger.LogAttrs(ctx, slog.LevelDebug, "ai request start", attrs...)
+
+	return ctx
+}
+
+// End logs the outcome of the request: at info level on success, at error
+// level on failure.
+func (o *Observer) End(ctx context.Context, call *observe.Call, res *observe.Result) {
+	level := slog.LevelInfo
+	if res.Err != nil {
+		level = slog.LevelError
+	}
+	if !o.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", call.OperationName()),
+		slog.String("provider", call.Provider),
+		slog.String("model", call.Model),
+		slog.Bool("stream", call.Stream),
+		slog.Duration("latency", res.Latency),
+	}
+	if m := res.Metadata; m != nil {
+		attrs = append(attrs,
+			slog.Int("status", m.StatusCode),
+			slog.String("request_id", m.RequestID),
+		)
+	}
+	if u := res.Usage; u != nil {
+		attrs = append(attrs,
+			slog.Int("input_tokens", u.InputTokens),
+			slog.Int("output_tokens", u.OutputTokens),
+		)
+	}
+	if res.TimeToFirstToken > 0 {
+		attrs = append(attrs, slog.Duration("time_to_first_token", res.TimeToFirstToken))
+	}
+	if len(res.FinishReasons) > 0 {
+		attrs = append(attrs, slog.Any("finish_reasons", res.FinishReasons))
+	}
+	if res.Err != nil {
+		attrs = append(attrs, slog.String("error", o.opts.Redactor.String(res.Err.Error())))
+	}
+	o.logger.LogAttrs(ctx, level, "ai request end", attrs...)
+
+	if o.opts.LogBodies && res.Body != nil && o.logger.Enabled(ctx, slog.LevelDebug) {
+		o.logger.LogAttrs(ctx, slog.LevelDebug, "ai response body",
+			slog.String("provider", call.Provider),
+			slog.String("body", o.body(res.Body)),
+		)
+	}
+}
+
+func (o *Observer) body(b []byte) string {
+	truncated := false
+	if len(b) > o.opts.MaxBodyBytes {
+		b = b[:o.opts.MaxBodyBytes]
+		truncated = true
+	}
+	s := o.opts.Redactor.String(string(b))
+	if truncated {
+		s += "...(truncated)"
+	}
+	return s
+}
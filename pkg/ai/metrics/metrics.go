@@ -0,0 +1,220 @@
+// Package metrics exposes Prometheus metrics about provider calls.
+//
+// A Collector is both an observe.Observer, fed by the provider clients, and
+// a prometheus.Collector an application registers with its registry:
+//
+//	m := metrics.New(metrics.Options{})
+//	prometheus.MustRegister(m)
+//	agent, err := ai.NewAIAgent(ai.ModelChatGPT, &ai.AIOpts{
+//		Observers: []observe.Observer{m},
+//	})
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultNamespace = "ai"
+
+var (
+	// DefaultLatencyBuckets suits requests taking from a few hundred
+	// milliseconds to a few minutes.
+	DefaultLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80, 160, 320}
+	// DefaultTTFTBuckets suits the time to first token of streams.
+	DefaultTTFTBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 16}
+)
+
+// Price is the price of a model in USD per million tokens.
+type Price struct {
+	Input  float64
+	Output float64
+	// CachedInput applies to input tokens served from the provider's prompt
+	// cache. Zero means the Input price.
+	CachedInput float64
+}
+
+type Options struct {
+	// Namespace prefixes the metric names. Defaults to "ai".
+	Namespace string
+	// ConstLabels are added to every metric, e.g. the application name.
+	ConstLabels prometheus.Labels
+	// LatencyBuckets and TTFTBuckets default to DefaultLatencyBuckets and
+	// DefaultTTFTBuckets.
+	LatencyBuckets []float64
+	TTFTBuckets    []float64
+	// Prices maps model names to their price, used to estimate the cost of
+	// each call. Models without a price are not counted.
+	Prices map[string]Price
+}
+
+// Collector records metrics about provider calls.
+type Collector struct {
+	prices map[string]Price
+
+	requests     *prometheus.CounterVec
+	latency      *prometheus.HistogramVec
+	ttft         *prometheus.HistogramVec
+	inputTokens  *prometheus.CounterVec
+	outputTokens *prometheus.CounterVec
+	cachedTokens *prometheus.CounterVec
+	cost         *prometheus.CounterVec
+	retries      *prometheus.CounterVec
+	inFlight     *prometheus.GaugeVec
+}
+
+// New returns a Collector. It has to be registered to be exported.
+func New(opts Options) *Collector {
+	ns := opts.Namespace
+	if ns == "" {
+		ns = defaultNamespace
+	}
+	latencyBuckets := opts.LatencyBuckets
+	if latencyBuckets == nil {
+		latencyBuckets = DefaultLatencyBuckets
+	}
+	ttftBuckets := opts.TTFTBuckets
+	if ttftBuckets == nil {
+		ttftBuckets = DefaultTTFTBuckets
+	}
+
+	labels := []string{"provider", "model"}
+	counter := func(name, help string, extra ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   ns,
+			Name:        name,
+			Help:        help,
+			ConstLabels: opts.ConstLabels,
+		}, append(labels, extra...))
+	}
+	histogram := func(name, help string, buckets []float64) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   ns,
+			Name:        name,
+			Help:        help,
+			ConstLabels: opts.ConstLabels,
+			Buckets:     buckets,
+		}, labels)
+	}
+
+	return &Collector{
+		prices: opts.Prices,
+
+		requests:     counter("requests_total", "Provider requests by status: the HTTP status code, or \"error\" when no response was received or the stream failed.", "status"),
+		latency:      histogram("request_duration_seconds", "Duration of provider requests, up to the end of the stream for streamed requests.", latencyBuckets),
+		ttft:         histogram("time_to_first_token_seconds", "Time to the first text delta of streamed requests.", ttftBuckets),
+		inputTokens:  counter("input_tokens_total", "Input tokens reported by the providers, cached ones included."),
+		outputTokens: counter("output_tokens_total", "Output tokens reported by the providers."),
+		cachedTokens: counter("cached_input_tokens_total", "Input tokens served from the providers' prompt cache."),
+		cost:         counter("estimated_cost_usd_total", "Estimated cost of the requests in USD, for models with a configured price."),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   ns,
+			Name:        "retries_total",
+			Help:        "Failed attempts of a router that were retried on another agent, by the provider of the failed attempt.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"provider"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   ns,
+			Name:        "requests_in_flight",
+			Help:        "Provider requests in progress, open streams included.",
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.requests,
+		c.latency,
+		c.ttft,
+		c.inputTokens,
+		c.outputTokens,
+		c.cachedTokens,
+		c.cost,
+		c.retries,
+		c.inFlight,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.collectors() {
+		m.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.collectors() {
+		m.Collect(ch)
+	}
+}
+
+// Start counts the call as in flight and, when an ai.Router retries a
+// failed attempt with it, the retry.
+func (c *Collector) Start(ctx context.Context, call *observe.Call) context.Context {
+	c.inFlight.WithLabelValues(call.Provider, call.Model).Inc()
+	if provider, ok := observe.RetryOf(ctx); ok {
+		c.retries.WithLabelValues(provider).Inc()
+	}
+	return ctx
+}
+
+// End records the outcome of the call.
+func (c *Collector) End(_ context.Context, call *observe.Call, res *observe.Result) {
+	provider, model := call.Provider, call.Model
+	c.inFlight.WithLabelValues(provider, model).Dec()
+
+	c.requests.WithLabelValues(provider, model, status(res)).Inc()
+	c.latency.WithLabelValues(provider, model).Observe(res.Latency.Seconds())
+	if res.TimeToFirstToken > 0 {
+		c.ttft.WithLabelValues(provider, model).Observe(res.TimeToFirstToken.Seconds())
+	}
+
+	u := res.Usage
+	if u == nil {
+		return
+	}
+	c.inputTokens.WithLabelValues(provider, model).Add(float64(u.InputTokens))
+	c.outputTokens.WithLabelValues(provider, model).Add(float64(u.OutputTokens))
+	c.cachedTokens.WithLabelValues(provider, model).Add(float64(u.CachedInputTokens))
+	if price, ok := c.prices[model]; ok {
+		c.cost.WithLabelValues(provider, model).Add(Cost(price, u))
+	}
+}
+
+// Cost returns the estimated cost in USD of the usage at the given price.
+func Cost(p Price, u *union.Usage) float64 {
+	cachedPrice := p.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+	uncached := u.InputTokens - u.CachedInputTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	return (float64(uncached)*p.Input +
+		float64(u.CachedInputTokens)*cachedPrice +
+		float64(u.OutputTokens)*p.Output) / 1e6
+}
+
+// status returns the status label of a call. Streams failing after a 2xx
+// response are counted as errors, not with the status of the response.
+func status(res *observe.Result) string {
+	if res.Err != nil {
+		var apiErr *union.APIError
+		if errors.As(res.Err, &apiErr) && apiErr.StatusCode != 0 {
+			return strconv.Itoa(apiErr.StatusCode)
+		}
+		return "error"
+	}
+	if res.Metadata != nil && res.Metadata.StatusCode != 0 {
+		return strconv.Itoa(res.Metadata.StatusCode)
+	}
+	return "ok"
+}
@@ -0,0 +1,105 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai"
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/metrics"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/claude"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// value returns the value of the counter or gauge name with the given
+// labels, or 0 when it was never set.
+func value(t *testing.T, c *metrics.Collector, name string, labels map[string]string) float64 {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if want, ok := labels[l.GetName()]; ok && want != l.GetValue() {
+					continue metrics
+				}
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestRequestsAndRetries(t *testing.T) {
+	srv := aitest.NewAnthropicServer()
+	defer srv.Close()
+	srv.FailNext(http.StatusServiceUnavailable, 1)
+	m := metrics.New(metrics.Options{})
+	newAgent := func() ai.AIAgent {
+		return &claude.Client{ApiToken: aitest.DefaultAPIKey, TextInputEndpoint: srv.Endpoint(), Observer: m}
+	}
+
+	_, err := ai.NewRouter(newAgent(), newAgent()).AskAI(&union.Request{Prompt: &union.Prompt{
+		Messages: []union.PromptMessage{{Role: union.PromptRoleUser, Content: "hello"}},
+	}})
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+
+	for status, want := range map[string]float64{"503": 1, "200": 1} {
+		if got := value(t, m, "ai_requests_total", map[string]string{"status": status}); got != want {
+			t.Errorf("ai_requests_total{status=%q} = %v, want %v", status, got, want)
+		}
+	}
+	if got := value(t, m, "ai_retries_total", map[string]string{"provider": claude.ProviderName}); got != 1 {
+		t.Errorf("ai_retries_total = %v, want 1", got)
+	}
+	if got := value(t, m, "ai_requests_in_flight", nil); got != 0 {
+		t.Errorf("ai_requests_in_flight = %v, want 0", got)
+	}
+}
+
+func TestFailedStreamIsAnError(t *testing.T) {
+	m := metrics.New(metrics.Options{})
+	call := &observe.Call{Provider: "claude", Model: "m", Stream: true}
+	ctx := m.Start(context.Background(), call)
+	m.End(ctx, call, &observe.Result{
+		Err:      errors.New("stream error overloaded_error: Overloaded"),
+		Metadata: &union.Metadata{StatusCode: http.StatusOK},
+		Latency:  time.Second,
+	})
+
+	if got := value(t, m, "ai_requests_total", map[string]string{"status": "200"}); got != 0 {
+		t.Errorf("ai_requests_total{status=200} = %v, want 0", got)
+	}
+	if got := value(t, m, "ai_requests_total", map[string]string{"status": "error"}); got != 1 {
+		t.Errorf("ai_requests_total{status=error} = %v, want 1", got)
+	}
+}
+
+func TestCost(t *testing.T) {
+	got := metrics.Cost(metrics.Price{Input: 2, Output: 8, CachedInput: 0.5}, &union.Usage{
+		InputTokens:       1_000_000,
+		CachedInputTokens: 200_000,
+		OutputTokens:      500_000,
+	})
+	if want := 0.8*2 + 0.2*0.5 + 0.5*8; got != want {
+		t.Errorf("Cost = %v, want %v", got, want)
+	}
+}
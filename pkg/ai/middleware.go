@@ -0,0 +1,240 @@
+package ai
+
+import (
+	"io"
+	"strings"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Middleware wraps an AIAgent with cross-cutting behaviour such as logging,
+// retries or caching.
+type Middleware func(next AIAgent) AIAgent
+
+// StreamingMiddleware is the streaming-aware equivalent of Middleware: it
+// wraps both AskAI and StreamAI.
+type StreamingMiddleware func(next StreamingAIAgent) StreamingAIAgent
+
+// AgentFunc adapts a function to the AIAgent interface.
+type AgentFunc func(opts *union.Request) (*union.Response, error)
+
+func (f AgentFunc) AskAI(opts *union.Request) (*union.Response, error) {
+	return f(opts)
+}
+
+// StreamFunc is the function form of StreamingAIAgent.StreamAI.
+type StreamFunc func(opts *union.Request) (union.Stream, error)
+
+// StreamingAgent assembles a StreamingAIAgent from two functions.
+type StreamingAgent struct {
+	Ask    AgentFunc
+	Stream StreamFunc
+}
+
+func (a *StreamingAgent) AskAI(opts *union.Request) (*union.Response, error) {
+	return a.Ask(opts)
+}
+
+func (a *StreamingAgent) StreamAI(opts *union.Request) (union.Stream, error) {
+	return a.Stream(opts)
+}
+
+// Chain wraps agent with the middlewares; the first middleware is the
+// outermost. The chain streams only if every middleware returns a
+// StreamingAIAgent: a middleware returning a plain AIAgent wraps AskAI
+// alone, so streaming calls cannot go through it. Use Streaming to write
+// middlewares that wrap both. The provider name of agent is kept.
+func Chain(agent AIAgent, mws ...Middleware) AIAgent {
+	for i := len(mws) - 1; i >= 0; i-- {
+		agent = keepProvider(agent, mws[i](agent))
+	}
+	return agent
+}
+
+// ChainStreaming wraps agent with streaming-aware middlewares; the first
+// middleware is the outermost.
+func ChainStreaming(agent StreamingAIAgent, mws ...StreamingMiddleware) StreamingAIAgent {
+	for i := len(mws) - 1; i >= 0; i-- {
+		agent = keepProvider(agent, mws[i](agent)).(StreamingAIAgent)
+	}
+	return agent
+}
+
+type providerNamer interface {
+	Provider() string
+}
+
+type namedAgent struct {
+	AIAgent
+	name string
+}
+
+func (a *namedAgent) Provider() string {
+	return a.name
+}
+
+type namedStreamingAgent struct {
+	StreamingAIAgent
+	name string
+}
+
+func (a *namedStreamingAgent) Provider() string {
+	return a.name
+}
+
+// keepProvider gives wrapped the provider name of inner, if wrapped does not
+// report one itself.
+func keepProvider(inner, wrapped AIAgent) AIAgent {
+	p, ok := inner.(providerNamer)
+	if !ok {
+		return wrapped
+	}
+	if _, ok = wrapped.(providerNamer); ok {
+		return wrapped
+	}
+	if s, ok := wrapped.(StreamingAIAgent); ok {
+		return &namedStreamingAgent{StreamingAIAgent: s, name: p.Provider()}
+	}
+	return &namedAgent{AIAgent: wrapped, name: p.Provider()}
+}
+
+// Streaming turns a StreamingMiddleware into a Middleware usable with Chain
+// and AIOpts.Middleware. Agents that cannot stream stay that way: only the
+// AskAI of the wrapped agent is returned.
+func Streaming(mw StreamingMiddleware) Middleware {
+	return func(next AIAgent) AIAgent {
+		if s, ok := next.(StreamingAIAgent); ok {
+			return mw(s)
+		}
+		wrapped := mw(&StreamingAgent{Ask: next.AskAI, Stream: func(*union.Request) (union.Stream, error) {
+			return nil, ErrStreamingUnsupported
+		}})
+		return AgentFunc(wrapped.AskAI)
+	}
+}
+
+// RequestHook inspects or mutates a request before it is sent, e.g. to add
+// headers or fill in default parameters. Returning an error aborts the call.
+type RequestHook func(opts *union.Request) error
+
+// ResponseHook inspects or mutates a response before it is returned.
+// Returning an error turns the call into a failure.
+type ResponseHook func(opts *union.Request, resp *union.Response) error
+
+// WithRequestHook returns a middleware running hook before every call,
+// streaming or not.
+func WithRequestHook(hook RequestHook) Middleware {
+	return Streaming(func(next StreamingAIAgent) StreamingAIAgent {
+		return &StreamingAgent{
+			Ask: func(opts *union.Request) (*union.Response, error) {
+				if err := runRequestHook(hook, opts); err != nil {
+					return nil, err
+				}
+				return next.AskAI(opts)
+			},
+			Stream: func(opts *union.Request) (union.Stream, error) {
+				if err := runRequestHook(hook, opts); err != nil {
+					return nil, err
+				}
+				return next.StreamAI(opts)
+			},
+		}
+	})
+}
+
+// WithResponseHook returns a middleware running hook on every successful
+// response. For streams, hook runs when the stream ends, on a response
+// assembled from its events: the output, the finish reason and the usage.
+// Its error is then returned by Recv instead of io.EOF.
+func WithResponseHook(hook ResponseHook) Middleware {
+	return Streaming(func(next StreamingAIAgent) StreamingAIAgent {
+		return &StreamingAgent{
+			Ask: func(opts *union.Request) (*union.Response, error) {
+				resp, err := next.AskAI(opts)
+				if err != nil {
+					return nil, err
+				}
+				if err = hook(opts, resp); err != nil {
+					return nil, err
+				}
+				return resp, nil
+			},
+			Stream: func(opts *union.Request) (union.Stream, error) {
+				s, err := next.StreamAI(opts)
+				if err != nil {
+					return nil, err
+				}
+				return &hookStream{Stream: s, opts: opts, hook: hook}, nil
+			},
+		}
+	})
+}
+
+// hookStream runs a ResponseHook at the end of a stream.
+type hookStream struct {
+	union.Stream
+	opts   *union.Request
+	hook   ResponseHook
+	output strings.Builder
+	resp   union.Response
+	done   bool
+	err    error
+}
+
+func (s *hookStream) Recv() (*union.StreamEvent, error) {
+	if s.done {
+		return nil, s.err
+	}
+
+	ev, err := s.Stream.Recv()
+	if ev != nil {
+		s.output.WriteString(ev.Delta)
+		if ev.FinishReason != "" {
+			s.resp.FinishReason = ev.FinishReason
+		}
+		if ev.Usage != nil {
+			s.resp.Usage = ev.Usage
+		}
+	}
+	if err != io.EOF {
+		return ev, err
+	}
+
+	s.done, s.err = true, io.EOF
+	s.resp.Output = s.output.String()
+	if herr := s.hook(s.opts, &s.resp); herr != nil {
+		s.err = herr
+	}
+	return ev, s.err
+}
+
+// WithHeader returns a middleware adding an HTTP header to every provider
+// request. The request of the caller is left untouched.
+func WithHeader(key, value string) Middleware {
+	withHeader := func(opts *union.Request) *union.Request {
+		if opts == nil {
+			return nil
+		}
+		req := *opts
+		req.Header = opts.Header.Clone()
+		req.SetHeader(key, value)
+		return &req
+	}
+	return Streaming(func(next StreamingAIAgent) StreamingAIAgent {
+		return &StreamingAgent{
+			Ask: func(opts *union.Request) (*union.Response, error) {
+				return next.AskAI(withHeader(opts))
+			},
+			Stream: func(opts *union.Request) (union.Stream, error) {
+				return next.StreamAI(withHeader(opts))
+			},
+		}
+	})
+}
+
+func runRequestHook(hook RequestHook, opts *union.Request) error {
+	if opts == nil {
+		return nil
+	}
+	return hook(opts)
+}
@@ -0,0 +1,154 @@
+package ai_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// askOnly hides the StreamAI method of an agent.
+type askOnly struct {
+	agent ai.AIAgent
+}
+
+func (a askOnly) AskAI(opts *union.Request) (*union.Response, error) {
+	return a.agent.AskAI(opts)
+}
+
+func drain(t *testing.T, s union.Stream) (string, error) {
+	t.Helper()
+	defer s.Close()
+	var out string
+	for {
+		ev, err := s.Recv()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		out += ev.Delta
+	}
+}
+
+func TestWithHeaderLeavesCallerRequest(t *testing.T) {
+	fake := agent("fake", text("hi"), text("hi").WithChunks("h", "i"))
+	a := ai.Chain(fake, ai.WithHeader("X-Test", "1")).(ai.StreamingAIAgent)
+
+	req := prompt(nil)
+	if _, err := a.AskAI(req); err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if got := fake.LastRequest().Header.Get("X-Test"); got != "1" {
+		t.Errorf("sent X-Test = %q, want 1", got)
+	}
+	if req.Header != nil {
+		t.Errorf("caller request header = %v, want it untouched", req.Header)
+	}
+
+	s, err := a.StreamAI(req)
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	if _, err = drain(t, s); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.LastRequest().Header.Get("X-Test"); got != "1" {
+		t.Errorf("streamed X-Test = %q, want 1", got)
+	}
+	if req.Header != nil {
+		t.Errorf("caller request header = %v, want it untouched", req.Header)
+	}
+}
+
+func TestChainWithPlainMiddlewareDoesNotStream(t *testing.T) {
+	called := false
+	plain := func(next ai.AIAgent) ai.AIAgent {
+		return ai.AgentFunc(func(opts *union.Request) (*union.Response, error) {
+			called = true
+			return next.AskAI(opts)
+		})
+	}
+	a := ai.Chain(agent("fake", text("hi")), plain)
+
+	if _, ok := a.(ai.StreamingAIAgent); ok {
+		t.Error("chain streams around a middleware that does not")
+	}
+	if _, err := a.AskAI(prompt(nil)); err != nil || !called {
+		t.Errorf("AskAI = %v, middleware called %v", err, called)
+	}
+}
+
+func TestChainKeepsProvider(t *testing.T) {
+	a := ai.Chain(agent("fake"), ai.WithHeader("X-Test", "1"))
+	p, ok := a.(interface{ Provider() string })
+	if !ok || p.Provider() != "fake" {
+		t.Error("chain lost the provider name")
+	}
+}
+
+func TestStreamingKeepsNonStreamingAgents(t *testing.T) {
+	a := ai.Chain(askOnly{agent("fake", text("hi"))}, ai.WithHeader("X-Test", "1"))
+	if _, ok := a.(ai.StreamingAIAgent); ok {
+		t.Error("non-streaming agent gained a StreamAI")
+	}
+	if _, err := a.AskAI(prompt(nil)); err != nil {
+		t.Errorf("AskAI: %v", err)
+	}
+}
+
+func TestWithRequestHookAbortsCalls(t *testing.T) {
+	fake := agent("fake", text("hi"))
+	hookErr := errors.New("rejected")
+	a := ai.Chain(fake, ai.WithRequestHook(func(*union.Request) error { return hookErr })).(ai.StreamingAIAgent)
+
+	if _, err := a.AskAI(prompt(nil)); !errors.Is(err, hookErr) {
+		t.Errorf("AskAI = %v, want the hook error", err)
+	}
+	if _, err := a.StreamAI(prompt(nil)); !errors.Is(err, hookErr) {
+		t.Errorf("StreamAI = %v, want the hook error", err)
+	}
+	if n := len(fake.Requests()); n != 0 {
+		t.Errorf("agent got %d requests, want 0", n)
+	}
+}
+
+func TestWithResponseHook(t *testing.T) {
+	var seen []string
+	hook := func(opts *union.Request, resp *union.Response) error {
+		seen = append(seen, resp.Output)
+		return nil
+	}
+	a := ai.Chain(agent("fake", text("hello"), text("hello").WithChunks("hel", "lo")), ai.WithResponseHook(hook)).(ai.StreamingAIAgent)
+
+	if _, err := a.AskAI(prompt(nil)); err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	s, err := a.StreamAI(prompt(nil))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	if _, err = drain(t, s); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 || seen[0] != "hello" || seen[1] != "hello" {
+		t.Errorf("hook saw %q, want the output of both calls", seen)
+	}
+}
+
+func TestWithResponseHookFailsStream(t *testing.T) {
+	hookErr := errors.New("blocked")
+	hook := func(*union.Request, *union.Response) error { return hookErr }
+	a := ai.Chain(agent("fake", text("hi").WithChunks("h", "i")), ai.WithResponseHook(hook)).(ai.StreamingAIAgent)
+
+	s, err := a.StreamAI(prompt(nil))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	if _, err = drain(t, s); !errors.Is(err, hookErr) {
+		t.Errorf("stream ended with %v, want the hook error", err)
+	}
+}
@@ -0,0 +1,275 @@
+// Package observe defines the hooks provider clients call around every
+// request, so logging, tracing and metrics can be plugged in without
+// wrapping the agents.
+package observe
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+const (
+	// OperationChat is a text generation call.
+	OperationChat = "chat"
+	// OperationEmbeddings is an embeddings call.
+	OperationEmbeddings = "embeddings"
+	// OperationSpeech is a text-to-speech call.
+	OperationSpeech = "speech"
+	// OperationTranscription is a speech-to-text call.
+	OperationTranscription = "transcription"
+	// OperationTranslation is a speech-to-English-text call.
+	OperationTranslation = "translation"
+	// OperationImageGeneration is an image generation call.
+	OperationImageGeneration = "image_generation"
+	// OperationImageEdit is an image editing call.
+	OperationImageEdit = "image_edit"
+)
+
+// Call describes a provider request about to be sent.
+type Call struct {
+	// Operation is one of the Operation constants. Empty means
+	// OperationChat.
+	Operation string
+	Provider  string
+	Model     string
+	Endpoint  string
+	Stream    bool
+	// Header holds the extra headers sent with the request. Observers may
+	// add to it, e.g. to propagate trace context. Credentials are set by the
+	// client afterwards and never appear here.
+	Header http.Header
+	// Body is the marshalled request body.
+	Body []byte
+	// Request is the request the call was made for.
+	Request *union.Request
+}
+
+// Result describes the outcome of a Call.
+type Result struct {
+	Metadata      *union.Metadata
+	Usage         *union.Usage
+	FinishReasons []string
+	// Body is the raw response body. It is nil for streams.
+	Body []byte
+	// Output is the generated text, when the provider response exposes it.
+	Output  string
+	Err     error
+	Latency time.Duration
+	// TimeToFirstToken is set for streams.
+	TimeToFirstToken time.Duration
+}
+
+// Observer is notified at the start and end of every provider call.
+type Observer interface {
+	// Start is called before the request is sent. The returned context is
+	// passed to End and used for the request.
+	Start(ctx context.Context, call *Call) context.Context
+	// End is called once with the outcome of the call.
+	End(ctx context.Context, call *Call, res *Result)
+}
+
+type multi []Observer
+
+// Multi combines observers. Start runs in order, End in reverse order.
+func Multi(observers ...Observer) Observer {
+	var m multi
+	for _, o := range observers {
+		if o != nil {
+			m = append(m, o)
+		}
+	}
+	switch len(m) {
+	case 0:
+		return nil
+	case 1:
+		return m[0]
+	}
+	return m
+}
+
+func (m multi) Start(ctx context.Context, call *Call) context.Context {
+	for _, o := range m {
+		ctx = o.Start(ctx, call)
+	}
+	return ctx
+}
+
+func (m multi) End(ctx context.Context, call *Call, res *Result) {
+	for i := len(m) - 1; i >= 0; i-- {
+		m[i].End(ctx, call, res)
+	}
+}
+
+// Stream wraps a streamed response so that o.End is called once the stream
+// is exhausted, fails or is closed. It records the time to first token, the
+// usage and the finish reasons reported by the stream. start is the time the
+// request was sent.
+func Stream(ctx context.Context, o Observer, call *Call, start time.Time, meta *union.Metadata, s union.Stream) union.Stream {
+	if o == nil {
+		return s
+	}
+	return &stream{
+		Stream: s,
+		ctx:    ctx,
+		o:      o,
+		call:   call,
+		start:  start,
+		res:    &Result{Metadata: meta},
+	}
+}
+
+type stream struct {
+	union.Stream
+	ctx   context.Context
+	o     Observer
+	call  *Call
+	start time.Time
+	res   *Result
+	once  sync.Once
+}
+
+func (s *stream) Recv() (*union.StreamEvent, error) {
+	ev, err := s.Stream.Recv()
+	if ev != nil {
+		if ev.Delta != "" && s.res.TimeToFirstToken == 0 {
+			s.res.TimeToFirstToken = time.Since(s.start)
+		}
+		s.res.Output += ev.Delta
+		if ev.Usage != nil {
+			s.res.Usage = ev.Usage
+		}
+		if ev.FinishReason != "" {
+			s.res.FinishReasons = append(s.res.FinishReasons, ev.FinishReason)
+		}
+	}
+	if err != nil {
+		if err != io.EOF {
+			s.res.Err = err
+		}
+		s.end()
+	}
+	return ev, err
+}
+
+func (s *stream) Close() error {
+	err := s.Stream.Close()
+	s.end()
+	return err
+}
+
+func (s *stream) end() {
+	s.once.Do(func() {
+		s.res.Latency = time.Since(s.start)
+		s.o.End(s.ctx, s.call, s.res)
+	})
+}
+
+// NewCall describes a request made for opts. The extra headers of opts are
+// copied so observers can add to them.
+func NewCall(provider, model, endpoint string, stream bool, opts *union.Request, body []byte) *Call {
+	header := http.Header{}
+	for k, vs := range opts.Header {
+		header[k] = append([]string(nil), vs...)
+	}
+	return &Call{
+		Provider: provider,
+		Model:    model,
+		Endpoint: endpoint,
+		Stream:   stream,
+		Header:   header,
+		Body:     body,
+		Request:  opts,
+	}
+}
+
+// NewEmbedCall describes an embeddings request made for opts. Request is
+// set to a union.Request carrying the context and the extra headers of
+// opts.
+func NewEmbedCall(provider, model, endpoint string, opts *union.EmbedRequest, body []byte) *Call {
+	call := NewCall(provider, model, endpoint, false, &union.Request{
+		Context: opts.Context,
+		Header:  opts.Header,
+	}, body)
+	call.Operation = OperationEmbeddings
+	return call
+}
+
+// OperationName returns the operation of the call, OperationChat by
+// default.
+func (c *Call) OperationName() string {
+	if c.Operation == "" {
+		return OperationChat
+	}
+	return c.Operation
+}
+
+// Begin calls o.Start if o is not nil.
+func Begin(ctx context.Context, o Observer, call *Call) context.Context {
+	if o == nil {
+		return ctx
+	}
+	return o.Start(ctx, call)
+}
+
+// Finish calls o.End if o is not nil.
+func Finish(ctx context.Context, o Observer, call *Call, res *Result) {
+	if o == nil {
+		return
+	}
+	o.End(ctx, call, res)
+}
+
+type retryKey struct{}
+
+// WithRetry returns a context marking the calls made with it as retries of
+// an attempt that failed on provider. ai.Router sets it on every attempt
+// after the first.
+func WithRetry(ctx context.Context, provider string) context.Context {
+	return context.WithValue(ctx, retryKey{}, provider)
+}
+
+// RetryOf returns the provider of the failed attempt the call retries, if
+// the call is a retry.
+func RetryOf(ctx context.Context) (string, bool) {
+	provider, ok := ctx.Value(retryKey{}).(string)
+	return provider, ok
+}
+
+// NewResult builds the Result of a non-streamed call from its response or
+// error. The metadata of an *union.APIError or *union.RequestError is
+// picked up as well.
+func NewResult(resp *union.Response, body []byte, err error, latency time.Duration) *Result {
+	res := &Result{
+		Body:    body,
+		Err:     err,
+		Latency: latency,
+	}
+	if resp != nil {
+		res.Metadata = resp.Metadata
+		res.Usage = resp.Usage
+		res.Output = resp.Output
+		if resp.FinishReason != "" {
+			res.FinishReasons = []string{resp.FinishReason}
+		}
+	}
+	if meta := union.MetadataOf(err); meta != nil {
+		res.Metadata = meta
+	}
+	return res
+}
+
+// NewEmbedResult builds the Result of an embeddings call from its response
+// or error.
+func NewEmbedResult(resp *union.EmbedResponse, body []byte, err error, latency time.Duration) *Result {
+	res := NewResult(nil, body, err, latency)
+	if resp != nil {
+		res.Metadata = resp.Metadata
+		res.Usage = resp.Usage
+	}
+	return res
+}
@@ -0,0 +1,198 @@
+// Package azure is a client for Azure OpenAI chat completions. It reuses
+// the ChatGPT Chat Completions types; requests are routed to the deployment
+// serving their model.
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/muraduiurie/gpt/pkg/ai/cache"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func init() {
+	cache.RegisterResponse(func() union.Responser { return &cgtypes.ChatCompletionResponse{} })
+}
+
+const (
+	// ProviderName identifies this provider in responses and routing attempts.
+	ProviderName = "azure"
+	// DefaultAPIVersion is used when APIVersion is empty.
+	DefaultAPIVersion = "2024-10-21"
+)
+
+// AuthMode selects how requests are authenticated.
+type AuthMode string
+
+const (
+	// AuthAPIKey sends the resource key in the `api-key` header.
+	AuthAPIKey AuthMode = "api_key"
+	// AuthEntra sends a Microsoft Entra ID bearer token from TokenSource.
+	AuthEntra AuthMode = "entra"
+)
+
+type Client struct {
+	// ApiToken is the resource key, used with AuthAPIKey.
+	ApiToken string
+	// TextInputEndpoint is the resource endpoint, e.g.
+	// "https://my-resource.openai.azure.com".
+	TextInputEndpoint string
+	// APIVersion is sent as the api-version query parameter. Defaults to
+	// DefaultAPIVersion.
+	APIVersion string
+	// Deployments maps model names to deployment names. A model without a
+	// mapping is used as the deployment name.
+	Deployments map[string]string
+	// AuthMode defaults to AuthAPIKey.
+	AuthMode AuthMode
+	// TokenSource supplies the bearer tokens of AuthEntra.
+	TokenSource TokenSource
+	// Keys, when set, supplies the resource key of each request instead of
+	// ApiToken.
+	Keys *keypool.Pool
+	// HTTPClient is used to send requests. Defaults to a client with a
+	// 300 second timeout.
+	HTTPClient *http.Client
+	// Observer is notified around every request, e.g. for logging.
+	Observer observe.Observer
+}
+
+// Provider returns the provider name, ProviderName.
+func (c *Client) Provider() string {
+	return ProviderName
+}
+
+// Endpoint returns the resource endpoint.
+func (c *Client) Endpoint() string {
+	return strings.TrimSuffix(c.TextInputEndpoint, "/")
+}
+
+// DefaultModel returns the model requests without one are sent to: the
+// model of the only deployment, or none when there are several.
+func (c *Client) DefaultModel(opts *union.Request) string {
+	if len(c.Deployments) != 1 {
+		return ""
+	}
+	for model := range c.Deployments {
+		return model
+	}
+	return ""
+}
+
+// AskAI sends a chat completions request to the deployment of its model and
+// returns the parsed response. An error is returned for invalid input,
+// network issues, or unexpected HTTP status codes.
+func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
+	chatRequest, err := c.chatRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := chatRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(chatRequest.Model), c.deploymentURL(chatRequest.Model), false, opts, body)
+	return c.wire().Chat(opts, call, &cgtypes.ChatCompletionResponse{})
+}
+
+// chatRequest returns the validated chat completions request of opts, with
+// defaults filled in. With a single deployment configured, its model is the
+// default.
+func (c *Client) chatRequest(opts *union.Request) (*cgtypes.ChatCompletionRequest, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+	requester := opts.TextRequest
+	if requester == nil && opts.Prompt != nil {
+		requester = fromPrompt(opts.Prompt)
+	}
+	chatRequest, ok := requester.(*cgtypes.ChatCompletionRequest)
+	if !ok {
+		return nil, fmt.Errorf("*cgtypes.ChatCompletionRequest type conversion failed")
+	}
+
+	if chatRequest.Model == "" {
+		if len(c.Deployments) != 1 {
+			return nil, errors.New("model is required to select the deployment")
+		}
+		for model := range c.Deployments {
+			chatRequest.Model = cgtypes.ChatGPTAIModel(model)
+		}
+	}
+	if len(chatRequest.Messages) == 0 {
+		return nil, errors.New("messages is required")
+	}
+	for i, m := range chatRequest.Messages {
+		if m.Role == "" {
+			chatRequest.Messages[i].Role = cgtypes.ChatGPTAIRoleUser
+		}
+		if m.Content == "" && len(m.ToolCalls) == 0 {
+			return nil, errors.New("content in message is required")
+		}
+	}
+
+	return chatRequest, nil
+}
+
+// deploymentURL returns the chat completions URL of the deployment serving
+// model.
+func (c *Client) deploymentURL(model cgtypes.ChatGPTAIModel) string {
+	deployment, ok := c.Deployments[string(model)]
+	if !ok {
+		deployment = string(model)
+	}
+	apiVersion := c.APIVersion
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		c.Endpoint(), url.PathEscape(deployment), url.QueryEscape(apiVersion))
+}
+
+// wire returns the transport requests are sent with. Resource keys are
+// leased from Keys unless AuthEntra is used.
+func (c *Client) wire() *openaiwire.Transport {
+	t := &openaiwire.Transport{
+		Provider:   ProviderName,
+		Token:      c.ApiToken,
+		HTTPClient: c.HTTPClient,
+		Observer:   c.Observer,
+		Authorize:  c.authorize,
+	}
+	if c.AuthMode != AuthEntra {
+		t.Keys = c.Keys
+	}
+	return t
+}
+
+// authorize sets the resource key or the Entra ID bearer token of req.
+func (c *Client) authorize(ctx context.Context, req *http.Request, token string) error {
+	if c.TextInputEndpoint == "" {
+		return errors.New("resource endpoint is required")
+	}
+	if c.AuthMode != AuthEntra {
+		req.Header.Set("api-key", token)
+		return nil
+	}
+
+	if c.TokenSource == nil {
+		return errors.New("entra auth requires a token source")
+	}
+	bearer, err := c.TokenSource.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("get entra token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	return nil
+}
@@ -0,0 +1,269 @@
+package azure_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/providers/azure"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+const resourceKey = "azure-key"
+
+// received is a request received by the test resource.
+type received struct {
+	Path, APIVersion string
+	Header           http.Header
+	Body             map[string]json.RawMessage
+}
+
+// resource is a local stand-in for an Azure OpenAI resource.
+type resource struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []received
+}
+
+func newResource(t *testing.T) *resource {
+	t.Helper()
+	res := &resource{}
+	res.Server = httptest.NewServer(http.HandlerFunc(res.handle))
+	t.Cleanup(res.Close)
+	return res
+}
+
+func (res *resource) handle(w http.ResponseWriter, r *http.Request) {
+	var body map[string]json.RawMessage
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	res.mu.Lock()
+	res.requests = append(res.requests, received{
+		Path:       r.URL.Path,
+		APIVersion: r.URL.Query().Get("api-version"),
+		Header:     r.Header.Clone(),
+		Body:       body,
+	})
+	res.mu.Unlock()
+
+	if r.Header.Get("api-key") != resourceKey && r.Header.Get("Authorization") != "Bearer entra-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"code":"401","message":"Access denied due to invalid subscription key."}}`)
+		return
+	}
+
+	if string(body["stream"]) != "true" {
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o",
+			"choices":[{"index":0,"message":{"role":"assistant","content":"Hello there"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, word := range []string{"Hello", " there"} {
+		fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", word)
+	}
+	fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+	fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2,\"total_tokens\":5}}\n\n")
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func (res *resource) last() received {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	return res.requests[len(res.requests)-1]
+}
+
+func request(model cgtypes.ChatGPTAIModel) *union.Request {
+	return &union.Request{TextRequest: &cgtypes.ChatCompletionRequest{
+		Model:    model,
+		Messages: []cgtypes.ChatCompletionMessage{{Content: "Say hello"}},
+	}}
+}
+
+func TestAskAIDeployment(t *testing.T) {
+	res := newResource(t)
+	c := &azure.Client{
+		ApiToken:          resourceKey,
+		TextInputEndpoint: res.URL + "/",
+		APIVersion:        "2025-01-01-preview",
+		Deployments:       map[string]string{"gpt-4o": "prod 4o"},
+	}
+
+	resp, err := c.AskAI(request(""))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "Hello there" || resp.Provider != azure.ProviderName || resp.Usage.TotalTokens != 5 {
+		t.Errorf("response = %q from %q, usage %+v", resp.Output, resp.Provider, resp.Usage)
+	}
+
+	req := res.last()
+	if want := "/openai/deployments/prod 4o/chat/completions"; req.Path != want {
+		t.Errorf("path = %q, want %q", req.Path, want)
+	}
+	if req.APIVersion != "2025-01-01-preview" {
+		t.Errorf("api-version = %q", req.APIVersion)
+	}
+	if got := req.Header.Get("api-key"); got != resourceKey {
+		t.Errorf("api-key = %q", got)
+	}
+	if got := string(req.Body["model"]); got != `"gpt-4o"` {
+		t.Errorf("model = %s, want the model of the only deployment", got)
+	}
+}
+
+func TestAskAIUnmappedModel(t *testing.T) {
+	res := newResource(t)
+	c := &azure.Client{
+		ApiToken:          resourceKey,
+		TextInputEndpoint: res.URL,
+		Deployments:       map[string]string{"gpt-4o": "prod-4o", "gpt-4o-mini": "prod-mini"},
+	}
+
+	if _, err := c.AskAI(request("")); err == nil {
+		t.Error("AskAI without a model succeeded with several deployments")
+	}
+	if _, err := c.AskAI(request("gpt-4.1")); err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	req := res.last()
+	if req.Path != "/openai/deployments/gpt-4.1/chat/completions" || req.APIVersion != azure.DefaultAPIVersion {
+		t.Errorf("URL = %s?api-version=%s, want the model as deployment and the default version", req.Path, req.APIVersion)
+	}
+}
+
+func TestStreamAI(t *testing.T) {
+	res := newResource(t)
+	c := &azure.Client{ApiToken: resourceKey, TextInputEndpoint: res.URL}
+
+	s, err := c.StreamAI(request("gpt-4o"))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	defer s.Close()
+
+	var (
+		text  strings.Builder
+		usage *union.Usage
+	)
+	for {
+		ev, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		text.WriteString(ev.Delta)
+		if ev.Usage != nil {
+			usage = ev.Usage
+		}
+	}
+	if text.String() != "Hello there" {
+		t.Errorf("streamed %q", text.String())
+	}
+	if usage == nil || usage.TotalTokens != 5 {
+		t.Errorf("usage = %+v, want the usage of the last chunk", usage)
+	}
+	if got := string(res.last().Body["stream_options"]); got != `{"include_usage":true}` {
+		t.Errorf("stream_options = %s", got)
+	}
+}
+
+func TestAskAIErrorStatus(t *testing.T) {
+	res := newResource(t)
+	c := &azure.Client{ApiToken: "wrong", TextInputEndpoint: res.URL}
+
+	_, err := c.AskAI(request("gpt-4o"))
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want a 401 *union.APIError", err)
+	}
+}
+
+func TestEntra(t *testing.T) {
+	var tokenRequests int
+	authority := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		if r.URL.Path != "/my-tenant/oauth2/v2.0/token" {
+			t.Errorf("token path = %s", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		for key, want := range map[string]string{
+			"grant_type":    "client_credentials",
+			"client_id":     "my-client",
+			"client_secret": "my-secret",
+			"scope":         azure.DefaultEntraScope,
+		} {
+			if got := r.PostForm.Get(key); got != want {
+				t.Errorf("%s = %q, want %q", key, got, want)
+			}
+		}
+		fmt.Fprint(w, `{"token_type":"Bearer","expires_in":3599,"access_token":"entra-token"}`)
+	}))
+	defer authority.Close()
+
+	res := newResource(t)
+	c := &azure.Client{
+		TextInputEndpoint: res.URL,
+		AuthMode:          azure.AuthEntra,
+		TokenSource: &azure.ClientCredentials{
+			TenantID:     "my-tenant",
+			ClientID:     "my-client",
+			ClientSecret: "my-secret",
+			Authority:    authority.URL,
+		},
+	}
+
+	for range 2 {
+		if _, err := c.AskAI(request("gpt-4o")); err != nil {
+			t.Fatalf("AskAI: %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("requested %d tokens, want 1 cached", tokenRequests)
+	}
+	req := res.last()
+	if got := req.Header.Get("Authorization"); got != "Bearer entra-token" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if got := req.Header.Get("api-key"); got != "" {
+		t.Errorf("api-key = %q sent with Entra auth", got)
+	}
+}
+
+func TestEntraTokenError(t *testing.T) {
+	authority := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"invalid_client","error_description":"AADSTS7000215: Invalid client secret provided."}`)
+	}))
+	defer authority.Close()
+
+	res := newResource(t)
+	c := &azure.Client{
+		TextInputEndpoint: res.URL,
+		AuthMode:          azure.AuthEntra,
+		TokenSource: &azure.ClientCredentials{
+			TenantID:     "my-tenant",
+			ClientID:     "my-client",
+			ClientSecret: "wrong",
+			Authority:    authority.URL,
+		},
+	}
+
+	_, err := c.AskAI(request("gpt-4o"))
+	if err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Fatalf("err = %v, want the token error", err)
+	}
+	if len(res.requests) != 0 {
+		t.Errorf("resource got %d requests without a token", len(res.requests))
+	}
+}
@@ -0,0 +1,71 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/oauth"
+)
+
+const (
+	// DefaultEntraScope is the scope of tokens for Azure OpenAI.
+	DefaultEntraScope = "https://cognitiveservices.azure.com/.default"
+	// DefaultAuthority is the Microsoft Entra ID login endpoint.
+	DefaultAuthority = "https://login.microsoftonline.com"
+)
+
+// TokenSource supplies Microsoft Entra ID bearer tokens.
+type TokenSource = oauth.TokenSource
+
+// StaticToken is a TokenSource returning a fixed token, e.g. one obtained
+// with `az account get-access-token`.
+type StaticToken = oauth.StaticToken
+
+// ClientCredentials obtains tokens for a service principal with the OAuth 2.0
+// client credentials flow and caches them until shortly before they expire.
+type ClientCredentials struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	// Scope defaults to DefaultEntraScope.
+	Scope string
+	// Authority defaults to DefaultAuthority.
+	Authority string
+	// HTTPClient defaults to a client with a 30 second timeout.
+	HTTPClient *http.Client
+
+	cache oauth.Cache
+}
+
+// Token returns the cached token, or requests a new one.
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	return c.cache.Token(ctx, c.requestToken)
+}
+
+func (c *ClientCredentials) requestToken(ctx context.Context) (string, time.Duration, error) {
+	if c.TenantID == "" || c.ClientID == "" || c.ClientSecret == "" {
+		return "", 0, errors.New("tenant ID, client ID and client secret are required")
+	}
+
+	scope := c.Scope
+	if scope == "" {
+		scope = DefaultEntraScope
+	}
+	authority := c.Authority
+	if authority == "" {
+		authority = DefaultAuthority
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"scope":         {scope},
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authority, "/"), url.PathEscape(c.TenantID))
+	return oauth.RequestToken(ctx, c.HTTPClient, tokenURL, form)
+}
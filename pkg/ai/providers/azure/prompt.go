@@ -0,0 +1,20 @@
+package azure
+
+import (
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// fromPrompt translates a provider-neutral prompt into a chat completions
+// request. The system prompt becomes a leading system message.
+func fromPrompt(p *union.Prompt) *cgtypes.ChatCompletionRequest {
+	return &cgtypes.ChatCompletionRequest{
+		Model:               cgtypes.ChatGPTAIModel(p.Model),
+		Temperature:         p.Temperature,
+		MaxCompletionTokens: openaiwire.MaxTokens(p),
+		Messages: openaiwire.PromptMessages(p, func(role, content string) cgtypes.ChatCompletionMessage {
+			return cgtypes.ChatCompletionMessage{Role: cgtypes.ChatGPTAIRole(role), Content: content}
+		}),
+	}
+}
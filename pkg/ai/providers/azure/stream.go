@@ -0,0 +1,30 @@
+package azure
+
+import (
+	"fmt"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// StreamAI sends a chat completions request with streaming enabled and
+// returns the answer as a stream of text deltas. Usage is requested through
+// stream_options and reported on the last event.
+func (c *Client) StreamAI(opts *union.Request) (union.Stream, error) {
+	chatRequest, err := c.chatRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	streamRequest := *chatRequest
+	streamRequest.Stream = true
+	streamRequest.StreamOptions = &cgtypes.ChatCompletionStreamOptions{IncludeUsage: true}
+	body, err := streamRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(chatRequest.Model), c.deploymentURL(chatRequest.Model), true, opts, body)
+	return c.wire().StreamChat(opts, call)
+}
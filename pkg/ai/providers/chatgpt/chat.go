@@ -0,0 +1,83 @@
+package chatgpt
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// askChat sends a Chat Completions request. The response output is the
+// message of the first choice.
+func (c *Client) askChat(opts *union.Request) (*union.Response, error) {
+	chatRequest, err := c.chatRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := chatRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(chatRequest.Model), c.Endpoint(), false, opts, body)
+	return c.wire().Chat(opts, call, &cgtypes.ChatCompletionResponse{})
+}
+
+// chatRequest returns the validated Chat Completions request of opts, with
+// defaults filled in.
+func (c *Client) chatRequest(opts *union.Request) (*cgtypes.ChatCompletionRequest, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+	requester := opts.TextRequest
+	if requester == nil && opts.Prompt != nil {
+		requester = chatFromPrompt(opts.Prompt)
+	}
+	chatRequest, ok := requester.(*cgtypes.ChatCompletionRequest)
+	if !ok {
+		return nil, fmt.Errorf("*cgtypes.ChatCompletionRequest type conversion failed")
+	}
+
+	if chatRequest.Model == "" {
+		chatRequest.Model = cgtypes.ChatGPTAIModel(c.DefaultModel(opts))
+	}
+	if err := checkTextModel(chatRequest.Model); err != nil {
+		return nil, err
+	}
+	if len(chatRequest.Messages) == 0 {
+		return nil, errors.New("messages is required")
+	}
+	for i, m := range chatRequest.Messages {
+		if m.Role == "" {
+			chatRequest.Messages[i].Role = cgtypes.ChatGPTAIRoleUser
+		}
+		if m.Content == "" && len(m.ToolCalls) == 0 {
+			return nil, errors.New("content in message is required")
+		}
+	}
+
+	return chatRequest, nil
+}
+
+// streamChat sends a Chat Completions request with streaming enabled. Usage
+// is requested through stream_options and reported on the last event.
+func (c *Client) streamChat(opts *union.Request) (union.Stream, error) {
+	chatRequest, err := c.chatRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	streamRequest := *chatRequest
+	streamRequest.Stream = true
+	streamRequest.StreamOptions = &cgtypes.ChatCompletionStreamOptions{IncludeUsage: true}
+	body, err := streamRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(chatRequest.Model), c.Endpoint(), true, opts, body)
+	return c.wire().StreamChat(opts, call)
+}
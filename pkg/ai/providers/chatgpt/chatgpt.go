@@ -1,20 +1,118 @@
 package chatgpt
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"time"
+	"strings"
 
+	"github.com/muraduiurie/gpt/pkg/ai/cache"
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
 	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
 	"github.com/muraduiurie/gpt/pkg/ai/types/union"
 )
 
+func init() {
+	cache.RegisterResponse(func() union.Responser { return &cgtypes.TextInputResponse{} })
+	cache.RegisterResponse(func() union.Responser { return &cgtypes.ChatCompletionResponse{} })
+}
+
+const (
+	// ProviderName identifies this provider in responses and routing attempts.
+	ProviderName = "chatgpt"
+	// DefaultTextInputEndpoint is used when TextInputEndpoint is empty.
+	DefaultTextInputEndpoint = "https://api.openai.com/v1/responses"
+	// DefaultChatCompletionsEndpoint is used instead in ModeChatCompletions.
+	DefaultChatCompletionsEndpoint = "https://api.openai.com/v1/chat/completions"
+	// DefaultEmbeddingsEndpoint is used when EmbeddingsEndpoint is empty.
+	DefaultEmbeddingsEndpoint = "https://api.openai.com/v1/embeddings"
+	// DefaultSpeechEndpoint is used when SpeechEndpoint is empty.
+	DefaultSpeechEndpoint = "https://api.openai.com/v1/audio/speech"
+	// DefaultTranscriptionsEndpoint is used when TranscriptionsEndpoint is
+	// empty.
+	DefaultTranscriptionsEndpoint = "https://api.openai.com/v1/audio/transcriptions"
+	// DefaultTranslationsEndpoint is used when TranslationsEndpoint is
+	// empty.
+	DefaultTranslationsEndpoint = "https://api.openai.com/v1/audio/translations"
+	// DefaultImageGenerationsEndpoint is used when ImageGenerationsEndpoint
+	// is empty.
+	DefaultImageGenerationsEndpoint = "https://api.openai.com/v1/images/generations"
+	// DefaultImageEditsEndpoint is used when ImageEditsEndpoint is empty.
+	DefaultImageEditsEndpoint = "https://api.openai.com/v1/images/edits"
+)
+
+// Mode selects the OpenAI API the client speaks.
+type Mode string
+
+const (
+	// ModeResponses uses the Responses API (`/v1/responses`) with
+	// cgtypes.TextInputRequest.
+	ModeResponses Mode = "responses"
+	// ModeChatCompletions uses the Chat Completions API
+	// (`/v1/chat/completions`) with cgtypes.ChatCompletionRequest, for
+	// proxies and gateways that only expose it.
+	ModeChatCompletions Mode = "chat_completions"
+)
+
 type Client struct {
 	ApiToken          string
 	TextInputEndpoint string
+	// Mode selects the API. Defaults to ModeResponses.
+	Mode Mode
+	// EmbeddingsEndpoint is where EmbedAI sends requests. Defaults to
+	// DefaultEmbeddingsEndpoint.
+	EmbeddingsEndpoint string
+	// EmbeddingModel is used for embedding requests without a model.
+	// Defaults to cgtypes.AiModelTextEmbedding3Small.
+	EmbeddingModel cgtypes.ChatGPTAIModel
+	// EmbedLimits bound the requests of one EmbedAI call. Zero fields take
+	// the values of DefaultEmbedLimits.
+	EmbedLimits embed.Limits
+	// SpeechEndpoint is where Speech sends requests. Defaults to
+	// DefaultSpeechEndpoint.
+	SpeechEndpoint string
+	// TranscriptionsEndpoint and TranslationsEndpoint are where Transcribe
+	// and Translate send requests. They default to
+	// DefaultTranscriptionsEndpoint and DefaultTranslationsEndpoint.
+	TranscriptionsEndpoint string
+	TranslationsEndpoint   string
+	// ImageGenerationsEndpoint and ImageEditsEndpoint are where
+	// GenerateImage and EditImage send requests. They default to
+	// DefaultImageGenerationsEndpoint and DefaultImageEditsEndpoint.
+	ImageGenerationsEndpoint string
+	ImageEditsEndpoint       string
+	// Keys, when set, supplies the API token of each request instead of
+	// ApiToken.
+	Keys *keypool.Pool
+	// HTTPClient is used to send requests. Defaults to a client with a
+	// 300 second timeout.
+	HTTPClient *http.Client
+	// Observer is notified around every request, e.g. for logging.
+	Observer observe.Observer
+}
+
+// Provider returns the provider name, ProviderName.
+func (c *Client) Provider() string {
+	return ProviderName
+}
+
+// Endpoint returns the text input endpoint requests are sent to.
+func (c *Client) Endpoint() string {
+	if c.TextInputEndpoint != "" {
+		return c.TextInputEndpoint
+	}
+	if c.Mode == ModeChatCompletions {
+		return DefaultChatCompletionsEndpoint
+	}
+	return DefaultTextInputEndpoint
+}
+
+// DefaultModel returns the model requests without one are sent to.
+func (c *Client) DefaultModel(opts *union.Request) string {
+	return string(cgtypes.AiModelGpt4_1)
 }
 
 // AskAI sends a text request to the configured ChatGPT endpoint and returns
@@ -22,13 +120,49 @@ type Client struct {
 // and unmarshals the response body. An error is returned for invalid input,
 // network issues, or unexpected HTTP status codes.
 func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
-	if c.TextInputEndpoint == "" {
-		c.TextInputEndpoint = "https://api.openai.com/v1/responses"
+	if c.Mode == ModeChatCompletions {
+		return c.askChat(opts)
 	}
+
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := textRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(textRequest.Model), c.Endpoint(), false, opts, body)
+	return c.wire().Do(opts, call, func(meta *union.Metadata, body []byte) (*union.Response, error) {
+		var textResponse cgtypes.TextInputResponse
+		if err := textResponse.Unmarshal(body); err != nil {
+			return nil, err
+		}
+
+		return &union.Response{
+			TextResponse: &textResponse,
+			Metadata:     meta,
+			Provider:     ProviderName,
+			Output:       output(&textResponse),
+			FinishReason: textResponse.Status,
+			Usage:        usage(textResponse.Usage),
+		}, nil
+	})
+}
+
+// textRequest returns the validated ChatGPT request of opts, with defaults
+// filled in.
+func (c *Client) textRequest(opts *union.Request) (*cgtypes.TextInputRequest, error) {
 	if opts == nil {
 		return nil, errors.New("nil opts")
 	}
-	textRequest, ok := opts.TextRequest.(*cgtypes.TextInputRequest)
+	requester := opts.TextRequest
+	if requester == nil && opts.Prompt != nil {
+		requester = fromPrompt(opts.Prompt)
+	}
+	textRequest, ok := requester.(*cgtypes.TextInputRequest)
 	if !ok {
 		return nil, fmt.Errorf("*cgtypes.TextInputRequest type conversion failed")
 	}
@@ -37,44 +171,51 @@ func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
 		return nil, errors.New("message is required")
 	}
 	if textRequest.Model == "" {
-		textRequest.Model = cgtypes.AiModelGpt4_1
+		textRequest.Model = cgtypes.ChatGPTAIModel(c.DefaultModel(opts))
 	}
-
-	body, err := opts.TextRequest.Marshal()
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
+	if err := checkTextModel(textRequest.Model); err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.TextInputEndpoint, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.ApiToken)
+	return textRequest, nil
+}
 
-	httpClient := &http.Client{Timeout: 300 * time.Second}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("do request: %w", err)
+// wire returns the transport requests are sent with.
+func (c *Client) wire() *openaiwire.Transport {
+	return &openaiwire.Transport{
+		Provider:   ProviderName,
+		Token:      c.ApiToken,
+		Keys:       c.Keys,
+		HTTPClient: c.HTTPClient,
+		Observer:   c.Observer,
 	}
-	defer resp.Body.Close()
+}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+// checkTextModel rejects the speech models, which only Speech serves.
+func checkTextModel(model cgtypes.ChatGPTAIModel) error {
+	switch model {
+	case cgtypes.AiModelTTS1, cgtypes.AiModelTTS1_HD, cgtypes.AiModelGpt4oMiniTTS:
+		return fmt.Errorf("%s is a text-to-speech model: use Client.Speech", model)
 	}
+	return nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+func usage(u cgtypes.ResponseUsage) *union.Usage {
+	return &union.Usage{
+		InputTokens:       u.InputTokens,
+		OutputTokens:      u.OutputTokens,
+		TotalTokens:       u.TotalTokens,
+		CachedInputTokens: u.InputTokensDetails.CachedTokens,
 	}
+}
 
-	var textResponse cgtypes.TextInputResponse
-	err = textResponse.Unmarshal(respBody)
-	if err != nil {
-		return nil, err
+// output concatenates the text of the message output items.
+func output(r *cgtypes.TextInputResponse) string {
+	var sb strings.Builder
+	for _, o := range r.Output {
+		for _, c := range o.Content {
+			sb.WriteString(c.Text)
+		}
 	}
-
-	return &union.Response{
-		TextResponse: &textResponse,
-	}, nil
+	return sb.String()
 }
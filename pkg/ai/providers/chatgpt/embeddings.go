@@ -0,0 +1,36 @@
+package chatgpt
+
+import (
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// DefaultEmbedLimits are OpenAI's limits for one `/v1/embeddings` request,
+// with four requests in flight.
+var DefaultEmbedLimits = embed.Limits{
+	MaxInputs:   2048,
+	MaxTokens:   300000,
+	Concurrency: 4,
+}
+
+// EmbedAI returns the embeddings of the inputs from `/v1/embeddings`.
+// Inputs above the limits of one request are split into batches sent
+// concurrently; the vectors are returned in input order and the usage is
+// summed. Requests without a model use EmbeddingModel, or
+// cgtypes.AiModelTextEmbedding3Small.
+func (c *Client) EmbedAI(opts *union.EmbedRequest) (*union.EmbedResponse, error) {
+	model := c.EmbeddingModel
+	if model == "" {
+		model = cgtypes.AiModelTextEmbedding3Small
+	}
+	return c.wire().Embed(opts, c.embeddingsEndpoint(), string(model), c.EmbedLimits.Or(DefaultEmbedLimits))
+}
+
+// embeddingsEndpoint returns the endpoint embedding requests are sent to.
+func (c *Client) embeddingsEndpoint() string {
+	if c.EmbeddingsEndpoint != "" {
+		return c.EmbeddingsEndpoint
+	}
+	return DefaultEmbeddingsEndpoint
+}
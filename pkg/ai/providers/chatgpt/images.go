@@ -0,0 +1,212 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// GenerateImage creates images from a prompt with
+// `/v1/images/generations`. Requests without a model use
+// cgtypes.AiModelGptImage1.
+func (c *Client) GenerateImage(ctx context.Context, r *cgtypes.ImageRequest) (*cgtypes.ImageResponse, error) {
+	if r == nil {
+		return nil, errors.New("nil request")
+	}
+	req := *r
+	if req.Model == "" {
+		req.Model = cgtypes.AiModelGptImage1
+	}
+	if err := checkImageRequest(req.Prompt, req.N, req.Background, req.OutputFormat); err != nil {
+		return nil, err
+	}
+
+	body, err := req.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	endpoint := c.ImageGenerationsEndpoint
+	if endpoint == "" {
+		endpoint = DefaultImageGenerationsEndpoint
+	}
+	call := observe.NewCall(ProviderName, string(req.Model), endpoint, false, &union.Request{Context: ctx}, body)
+	call.Operation = observe.OperationImageGeneration
+	return c.images(ctx, call, "application/json", body)
+}
+
+// EditImage edits or extends images from a prompt with `/v1/images/edits`,
+// uploading the images and the mask as a multipart form. Requests without
+// a model use cgtypes.AiModelGptImage1.
+func (c *Client) EditImage(ctx context.Context, r *cgtypes.ImageEditRequest) (*cgtypes.ImageResponse, error) {
+	if r == nil {
+		return nil, errors.New("nil request")
+	}
+	req := *r
+	if req.Model == "" {
+		req.Model = cgtypes.AiModelGptImage1
+	}
+	if err := checkImageRequest(req.Prompt, req.N, req.Background, req.OutputFormat); err != nil {
+		return nil, err
+	}
+
+	form, contentType, err := req.Multipart()
+	if err != nil {
+		return nil, err
+	}
+	logBody, err := req.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	endpoint := c.ImageEditsEndpoint
+	if endpoint == "" {
+		endpoint = DefaultImageEditsEndpoint
+	}
+	call := observe.NewCall(ProviderName, string(req.Model), endpoint, false, &union.Request{Context: ctx}, logBody)
+	call.Operation = observe.OperationImageEdit
+	return c.images(ctx, call, contentType, form)
+}
+
+// images sends an image request with a key of the pool, if any.
+func (c *Client) images(ctx context.Context, call *observe.Call, contentType string, body []byte) (*cgtypes.ImageResponse, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var imageResponse *cgtypes.ImageResponse
+	_, err := c.wire().Lease(func(token string) (*union.Response, error) {
+		ctx := observe.Begin(ctx, c.Observer, call)
+		start := time.Now()
+
+		meta, respBody, err := c.wire().Post(ctx, call, token, contentType, body)
+		if err == nil {
+			imageResponse = &cgtypes.ImageResponse{}
+			if err = imageResponse.Unmarshal(respBody); err != nil {
+				imageResponse = nil
+				err = &union.RequestError{Err: fmt.Errorf("failed to unmarshal response: %w", err), Metadata: meta}
+			}
+		}
+		res := observe.NewResult(nil, respBody, err, time.Since(start))
+		if meta != nil {
+			res.Metadata = meta
+		}
+		if imageResponse != nil && imageResponse.Usage != nil {
+			res.Usage = &union.Usage{
+				InputTokens:  imageResponse.Usage.InputTokens,
+				OutputTokens: imageResponse.Usage.OutputTokens,
+				TotalTokens:  imageResponse.Usage.TotalTokens,
+			}
+		}
+		observe.Finish(ctx, c.Observer, call, res)
+
+		return &union.Response{Metadata: res.Metadata, Usage: res.Usage}, err
+	})
+
+	return imageResponse, err
+}
+
+func checkImageRequest(prompt string, n *int, background cgtypes.ImageBackground, format cgtypes.ImageFormat) error {
+	if prompt == "" {
+		return errors.New("prompt is required")
+	}
+	if n != nil && (*n < 1 || *n > 10) {
+		return fmt.Errorf("n %d is out of range [1, 10]", *n)
+	}
+	if background == cgtypes.ImageBackgroundTransparent && format == cgtypes.ImageFormatJPEG {
+		return errors.New("a transparent background needs the png or webp format")
+	}
+	return nil
+}
+
+// ReadImage returns the bytes of a generated image, decoding it or
+// downloading it from its URL.
+func (c *Client) ReadImage(ctx context.Context, d *cgtypes.ImageData) ([]byte, error) {
+	if d == nil {
+		return nil, errors.New("nil image")
+	}
+	if d.B64JSON != "" {
+		b, err := d.Decode()
+		if err != nil {
+			return nil, fmt.Errorf("decode image: %w", err)
+		}
+		return b, nil
+	}
+	if d.URL == "" {
+		return nil, errors.New("image has neither data nor URL")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// the URL is signed; it takes no API token
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	start := time.Now()
+	resp, err := c.wire().HTTP().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("download image: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &union.APIError{
+			StatusCode: resp.StatusCode,
+			Body:       b,
+			Metadata:   union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start)),
+		}
+	}
+	return b, nil
+}
+
+// SaveImages writes the images of resp to files and returns their paths.
+// A single image is written to path; several are numbered before the
+// extension, e.g. "cat-1.png" and "cat-2.png". Without an extension, path
+// gets the one of the response output format, png by default.
+func (c *Client) SaveImages(ctx context.Context, resp *cgtypes.ImageResponse, path string) ([]string, error) {
+	if resp == nil || len(resp.Data) == 0 {
+		return nil, errors.New("no images to save")
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	if ext == "" {
+		ext = ".png"
+		if resp.OutputFormat != "" {
+			ext = "." + string(resp.OutputFormat)
+		}
+	}
+
+	paths := make([]string, 0, len(resp.Data))
+	for i := range resp.Data {
+		b, err := c.ReadImage(ctx, &resp.Data[i])
+		if err != nil {
+			return paths, fmt.Errorf("image %d: %w", i+1, err)
+		}
+		p := base + ext
+		if len(resp.Data) > 1 {
+			p = fmt.Sprintf("%s-%d%s", base, i+1, ext)
+		}
+		if err = os.WriteFile(p, b, 0o644); err != nil {
+			return paths, fmt.Errorf("write image: %w", err)
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
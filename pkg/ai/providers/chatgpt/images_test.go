@@ -0,0 +1,182 @@
+package chatgpt_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/chatgpt"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// newImageHost starts a server serving the image at /cat.png and a 403
+// elsewhere, as expired signed URLs are answered.
+func newImageHost(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q sent to the image URL", got)
+		}
+		if r.URL.Path != "/cat.png" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "<Error><Code>AuthenticationFailed</Code></Error>")
+			return
+		}
+		fmt.Fprint(w, "downloaded cat")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGenerateImage(t *testing.T) {
+	var req map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/images/generations" {
+			t.Errorf("path = %s, want /v1/images/generations", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer "+aitest.DefaultAPIKey {
+			t.Errorf("Authorization = %q", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		fmt.Fprintf(w, `{"created":1713833628,"data":[{"b64_json":%q},{"b64_json":%q}],
+			"output_format":"webp","size":"1024x1024",
+			"usage":{"input_tokens":10,"output_tokens":4160,"total_tokens":4170,"input_tokens_details":{"text_tokens":10}}}`,
+			b64("first cat"), b64("second cat"))
+	}))
+	defer srv.Close()
+
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey, ImageGenerationsEndpoint: srv.URL + "/v1/images/generations"}
+	n := 2
+	resp, err := c.GenerateImage(t.Context(), &cgtypes.ImageRequest{
+		Prompt:       "A cat on a windowsill",
+		N:            &n,
+		OutputFormat: cgtypes.ImageFormatWebP,
+	})
+	if err != nil {
+		t.Fatalf("GenerateImage: %v", err)
+	}
+	if len(resp.Data) != 2 || resp.OutputFormat != cgtypes.ImageFormatWebP || resp.Usage.TotalTokens != 4170 {
+		t.Errorf("response = %+v", resp)
+	}
+	for key, want := range map[string]string{
+		"model":         `"gpt-image-1"`,
+		"prompt":        `"A cat on a windowsill"`,
+		"n":             "2",
+		"output_format": `"webp"`,
+	} {
+		if got := string(req[key]); got != want {
+			t.Errorf("%s = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestGenerateImageInvalidRequest(t *testing.T) {
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey, ImageGenerationsEndpoint: "http://127.0.0.1:1"}
+	n := 11
+	for name, r := range map[string]*cgtypes.ImageRequest{
+		"prompt": {},
+		"n":      {Prompt: "A cat", N: &n},
+		"background": {
+			Prompt:       "A cat",
+			Background:   cgtypes.ImageBackgroundTransparent,
+			OutputFormat: cgtypes.ImageFormatJPEG,
+		},
+	} {
+		if _, err := c.GenerateImage(t.Context(), r); err == nil {
+			t.Errorf("%s: GenerateImage succeeded", name)
+		}
+	}
+}
+
+func TestReadImage(t *testing.T) {
+	host := newImageHost(t)
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey}
+
+	b, err := c.ReadImage(t.Context(), &cgtypes.ImageData{B64JSON: b64("encoded cat")})
+	if err != nil || string(b) != "encoded cat" {
+		t.Errorf("ReadImage(base64) = %q, %v", b, err)
+	}
+	b, err = c.ReadImage(t.Context(), &cgtypes.ImageData{URL: host.URL + "/cat.png"})
+	if err != nil || string(b) != "downloaded cat" {
+		t.Errorf("ReadImage(URL) = %q, %v", b, err)
+	}
+
+	_, err = c.ReadImage(t.Context(), &cgtypes.ImageData{URL: host.URL + "/expired.png"})
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("err = %v, want a 403 *union.APIError", err)
+	}
+	if _, err = c.ReadImage(t.Context(), &cgtypes.ImageData{B64JSON: "not base64!"}); err == nil {
+		t.Error("ReadImage of invalid base64 succeeded")
+	}
+	if _, err = c.ReadImage(t.Context(), &cgtypes.ImageData{}); err == nil {
+		t.Error("ReadImage without data or URL succeeded")
+	}
+}
+
+func TestSaveImages(t *testing.T) {
+	host := newImageHost(t)
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey}
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "cats"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	two := &cgtypes.ImageResponse{Data: []cgtypes.ImageData{
+		{B64JSON: b64("encoded cat")},
+		{URL: host.URL + "/cat.png"},
+	}}
+	one := &cgtypes.ImageResponse{Data: []cgtypes.ImageData{{B64JSON: b64("encoded cat")}}}
+	webp := &cgtypes.ImageResponse{Data: one.Data, OutputFormat: cgtypes.ImageFormatWebP}
+
+	for _, tc := range []struct {
+		name string
+		resp *cgtypes.ImageResponse
+		path string
+		want map[string]string
+	}{
+		{"numbered", two, "cats/cat.png", map[string]string{"cats/cat-1.png": "encoded cat", "cats/cat-2.png": "downloaded cat"}},
+		{"single", one, "cat.png", map[string]string{"cat.png": "encoded cat"}},
+		{"default extension", one, "plain", map[string]string{"plain.png": "encoded cat"}},
+		{"output format", webp, "modern", map[string]string{"modern.webp": "encoded cat"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			paths, err := c.SaveImages(t.Context(), tc.resp, filepath.Join(dir, tc.path))
+			if err != nil {
+				t.Fatalf("SaveImages: %v", err)
+			}
+			if len(paths) != len(tc.want) {
+				t.Errorf("paths = %v, want %d", paths, len(tc.want))
+			}
+			for _, p := range paths {
+				rel, _ := filepath.Rel(dir, p)
+				want, ok := tc.want[filepath.ToSlash(rel)]
+				if !ok {
+					t.Errorf("wrote unexpected %s", rel)
+					continue
+				}
+				if b, err := os.ReadFile(p); err != nil || string(b) != want {
+					t.Errorf("%s = %q, %v, want %q", rel, b, err, want)
+				}
+			}
+		})
+	}
+
+	if _, err := c.SaveImages(t.Context(), &cgtypes.ImageResponse{}, filepath.Join(dir, "none.png")); err == nil {
+		t.Error("SaveImages without images succeeded")
+	}
+}
@@ -0,0 +1,55 @@
+package chatgpt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// fromPrompt translates a provider-neutral prompt into a Responses API
+// request. A single user message is sent as-is; a conversation is flattened
+// into a role-prefixed transcript since TextInputRequest.Input is a string.
+func fromPrompt(p *union.Prompt) *cgtypes.TextInputRequest {
+	r := &cgtypes.TextInputRequest{
+		Model:           cgtypes.ChatGPTAIModel(p.Model),
+		Instructions:    p.System,
+		Temperature:     p.Temperature,
+		MaxOutputTokens: openaiwire.MaxTokens(p),
+	}
+
+	if len(p.Messages) == 1 {
+		r.Input = p.Messages[0].Content
+		return r
+	}
+
+	var sb strings.Builder
+	for i, m := range p.Messages {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		role := m.Role
+		if role == "" {
+			role = union.PromptRoleUser
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s", role, m.Content))
+	}
+	r.Input = sb.String()
+
+	return r
+}
+
+// chatFromPrompt translates a provider-neutral prompt into a Chat
+// Completions request. The system prompt becomes a leading system message.
+func chatFromPrompt(p *union.Prompt) *cgtypes.ChatCompletionRequest {
+	return &cgtypes.ChatCompletionRequest{
+		Model:               cgtypes.ChatGPTAIModel(p.Model),
+		Temperature:         p.Temperature,
+		MaxCompletionTokens: openaiwire.MaxTokens(p),
+		Messages: openaiwire.PromptMessages(p, func(role, content string) cgtypes.ChatCompletionMessage {
+			return cgtypes.ChatCompletionMessage{Role: cgtypes.ChatGPTAIRole(role), Content: content}
+		}),
+	}
+}
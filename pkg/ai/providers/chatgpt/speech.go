@@ -0,0 +1,136 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// maxSpeechInput is the longest input of a speech request, in characters.
+const maxSpeechInput = 4096
+
+// Speech generates spoken audio of r.Input with `/v1/audio/speech` and
+// copies it to w as it arrives, so playback or upload can start before
+// the generation ends. Requests without a model use cgtypes.AiModelTTS1
+// and without a voice cgtypes.VoiceAlloy. It returns the metadata of the
+// exchange.
+func (c *Client) Speech(ctx context.Context, r *cgtypes.SpeechRequest, w io.Writer) (*union.Metadata, error) {
+	var meta *union.Metadata
+	_, err := c.wire().Lease(func(token string) (*union.Response, error) {
+		var err error
+		meta, err = c.speech(ctx, r, w, token)
+		return &union.Response{Metadata: meta}, err
+	})
+
+	return meta, err
+}
+
+func (c *Client) speech(ctx context.Context, r *cgtypes.SpeechRequest, w io.Writer, token string) (*union.Metadata, error) {
+	speechRequest, err := speechRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, errors.New("nil writer")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	body, err := speechRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(speechRequest.Model), c.speechEndpoint(), true, &union.Request{Context: ctx}, body)
+	call.Operation = observe.OperationSpeech
+	ctx = observe.Begin(ctx, c.Observer, call)
+	start := time.Now()
+	meta, errBody, err := c.sendAudio(ctx, call, token, body, w)
+	res := observe.NewResult(nil, errBody, err, time.Since(start))
+	if meta != nil {
+		res.Metadata = meta
+	}
+	observe.Finish(ctx, c.Observer, call, res)
+
+	return meta, err
+}
+
+// sendAudio posts the request and copies the audio to w. The body of an
+// error response is returned for observers.
+func (c *Client) sendAudio(ctx context.Context, call *observe.Call, token string, body []byte, w io.Writer) (*union.Metadata, []byte, error) {
+	start := time.Now()
+	resp, err := c.wire().Send(ctx, call, token, "application/json", body)
+	if err != nil {
+		return nil, nil, &union.RequestError{Err: err, Metadata: &union.Metadata{Latency: time.Since(start)}}
+	}
+	defer resp.Body.Close()
+
+	meta := union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return meta, nil, &union.RequestError{Err: fmt.Errorf("read response: %w", err), Metadata: meta}
+		}
+		return meta, respBody, &union.APIError{
+			StatusCode: resp.StatusCode,
+			Body:       respBody,
+			Metadata:   meta,
+		}
+	}
+
+	if _, err = io.Copy(w, resp.Body); err != nil {
+		return meta, nil, &union.RequestError{Err: fmt.Errorf("copy audio: %w", err), Metadata: meta}
+	}
+	return meta, nil, nil
+}
+
+// speechRequest returns the validated speech request of r, with defaults
+// filled in.
+func speechRequest(r *cgtypes.SpeechRequest) (*cgtypes.SpeechRequest, error) {
+	if r == nil {
+		return nil, errors.New("nil request")
+	}
+	req := *r
+	if req.Input == "" {
+		return nil, errors.New("input is required")
+	}
+	if n := utf8.RuneCountInString(req.Input); n > maxSpeechInput {
+		return nil, fmt.Errorf("input is %d characters, above the limit of %d", n, maxSpeechInput)
+	}
+	if req.Model == "" {
+		req.Model = cgtypes.AiModelTTS1
+	}
+	if req.Voice == "" {
+		req.Voice = cgtypes.VoiceAlloy
+	}
+	if req.Speed != nil && (*req.Speed < 0.25 || *req.Speed > 4) {
+		return nil, fmt.Errorf("speed %g is out of range [0.25, 4]", *req.Speed)
+	}
+	if req.Instructions != "" && (req.Model == cgtypes.AiModelTTS1 || req.Model == cgtypes.AiModelTTS1_HD) {
+		return nil, fmt.Errorf("%s does not support instructions: use %s", req.Model, cgtypes.AiModelGpt4oMiniTTS)
+	}
+	switch req.ResponseFormat {
+	case "", cgtypes.AudioFormatMP3, cgtypes.AudioFormatOpus, cgtypes.AudioFormatAAC,
+		cgtypes.AudioFormatFLAC, cgtypes.AudioFormatWAV, cgtypes.AudioFormatPCM:
+	default:
+		return nil, fmt.Errorf("unknown audio format %q", req.ResponseFormat)
+	}
+
+	return &req, nil
+}
+
+// speechEndpoint returns the endpoint speech requests are sent to.
+func (c *Client) speechEndpoint() string {
+	if c.SpeechEndpoint != "" {
+		return c.SpeechEndpoint
+	}
+	return DefaultSpeechEndpoint
+}
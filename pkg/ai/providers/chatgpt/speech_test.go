@@ -0,0 +1,95 @@
+package chatgpt_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/chatgpt"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func TestSpeech(t *testing.T) {
+	audio := bytes.Repeat([]byte("ID3 audio frame "), 1024)
+	var req cgtypes.SpeechRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/speech" {
+			t.Errorf("path = %s, want /v1/audio/speech", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer "+aitest.DefaultAPIKey {
+			t.Errorf("Authorization = %q", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Header().Set("x-request-id", "req_speech")
+		// in chunks, as the audio is generated
+		for i := 0; i < len(audio); i += 4096 {
+			_, _ = w.Write(audio[i:min(i+4096, len(audio))])
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey, SpeechEndpoint: srv.URL + "/v1/audio/speech"}
+	var out bytes.Buffer
+	meta, err := c.Speech(t.Context(), &cgtypes.SpeechRequest{Input: "Hello there"}, &out)
+	if err != nil {
+		t.Fatalf("Speech: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), audio) {
+		t.Errorf("wrote %d bytes, want the %d bytes of audio", out.Len(), len(audio))
+	}
+	if meta == nil || meta.StatusCode != http.StatusOK || meta.RequestID != "req_speech" {
+		t.Errorf("Metadata = %+v", meta)
+	}
+	if req.Model != cgtypes.AiModelTTS1 || req.Voice != cgtypes.VoiceAlloy || req.Input != "Hello there" {
+		t.Errorf("request = %+v, want the default model and voice", req)
+	}
+}
+
+func TestSpeechErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"Invalid voice","type":"invalid_request_error"}}`)
+	}))
+	defer srv.Close()
+
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey, SpeechEndpoint: srv.URL}
+	var out bytes.Buffer
+	_, err := c.Speech(t.Context(), &cgtypes.SpeechRequest{Input: "Hello there", Voice: "robot"}, &out)
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("err = %v, want a 400 *union.APIError", err)
+	}
+	if !bytes.Contains(apiErr.Body, []byte("Invalid voice")) {
+		t.Errorf("Body = %s, want the error response", apiErr.Body)
+	}
+	if out.Len() != 0 {
+		t.Errorf("wrote %q to the audio writer", out.String())
+	}
+}
+
+func TestSpeechInvalidRequest(t *testing.T) {
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey, SpeechEndpoint: "http://127.0.0.1:1"}
+	speed := 5.0
+	for name, r := range map[string]*cgtypes.SpeechRequest{
+		"empty input":  {},
+		"speed":        {Input: "Hello", Speed: &speed},
+		"instructions": {Input: "Hello", Instructions: "Speak calmly"},
+		"format":       {Input: "Hello", ResponseFormat: "ogg"},
+	} {
+		if _, err := c.Speech(t.Context(), r, &bytes.Buffer{}); err == nil {
+			t.Errorf("%s: Speech succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package chatgpt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/sse"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// StreamAI sends a text request with streaming enabled and returns the
+// answer as a stream of text deltas. The last event carries the finish
+// reason and the token usage.
+func (c *Client) StreamAI(opts *union.Request) (union.Stream, error) {
+	if c.Mode == ModeChatCompletions {
+		return c.streamChat(opts)
+	}
+
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	streamRequest := *textRequest
+	streamRequest.Stream = true
+	body, err := streamRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(textRequest.Model), c.Endpoint(), true, opts, body)
+	return c.wire().Stream(opts, call, func(body io.ReadCloser) union.Stream {
+		return &stream{
+			body:   body,
+			events: sse.NewReader(body),
+		}
+	})
+}
+
+type streamEvent struct {
+	Type     string                     `json:"type"`
+	Delta    string                     `json:"delta"`
+	Code     string                     `json:"code"`
+	Message  string                     `json:"message"`
+	Response *cgtypes.TextInputResponse `json:"response"`
+}
+
+type stream struct {
+	body   io.ReadCloser
+	events *sse.Reader
+	done   bool
+}
+
+func (s *stream) Recv() (*union.StreamEvent, error) {
+	for !s.done {
+		ev, err := s.events.Next()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var se streamEvent
+		if err = json.Unmarshal(ev.Data, &se); err != nil {
+			return nil, fmt.Errorf("decode stream event: %w", err)
+		}
+
+		switch se.Type {
+		case "response.output_text.delta":
+			return &union.StreamEvent{Delta: se.Delta, Raw: ev.Data}, nil
+		case "response.completed", "response.incomplete":
+			s.done = true
+			out := &union.StreamEvent{Raw: ev.Data}
+			if se.Response != nil {
+				out.FinishReason = se.Response.Status
+				out.Usage = usage(se.Response.Usage)
+			}
+			return out, nil
+		case "response.failed":
+			s.done = true
+			if se.Response != nil && se.Response.Error != nil {
+				return nil, fmt.Errorf("stream failed: %v", se.Response.Error)
+			}
+			return nil, fmt.Errorf("stream failed")
+		case "error":
+			s.done = true
+			return nil, fmt.Errorf("stream error %s: %s", se.Code, se.Message)
+		}
+	}
+
+	return nil, io.EOF
+}
+
+func (s *stream) Close() error {
+	s.done = true
+	return s.body.Close()
+}
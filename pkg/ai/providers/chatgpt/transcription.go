@@ -0,0 +1,30 @@
+package chatgpt
+
+import (
+	"context"
+
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+)
+
+// Transcribe transcribes audio with `/v1/audio/transcriptions`, uploading
+// r.File as a multipart form. Requests without a model use
+// cgtypes.AiModelWhisper1. Timestamp granularities switch an unset format
+// to verbose_json.
+func (c *Client) Transcribe(ctx context.Context, r *cgtypes.TranscriptionRequest) (*cgtypes.Transcription, error) {
+	endpoint := c.TranscriptionsEndpoint
+	if endpoint == "" {
+		endpoint = DefaultTranscriptionsEndpoint
+	}
+	return c.wire().Transcribe(ctx, r, endpoint, cgtypes.AiModelWhisper1)
+}
+
+// Translate transcribes audio into English with `/v1/audio/translations`,
+// uploading r.File as a multipart form. Requests without a model use
+// cgtypes.AiModelWhisper1, the only model supporting translations.
+func (c *Client) Translate(ctx context.Context, r *cgtypes.TranslationRequest) (*cgtypes.Transcription, error) {
+	endpoint := c.TranslationsEndpoint
+	if endpoint == "" {
+		endpoint = DefaultTranslationsEndpoint
+	}
+	return c.wire().Translate(ctx, r, endpoint, cgtypes.AiModelWhisper1)
+}
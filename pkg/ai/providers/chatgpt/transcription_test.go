@@ -0,0 +1,167 @@
+package chatgpt_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/chatgpt"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+)
+
+const srt = "1\n00:00:00,000 --> 00:00:01,500\nHello there.\n"
+
+const verboseJSON = `{"task":"transcribe","language":"english","duration":1.5,"text":"Hello there.",
+	"segments":[{"id":0,"seek":0,"start":0,"end":1.5,"text":"Hello there.","tokens":[50364,2425],"temperature":0,
+		"avg_logprob":-0.2,"compression_ratio":0.8,"no_speech_prob":0.01}],
+	"words":[{"word":"Hello","start":0,"end":0.6},{"word":"there","start":0.7,"end":1.4}],
+	"usage":{"type":"duration","seconds":2}}`
+
+// upload is a transcription form received by the test server.
+type upload struct {
+	Fields   map[string][]string
+	FileName string
+	File     string
+}
+
+// newTranscriptionServer starts a server answering each form with the
+// response for its response_format, and returns the forms it receives.
+func newTranscriptionServer(t *testing.T) (*httptest.Server, *[]upload) {
+	t.Helper()
+	var uploads []upload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			t.Errorf("path = %s, want /v1/audio/transcriptions", r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse form: %v", err)
+			return
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("form file: %v", err)
+			return
+		}
+		b, _ := io.ReadAll(f)
+		f.Close()
+		uploads = append(uploads, upload{Fields: r.MultipartForm.Value, FileName: h.Filename, File: string(b)})
+
+		switch cgtypes.TranscriptFormat(r.FormValue("response_format")) {
+		case cgtypes.TranscriptFormatText:
+			fmt.Fprint(w, "Hello there.\n")
+		case cgtypes.TranscriptFormatSRT, cgtypes.TranscriptFormatVTT:
+			fmt.Fprint(w, srt)
+		case cgtypes.TranscriptFormatVerboseJSON:
+			fmt.Fprint(w, verboseJSON)
+		default:
+			fmt.Fprint(w, `{"text":"Hello there."}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &uploads
+}
+
+func transcriptionRequest(format cgtypes.TranscriptFormat) *cgtypes.TranscriptionRequest {
+	return &cgtypes.TranscriptionRequest{
+		File:           strings.NewReader("RIFF audio"),
+		FileName:       "hello.wav",
+		ResponseFormat: format,
+	}
+}
+
+func TestTranscribe(t *testing.T) {
+	srv, uploads := newTranscriptionServer(t)
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey, TranscriptionsEndpoint: srv.URL + "/v1/audio/transcriptions"}
+
+	temperature := 0.2
+	r := transcriptionRequest("")
+	r.Language = "en"
+	r.Prompt = "A greeting."
+	r.Temperature = &temperature
+	tr, err := c.Transcribe(t.Context(), r)
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	if tr.Text != "Hello there." {
+		t.Errorf("Text = %q", tr.Text)
+	}
+
+	u := (*uploads)[0]
+	if u.FileName != "hello.wav" || u.File != "RIFF audio" {
+		t.Errorf("file = %q %q, want the audio", u.FileName, u.File)
+	}
+	for key, want := range map[string]string{
+		"model":       string(cgtypes.AiModelWhisper1),
+		"language":    "en",
+		"prompt":      "A greeting.",
+		"temperature": "0.2",
+	} {
+		if got := fmt.Sprint(u.Fields[key]); got != "["+want+"]" {
+			t.Errorf("%s = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestTranscribeRawFormats(t *testing.T) {
+	srv, _ := newTranscriptionServer(t)
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey, TranscriptionsEndpoint: srv.URL + "/v1/audio/transcriptions"}
+
+	for format, want := range map[cgtypes.TranscriptFormat]string{
+		cgtypes.TranscriptFormatText: "Hello there.\n",
+		cgtypes.TranscriptFormatSRT:  srt,
+		cgtypes.TranscriptFormatVTT:  srt,
+	} {
+		tr, err := c.Transcribe(t.Context(), transcriptionRequest(format))
+		if err != nil {
+			t.Fatalf("%s: Transcribe: %v", format, err)
+		}
+		if tr.Text != want || tr.Segments != nil {
+			t.Errorf("%s: transcription = %+v, want the raw response as Text", format, tr)
+		}
+	}
+}
+
+func TestTranscribeVerboseJSON(t *testing.T) {
+	srv, uploads := newTranscriptionServer(t)
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey, TranscriptionsEndpoint: srv.URL + "/v1/audio/transcriptions"}
+
+	// granularities switch the unset format to verbose_json
+	r := transcriptionRequest("")
+	r.TimestampGranularities = []string{cgtypes.GranularityWord, cgtypes.GranularitySegment}
+	tr, err := c.Transcribe(t.Context(), r)
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+
+	u := (*uploads)[0]
+	if got := fmt.Sprint(u.Fields["response_format"]); got != "[verbose_json]" {
+		t.Errorf("response_format = %s, want verbose_json", got)
+	}
+	if got := fmt.Sprint(u.Fields["timestamp_granularities[]"]); got != "[word segment]" {
+		t.Errorf("timestamp_granularities[] = %s", got)
+	}
+
+	if tr.Text != "Hello there." || tr.Language != "english" || tr.Duration != 1.5 {
+		t.Errorf("transcription = %+v", tr)
+	}
+	if len(tr.Segments) != 1 || tr.Segments[0].End != 1.5 || tr.Segments[0].NoSpeechProb != 0.01 {
+		t.Errorf("Segments = %+v", tr.Segments)
+	}
+	if len(tr.Words) != 2 || tr.Words[1].Word != "there" || tr.Words[1].Start != 0.7 {
+		t.Errorf("Words = %+v", tr.Words)
+	}
+	if tr.Usage == nil || tr.Usage.Type != "duration" || tr.Usage.Seconds != 2 {
+		t.Errorf("Usage = %+v", tr.Usage)
+	}
+
+	// granularities need verbose_json
+	r = transcriptionRequest(cgtypes.TranscriptFormatText)
+	r.TimestampGranularities = []string{cgtypes.GranularityWord}
+	if _, err := c.Transcribe(t.Context(), r); err == nil {
+		t.Error("Transcribe with granularities and the text format succeeded")
+	}
+}
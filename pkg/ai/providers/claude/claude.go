@@ -2,35 +2,174 @@ package claude
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/muraduiurie/gpt/pkg/ai/cache"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
 	cltypes "github.com/muraduiurie/gpt/pkg/ai/types/claude"
 	"github.com/muraduiurie/gpt/pkg/ai/types/union"
 )
 
+func init() {
+	cache.RegisterResponse(func() union.Responser { return &cltypes.TextInputResponse{} })
+}
+
+const (
+	// ProviderName identifies this provider in responses and routing attempts.
+	ProviderName = "claude"
+	// DefaultTextInputEndpoint is used when TextInputEndpoint is empty.
+	DefaultTextInputEndpoint = "https://api.anthropic.com/v1/messages"
+)
+
 type Client struct {
-	ApiToken          string
+	ApiToken string
+	// TextInputEndpoint is the Messages API endpoint or, on Bedrock and
+	// Vertex, the base URL that replaces the regional default, e.g. for
+	// a proxy.
 	TextInputEndpoint string
+	// Platform defaults to PlatformAnthropic.
+	Platform Platform
+	// Bedrock configures PlatformBedrock.
+	Bedrock Bedrock
+	// Vertex configures PlatformVertex.
+	Vertex Vertex
+	// Keys, when set, supplies the API token of each request instead of
+	// ApiToken.
+	Keys *keypool.Pool
+	// HTTPClient is used to send requests. Defaults to a client with a
+	// 300 second timeout.
+	HTTPClient *http.Client
+	// Observer is notified around every request, e.g. for logging.
+	Observer observe.Observer
 }
 
-func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
+// Provider returns the provider name, ProviderName.
+func (c *Client) Provider() string {
+	return ProviderName
+}
+
+// Endpoint returns the text input endpoint requests are sent to. On
+// Bedrock and Vertex, it is the base URL the model and method are appended
+// to.
+func (c *Client) Endpoint() string {
 	if c.TextInputEndpoint == "" {
-		c.TextInputEndpoint = "https://api.openai.com/v1/responses"
+		return c.platformEndpoint()
+	}
+	return strings.TrimSuffix(c.TextInputEndpoint, "/")
+}
+
+// DefaultModel returns the model requests without one are sent to.
+func (c *Client) DefaultModel(opts *union.Request) string {
+	return string(cltypes.ClaudeAIModelSonnet4_20250514)
+}
+
+// AskAI sends a text request to the configured Claude endpoint and returns
+// the parsed response. It validates inputs, performs the HTTP POST request,
+// and unmarshals the response body. An error is returned for invalid input,
+// network issues, or unexpected HTTP status codes. Keys only apply to
+// PlatformAnthropic.
+func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
+	if c.Keys == nil || c.platform() != PlatformAnthropic {
+		return c.askAI(opts, c.ApiToken)
+	}
+
+	lease, err := c.Keys.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.askAI(opts, lease.Token())
+	lease.Release(resp, err)
+
+	return resp, err
+}
+
+func (c *Client) askAI(opts *union.Request, token string) (*union.Response, error) {
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.body(textRequest, false)
+	if err != nil {
+		return nil, err
+	}
+
+	call := observe.NewCall(ProviderName, string(textRequest.Model), c.requestURL(textRequest.Model, false), false, opts, body)
+	ctx := observe.Begin(requestContext(opts), c.Observer, call)
+	start := time.Now()
+	resp, respBody, err := c.do(ctx, call, token, body)
+	observe.Finish(ctx, c.Observer, call, observe.NewResult(resp, respBody, err, time.Since(start)))
+
+	return resp, err
+}
+
+// do sends the request and decodes the response. The raw response body is
+// returned for observers.
+func (c *Client) do(ctx context.Context, call *observe.Call, token string, body []byte) (*union.Response, []byte, error) {
+	start := time.Now()
+	resp, err := c.send(ctx, call, token, body)
+	if err != nil {
+		return nil, nil, &union.RequestError{Err: err, Metadata: &union.Metadata{Latency: time.Since(start)}}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	meta := union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start))
+	if err != nil {
+		return nil, nil, &union.RequestError{Err: fmt.Errorf("read response: %w", err), Metadata: meta}
 	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, respBody, &union.APIError{
+			StatusCode: resp.StatusCode,
+			Body:       respBody,
+			Metadata:   meta,
+		}
+	}
+
+	var textResponse cltypes.TextInputResponse
+	err = textResponse.Unmarshal(respBody)
+	if err != nil {
+		return nil, respBody, &union.RequestError{Err: fmt.Errorf("failed to unmarshal response: %w", err), Metadata: meta}
+	}
+
+	return &union.Response{
+		TextResponse: &textResponse,
+		Metadata:     meta,
+		Provider:     ProviderName,
+		Output:       output(&textResponse),
+		FinishReason: textResponse.StopReason,
+		Usage:        usage(textResponse.Usage),
+	}, respBody, nil
+}
+
+// textRequest returns the validated Claude request of opts, with defaults
+// filled in.
+func (c *Client) textRequest(opts *union.Request) (*cltypes.TextInputRequest, error) {
 	if opts == nil {
 		return nil, errors.New("nil opts")
 	}
-	textRequest, ok := opts.TextRequest.(*cltypes.TextInputRequest)
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	requester := opts.TextRequest
+	if requester == nil && opts.Prompt != nil {
+		requester = fromPrompt(opts.Prompt)
+	}
+	textRequest, ok := requester.(*cltypes.TextInputRequest)
 	if !ok {
 		return nil, fmt.Errorf("*cltypes.TextInputRequest type conversion failed")
 	}
 
 	if textRequest.Model == "" {
-		textRequest.Model = cltypes.ClaudeAIModelSonnet4_20250514
+		textRequest.Model = cltypes.ClaudeAIModel(c.DefaultModel(opts))
 	}
 	if textRequest.MaxTokens == 0 {
 		textRequest.MaxTokens = 100
@@ -47,42 +186,75 @@ func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
 		}
 	}
 
-	body, err := opts.TextRequest.Marshal()
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
+	return textRequest, nil
+}
 
-	req, err := http.NewRequest(http.MethodPost, c.TextInputEndpoint, bytes.NewReader(body))
+// send posts body to the URL of the call, authenticated for the platform.
+// The extra headers of the call are applied last; Bedrock requests are
+// signed after them.
+func (c *Client) send(ctx context.Context, call *observe.Call, token string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, call.Endpoint, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", c.ApiToken)
-	req.Header.Set("anthropic-version", "2023-06-01")
+	switch c.platform() {
+	case PlatformBedrock:
+		if call.Stream {
+			req.Header.Set("Accept", "application/vnd.amazon.eventstream")
+		} else {
+			req.Header.Set("Accept", "application/json")
+		}
+	case PlatformVertex:
+		bearer, err := c.Vertex.TokenSource.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	default:
+		req.Header.Set("x-api-key", token)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	}
+	for k, vs := range call.Header {
+		req.Header[k] = vs
+	}
+	if c.platform() == PlatformBedrock {
+		signV4(req, body, c.Bedrock.Credentials, c.Bedrock.Region, "bedrock", time.Now())
+	}
 
-	httpClient := &http.Client{Timeout: 300 * time.Second}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 300 * time.Second}
+	}
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("do request: %w", err)
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
-	}
+	return resp, nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+func requestContext(opts *union.Request) context.Context {
+	if opts.Context == nil {
+		return context.Background()
 	}
+	return opts.Context
+}
 
-	var textResponse cltypes.TextInputResponse
-	err = textResponse.Unmarshal(respBody)
-	if err != nil {
-		return nil, err
+func usage(u cltypes.TextInputResponseUsage) *union.Usage {
+	return &union.Usage{
+		InputTokens:       u.InputTokens,
+		OutputTokens:      u.OutputTokens,
+		TotalTokens:       u.InputTokens + u.OutputTokens,
+		CachedInputTokens: u.CacheReadInputTokens,
 	}
+}
 
-	return &union.Response{
-		TextResponse: &textResponse,
-	}, nil
+// output concatenates the text content blocks of the message.
+func output(r *cltypes.TextInputResponse) string {
+	var sb strings.Builder
+	for _, c := range r.Content {
+		sb.WriteString(c.Text)
+	}
+	return sb.String()
 }
@@ -0,0 +1,160 @@
+package claude
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/oauth"
+)
+
+const (
+	// DefaultGoogleScope is the scope of the tokens requested for Vertex AI.
+	DefaultGoogleScope = "https://www.googleapis.com/auth/cloud-platform"
+	// DefaultGoogleTokenURL is the Google OAuth 2.0 token endpoint.
+	DefaultGoogleTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+// TokenSource supplies the OAuth 2.0 bearer tokens of Vertex AI requests.
+type TokenSource = oauth.TokenSource
+
+// StaticToken is a TokenSource returning a fixed token, e.g. one obtained
+// with `gcloud auth print-access-token`.
+type StaticToken = oauth.StaticToken
+
+// GoogleCredentials obtains tokens from a Google credentials file: a
+// service account key (JWT bearer grant) or the `authorized_user` file
+// written by `gcloud auth application-default login` (refresh token grant).
+// Tokens are cached until shortly before they expire.
+type GoogleCredentials struct {
+	// Type is "service_account" or "authorized_user".
+	Type string `json:"type"`
+	// service account
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	// authorized user
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	// TokenURL defaults to DefaultGoogleTokenURL.
+	TokenURL string `json:"token_uri"`
+
+	// Scope defaults to DefaultGoogleScope.
+	Scope string `json:"-"`
+	// HTTPClient defaults to a client with a 30 second timeout.
+	HTTPClient *http.Client `json:"-"`
+
+	cache oauth.Cache
+}
+
+// GoogleCredentialsFromFile reads a credentials file, e.g. the one named by
+// GOOGLE_APPLICATION_CREDENTIALS.
+func GoogleCredentialsFromFile(path string) (*GoogleCredentials, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read credentials file: %w", err)
+	}
+	var c GoogleCredentials
+	if err = json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal credentials file: %w", err)
+	}
+	switch c.Type {
+	case "service_account", "authorized_user":
+	default:
+		return nil, fmt.Errorf("unsupported credentials type %q", c.Type)
+	}
+	return &c, nil
+}
+
+// Token returns the cached token, or requests a new one.
+func (c *GoogleCredentials) Token(ctx context.Context) (string, error) {
+	return c.cache.Token(ctx, c.requestToken)
+}
+
+func (c *GoogleCredentials) requestToken(ctx context.Context) (string, time.Duration, error) {
+	tokenURL := c.TokenURL
+	if tokenURL == "" {
+		tokenURL = DefaultGoogleTokenURL
+	}
+	var form url.Values
+	switch c.Type {
+	case "service_account":
+		assertion, err := c.assertion(tokenURL, time.Now())
+		if err != nil {
+			return "", 0, err
+		}
+		form = url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		}
+	case "authorized_user":
+		form = url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {c.ClientID},
+			"client_secret": {c.ClientSecret},
+			"refresh_token": {c.RefreshToken},
+		}
+	default:
+		return "", 0, fmt.Errorf("unsupported credentials type %q", c.Type)
+	}
+
+	return oauth.RequestToken(ctx, c.HTTPClient, tokenURL, form)
+}
+
+// assertion returns the signed JWT a service account exchanges for a token.
+func (c *GoogleCredentials) assertion(audience string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(c.PrivateKey))
+	if block == nil {
+		return "", errors.New("invalid private key: no PEM block")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("parse private key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("invalid private key: not an RSA key")
+	}
+
+	scope := c.Scope
+	if scope == "" {
+		scope = DefaultGoogleScope
+	}
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": c.PrivateKeyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   c.ClientEmail,
+		"scope": scope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("sign assertion: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,188 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	cltypes "github.com/muraduiurie/gpt/pkg/ai/types/claude"
+)
+
+// Platform selects the API Claude is reached through.
+type Platform string
+
+const (
+	// PlatformAnthropic is the Anthropic Messages API, authenticated with
+	// the `x-api-key` header.
+	PlatformAnthropic Platform = "anthropic"
+	// PlatformBedrock is AWS Bedrock: InvokeModel and
+	// InvokeModelWithResponseStream, signed with SigV4.
+	PlatformBedrock Platform = "bedrock"
+	// PlatformVertex is Google Vertex AI: rawPredict and streamRawPredict,
+	// authenticated with an OAuth 2.0 bearer token.
+	PlatformVertex Platform = "vertex"
+)
+
+const (
+	// BedrockAnthropicVersion is the `anthropic_version` of Bedrock requests.
+	BedrockAnthropicVersion = "bedrock-2023-05-31"
+	// VertexAnthropicVersion is the `anthropic_version` of Vertex requests.
+	VertexAnthropicVersion = "vertex-2023-10-16"
+)
+
+// Bedrock configures PlatformBedrock.
+type Bedrock struct {
+	// Region is the AWS region, e.g. "us-east-1".
+	Region      string
+	Credentials AWSCredentials
+	// Models maps Anthropic model names to Bedrock model IDs or inference
+	// profiles, e.g. "us.anthropic.claude-sonnet-4-20250514-v1:0". Known
+	// models are mapped to their Bedrock model IDs by default; other models
+	// are used as-is.
+	Models map[string]string
+}
+
+// Vertex configures PlatformVertex.
+type Vertex struct {
+	ProjectID string
+	// Region is the Vertex AI location, e.g. "us-east5" or "global".
+	Region string
+	// TokenSource supplies the bearer tokens, e.g. a StaticToken or
+	// GoogleCredentials.
+	TokenSource TokenSource
+	// Models maps Anthropic model names to Vertex model IDs. Known models
+	// are mapped to their Vertex model IDs by default; other models are used
+	// as-is.
+	Models map[string]string
+}
+
+var (
+	bedrockModels = map[cltypes.ClaudeAIModel]string{
+		cltypes.ClaudeAIModelSonnet4_20250514: "anthropic.claude-sonnet-4-20250514-v1:0",
+	}
+	vertexModels = map[cltypes.ClaudeAIModel]string{
+		cltypes.ClaudeAIModelSonnet4_20250514: "claude-sonnet-4@20250514",
+	}
+)
+
+// modelID returns the platform model ID of model.
+func modelID(model cltypes.ClaudeAIModel, models map[string]string, defaults map[cltypes.ClaudeAIModel]string) string {
+	if id, ok := models[string(model)]; ok {
+		return id
+	}
+	if id, ok := defaults[model]; ok {
+		return id
+	}
+	return string(model)
+}
+
+func (c *Client) platform() Platform {
+	if c.Platform == "" {
+		return PlatformAnthropic
+	}
+	return c.Platform
+}
+
+// requestURL returns the URL a request for model is sent to.
+func (c *Client) requestURL(model cltypes.ClaudeAIModel, stream bool) string {
+	switch c.platform() {
+	case PlatformBedrock:
+		method := "invoke"
+		if stream {
+			method = "invoke-with-response-stream"
+		}
+		id := modelID(model, c.Bedrock.Models, bedrockModels)
+		return fmt.Sprintf("%s/model/%s/%s", c.Endpoint(), awsEscape(id), method)
+	case PlatformVertex:
+		method := "rawPredict"
+		if stream {
+			method = "streamRawPredict"
+		}
+		id := modelID(model, c.Vertex.Models, vertexModels)
+		return fmt.Sprintf("%s/projects/%s/locations/%s/publishers/anthropic/models/%s:%s",
+			c.Endpoint(), c.Vertex.ProjectID, c.Vertex.Region, id, method)
+	default:
+		return c.Endpoint()
+	}
+}
+
+// platformEndpoint returns the default base URL of Bedrock and Vertex.
+func (c *Client) platformEndpoint() string {
+	switch c.platform() {
+	case PlatformBedrock:
+		return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", c.Bedrock.Region)
+	case PlatformVertex:
+		if c.Vertex.Region == "global" {
+			return "https://aiplatform.googleapis.com/v1"
+		}
+		return fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1", c.Vertex.Region)
+	default:
+		return DefaultTextInputEndpoint
+	}
+}
+
+// platformRequest is the body Bedrock and Vertex expect: a Messages API
+// request without the model, which goes in the URL, and with the
+// `anthropic_version` of the platform. Its Model and Stream fields hide
+// those of the embedded request.
+type platformRequest struct {
+	*cltypes.TextInputRequest
+	Model            string `json:"model,omitempty"`
+	Stream           bool   `json:"stream,omitempty"`
+	AnthropicVersion string `json:"anthropic_version"`
+}
+
+// body marshals the request for the platform. Bedrock streams depending on
+// the URL, so only Vertex bodies carry the stream flag.
+func (c *Client) body(r *cltypes.TextInputRequest, stream bool) ([]byte, error) {
+	var (
+		b   []byte
+		err error
+	)
+	switch c.platform() {
+	case PlatformBedrock:
+		b, err = json.Marshal(platformRequest{TextInputRequest: r, AnthropicVersion: BedrockAnthropicVersion})
+	case PlatformVertex:
+		b, err = json.Marshal(platformRequest{TextInputRequest: r, Stream: stream, AnthropicVersion: VertexAnthropicVersion})
+	default:
+		wire := *r
+		wire.Stream = stream
+		b, err = wire.Marshal()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	return b, nil
+}
+
+// validate reports missing platform settings.
+func (c *Client) validate() error {
+	var missing []string
+	switch c.platform() {
+	case PlatformAnthropic:
+		return nil
+	case PlatformBedrock:
+		if c.Bedrock.Region == "" {
+			missing = append(missing, "region")
+		}
+		if c.Bedrock.Credentials.AccessKeyID == "" || c.Bedrock.Credentials.SecretAccessKey == "" {
+			missing = append(missing, "credentials")
+		}
+	case PlatformVertex:
+		if c.Vertex.ProjectID == "" {
+			missing = append(missing, "project ID")
+		}
+		if c.Vertex.Region == "" {
+			missing = append(missing, "region")
+		}
+		if c.Vertex.TokenSource == nil {
+			missing = append(missing, "token source")
+		}
+	default:
+		return fmt.Errorf("unknown platform %q", c.Platform)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s requires %s", c.Platform, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package claude_test
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/claude"
+)
+
+func bedrockClient(srv *aitest.Server) *claude.Client {
+	return &claude.Client{
+		Platform:          claude.PlatformBedrock,
+		TextInputEndpoint: srv.Endpoint(),
+		Bedrock: claude.Bedrock{
+			Region: "us-east-1",
+			Credentials: claude.AWSCredentials{
+				AccessKeyID:     aitest.DefaultAPIKey,
+				SecretAccessKey: "secret",
+				SessionToken:    "session",
+			},
+		},
+	}
+}
+
+func vertexClient(srv *aitest.Server) *claude.Client {
+	return &claude.Client{
+		Platform:          claude.PlatformVertex,
+		TextInputEndpoint: srv.Endpoint(),
+		Vertex: claude.Vertex{
+			ProjectID:   "my-project",
+			Region:      "us-east5",
+			TokenSource: claude.StaticToken(aitest.DefaultAPIKey),
+		},
+	}
+}
+
+func drain(t *testing.T, c *claude.Client, input string) string {
+	t.Helper()
+	s, err := c.StreamAI(request(input))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	defer s.Close()
+
+	var text strings.Builder
+	for {
+		ev, err := s.Recv()
+		if err == io.EOF {
+			return text.String()
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		text.WriteString(ev.Delta)
+	}
+}
+
+// platformBody decodes a request body sent to Bedrock or Vertex.
+func platformBody(t *testing.T, body []byte) map[string]json.RawMessage {
+	t.Helper()
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(body, &keys); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	if _, ok := keys["model"]; ok {
+		t.Errorf("body %s carries the model", body)
+	}
+	return keys
+}
+
+func TestBedrockAskAI(t *testing.T) {
+	srv := aitest.NewBedrockServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello from Bedrock" }
+
+	resp, err := bedrockClient(srv).AskAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "Hello from Bedrock" {
+		t.Errorf("Output = %q", resp.Output)
+	}
+
+	req := srv.Requests()[0]
+	if want := "/model/anthropic.claude-sonnet-4-20250514-v1:0/invoke"; req.Path != want {
+		t.Errorf("path = %s, want %s", req.Path, want)
+	}
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential="+aitest.DefaultAPIKey+"/") ||
+		!strings.Contains(auth, "/us-east-1/bedrock/aws4_request") ||
+		!strings.Contains(auth, "x-amz-security-token") {
+		t.Errorf("Authorization = %s", auth)
+	}
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "session" {
+		t.Errorf("X-Amz-Security-Token = %q, want session", got)
+	}
+	keys := platformBody(t, req.Body)
+	if got := string(keys["anthropic_version"]); got != `"`+claude.BedrockAnthropicVersion+`"` {
+		t.Errorf("anthropic_version = %s", got)
+	}
+}
+
+func TestBedrockStreamAI(t *testing.T) {
+	srv := aitest.NewBedrockServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello there, streamed from Bedrock" }
+
+	if got := drain(t, bedrockClient(srv), "Say hello"); got != "Hello there, streamed from Bedrock" {
+		t.Errorf("streamed %q", got)
+	}
+
+	req := srv.Requests()[0]
+	if want := "/model/anthropic.claude-sonnet-4-20250514-v1:0/invoke-with-response-stream"; req.Path != want {
+		t.Errorf("path = %s, want %s", req.Path, want)
+	}
+	if _, ok := platformBody(t, req.Body)["stream"]; ok {
+		t.Errorf("body %s carries the stream flag", req.Body)
+	}
+}
+
+func TestVertexAskAI(t *testing.T) {
+	srv := aitest.NewVertexServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello from Vertex" }
+
+	resp, err := vertexClient(srv).AskAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "Hello from Vertex" {
+		t.Errorf("Output = %q", resp.Output)
+	}
+
+	req := srv.Requests()[0]
+	if want := "/v1/projects/my-project/locations/us-east5/publishers/anthropic/models/claude-sonnet-4@20250514:rawPredict"; req.Path != want {
+		t.Errorf("path = %s, want %s", req.Path, want)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer "+aitest.DefaultAPIKey {
+		t.Errorf("Authorization = %q", got)
+	}
+	keys := platformBody(t, req.Body)
+	if got := string(keys["anthropic_version"]); got != `"`+claude.VertexAnthropicVersion+`"` {
+		t.Errorf("anthropic_version = %s", got)
+	}
+	if _, ok := keys["stream"]; ok {
+		t.Errorf("body %s carries the stream flag", req.Body)
+	}
+}
+
+func TestVertexStreamAI(t *testing.T) {
+	srv := aitest.NewVertexServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello there, streamed from Vertex" }
+
+	if got := drain(t, vertexClient(srv), "Say hello"); got != "Hello there, streamed from Vertex" {
+		t.Errorf("streamed %q", got)
+	}
+
+	req := srv.Requests()[0]
+	if want := "/v1/projects/my-project/locations/us-east5/publishers/anthropic/models/claude-sonnet-4@20250514:streamRawPredict"; req.Path != want {
+		t.Errorf("path = %s, want %s", req.Path, want)
+	}
+	keys := platformBody(t, req.Body)
+	if got := string(keys["stream"]); got != "true" {
+		t.Errorf("stream = %s, want true", got)
+	}
+	if got := string(keys["anthropic_version"]); got != `"`+claude.VertexAnthropicVersion+`"` {
+		t.Errorf("anthropic_version = %s", got)
+	}
+}
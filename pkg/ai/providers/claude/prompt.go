@@ -0,0 +1,24 @@
+package claude
+
+import (
+	cltypes "github.com/muraduiurie/gpt/pkg/ai/types/claude"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// fromPrompt translates a provider-neutral prompt into a Messages API request.
+func fromPrompt(p *union.Prompt) *cltypes.TextInputRequest {
+	r := &cltypes.TextInputRequest{
+		Model:       cltypes.ClaudeAIModel(p.Model),
+		MaxTokens:   p.MaxTokens,
+		System:      p.System,
+		Temperature: p.Temperature,
+	}
+	for _, m := range p.Messages {
+		r.Messages = append(r.Messages, cltypes.TextInputRequestMessage{
+			Role:    cltypes.ClaudeAIRole(m.Role),
+			Content: m.Content,
+		})
+	}
+
+	return r
+}
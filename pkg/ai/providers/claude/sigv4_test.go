@@ -0,0 +1,50 @@
+package claude
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The vectors come from the AWS Signature Version 4 test suite.
+var testCreds = AWSCredentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+var testTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+func TestSignV4(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		want        string
+	}{{
+		name:   "get-vanilla",
+		method: http.MethodGet,
+		want:   "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+	}, {
+		name:        "post-x-www-form-urlencoded",
+		method:      http.MethodPost,
+		contentType: "application/x-www-form-urlencoded",
+		body:        "Param1=value1",
+		want:        "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "https://example.amazonaws.com/", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			signV4(req, []byte(tc.body), testCreds, "us-east-1", "service", testTime)
+			if got := req.Header.Get("Authorization"); got != tc.want {
+				t.Errorf("Authorization = %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/eventstream"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/sse"
+	cltypes "github.com/muraduiurie/gpt/pkg/ai/types/claude"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// StreamAI sends a text request with streaming enabled and returns the
+// answer as a stream of text deltas. The event that ends the message
+// carries the stop reason and the token usage.
+func (c *Client) StreamAI(opts *union.Request) (union.Stream, error) {
+	if c.Keys == nil || c.platform() != PlatformAnthropic {
+		s, _, err := c.streamAI(opts, c.ApiToken)
+		return s, err
+	}
+
+	lease, err := c.Keys.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	s, meta, err := c.streamAI(opts, lease.Token())
+	if err != nil {
+		lease.Release(nil, err)
+		return nil, err
+	}
+
+	return lease.Stream(s, meta), nil
+}
+
+func (c *Client) streamAI(opts *union.Request, token string) (union.Stream, *union.Metadata, error) {
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := c.body(textRequest, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	call := observe.NewCall(ProviderName, string(textRequest.Model), c.requestURL(textRequest.Model, true), true, opts, body)
+	ctx := observe.Begin(requestContext(opts), c.Observer, call)
+	start := time.Now()
+	resp, err := c.send(ctx, call, token, body)
+	if err != nil {
+		err = &union.RequestError{Err: err, Metadata: &union.Metadata{Latency: time.Since(start)}}
+		observe.Finish(ctx, c.Observer, call, observe.NewResult(nil, nil, err, time.Since(start)))
+		return nil, nil, err
+	}
+
+	meta := union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			err = &union.RequestError{Err: fmt.Errorf("read response: %w", err), Metadata: meta}
+		} else {
+			err = &union.APIError{
+				StatusCode: resp.StatusCode,
+				Body:       respBody,
+				Metadata:   meta,
+			}
+		}
+		observe.Finish(ctx, c.Observer, call, observe.NewResult(nil, respBody, err, time.Since(start)))
+		return nil, nil, err
+	}
+
+	s := &stream{body: resp.Body}
+	if c.platform() == PlatformBedrock {
+		s.next = bedrockEvents(eventstream.NewReader(resp.Body))
+	} else {
+		s.next = sseEvents(sse.NewReader(resp.Body))
+	}
+	return observe.Stream(ctx, c.Observer, call, start, meta, s), meta, nil
+}
+
+type streamEvent struct {
+	Type    string                          `json:"type"`
+	Message *cltypes.TextInputResponse      `json:"message"`
+	Usage   *cltypes.TextInputResponseUsage `json:"usage"`
+	Delta   struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// sseEvents returns the data of the server-sent events of the Anthropic
+// API and Vertex.
+func sseEvents(r *sse.Reader) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		ev, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		return ev.Data, nil
+	}
+}
+
+// bedrockEvents returns the Messages API events of a Bedrock response
+// stream: each `chunk` event carries one, base64 encoded. Exceptions end
+// the stream with an error.
+func bedrockEvents(r *eventstream.Reader) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		for {
+			msg, err := r.Next()
+			if err != nil {
+				return nil, err
+			}
+			switch msg.MessageType() {
+			case "exception":
+				var e struct {
+					Message string `json:"message"`
+				}
+				json.Unmarshal(msg.Payload, &e)
+				return nil, fmt.Errorf("stream error %s: %s", msg.Headers[":exception-type"], e.Message)
+			case "error":
+				return nil, fmt.Errorf("stream error %s: %s", msg.Headers[":error-code"], msg.Headers[":error-message"])
+			}
+			if msg.EventType() != "chunk" {
+				continue
+			}
+
+			var chunk struct {
+				Bytes []byte `json:"bytes"`
+			}
+			if err = json.Unmarshal(msg.Payload, &chunk); err != nil {
+				return nil, fmt.Errorf("decode stream chunk: %w", err)
+			}
+			return chunk.Bytes, nil
+		}
+	}
+}
+
+type stream struct {
+	body io.ReadCloser
+	// next returns the data of the next event.
+	next  func() ([]byte, error)
+	usage cltypes.TextInputResponseUsage
+	done  bool
+}
+
+func (s *stream) Recv() (*union.StreamEvent, error) {
+	for !s.done {
+		data, err := s.next()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var se streamEvent
+		if err = json.Unmarshal(data, &se); err != nil {
+			return nil, fmt.Errorf("decode stream event: %w", err)
+		}
+
+		switch se.Type {
+		case "message_start":
+			if se.Message != nil {
+				s.usage = se.Message.Usage
+			}
+		case "content_block_delta":
+			if se.Delta.Type == "text_delta" {
+				return &union.StreamEvent{Delta: se.Delta.Text, Raw: data}, nil
+			}
+		case "message_delta":
+			if se.Usage != nil {
+				s.usage.OutputTokens = se.Usage.OutputTokens
+			}
+			return &union.StreamEvent{
+				FinishReason: se.Delta.StopReason,
+				Usage:        usage(s.usage),
+				Raw:          data,
+			}, nil
+		case "message_stop":
+			s.done = true
+		case "error":
+			s.done = true
+			return nil, fmt.Errorf("stream error %s: %s", se.Error.Type, se.Error.Message)
+		}
+	}
+
+	return nil, io.EOF
+}
+
+func (s *stream) Close() error {
+	s.done = true
+	return s.body.Close()
+}
@@ -1,40 +1,96 @@
 package deepseek
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"time"
 
+	"github.com/muraduiurie/gpt/pkg/ai/cache"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
 	dstypes "github.com/muraduiurie/gpt/pkg/ai/types/deepseek"
 	"github.com/muraduiurie/gpt/pkg/ai/types/union"
 )
 
+func init() {
+	cache.RegisterResponse(func() union.Responser { return &dstypes.TextInputResponse{} })
+}
+
+const (
+	// ProviderName identifies this provider in responses and routing attempts.
+	ProviderName = "deepseek"
+	// DefaultTextInputEndpoint is used when TextInputEndpoint is empty.
+	DefaultTextInputEndpoint = "https://api.deepseek.com/chat/completions"
+)
+
 type Client struct {
 	ApiToken          string
 	TextInputEndpoint string
+	// Keys, when set, supplies the API token of each request instead of
+	// ApiToken.
+	Keys *keypool.Pool
+	// HTTPClient is used to send requests. Defaults to a client with a
+	// 300 second timeout.
+	HTTPClient *http.Client
+	// Observer is notified around every request, e.g. for logging.
+	Observer observe.Observer
+}
+
+// Provider returns the provider name, ProviderName.
+func (c *Client) Provider() string {
+	return ProviderName
+}
+
+// Endpoint returns the text input endpoint requests are sent to.
+func (c *Client) Endpoint() string {
+	if c.TextInputEndpoint == "" {
+		return DefaultTextInputEndpoint
+	}
+	return c.TextInputEndpoint
 }
 
-// AskAI sends a text request to the configured ChatGPT endpoint and returns
+// DefaultModel returns the model requests without one are sent to.
+func (c *Client) DefaultModel(opts *union.Request) string {
+	return string(dstypes.DeepSeekAIModelChat)
+}
+
+// AskAI sends a text request to the configured DeepSeek endpoint and returns
 // the parsed response. It validates inputs, performs the HTTP POST request,
 // and unmarshals the response body. An error is returned for invalid input,
 // network issues, or unexpected HTTP status codes.
 func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
-	if c.TextInputEndpoint == "" {
-		c.TextInputEndpoint = "https://api.deepseek.com/chat/completions"
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := textRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
 	}
+
+	call := observe.NewCall(ProviderName, string(textRequest.Model), c.Endpoint(), false, opts, body)
+	return c.wire().Chat(opts, call, &dstypes.TextInputResponse{})
+}
+
+// textRequest returns the validated DeepSeek request of opts, with defaults
+// filled in.
+func (c *Client) textRequest(opts *union.Request) (*dstypes.TextInputRequest, error) {
 	if opts == nil {
 		return nil, errors.New("nil opts")
 	}
-	textRequest, ok := opts.TextRequest.(*dstypes.TextInputRequest)
+	requester := opts.TextRequest
+	if requester == nil && opts.Prompt != nil {
+		requester = fromPrompt(opts.Prompt)
+	}
+	textRequest, ok := requester.(*dstypes.TextInputRequest)
 	if !ok {
 		return nil, fmt.Errorf("*dstypes.TextInputRequest type conversion failed")
 	}
 
 	if textRequest.Model == "" {
-		textRequest.Model = dstypes.DeepSeekAIModelChat
+		textRequest.Model = dstypes.DeepSeekAIModel(c.DefaultModel(opts))
 	}
 	if len(textRequest.Messages) == 0 {
 		return nil, fmt.Errorf("messages is required")
@@ -48,41 +104,16 @@ func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
 		}
 	}
 
-	body, err := opts.TextRequest.Marshal()
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPost, c.TextInputEndpoint, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.ApiToken)
-
-	httpClient := &http.Client{Timeout: 300 * time.Second}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("do request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
-	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
-	}
+	return textRequest, nil
+}
 
-	var textResponse dstypes.TextInputResponse
-	err = textResponse.Unmarshal(respBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+// wire returns the transport requests are sent with.
+func (c *Client) wire() *openaiwire.Transport {
+	return &openaiwire.Transport{
+		Provider:   ProviderName,
+		Token:      c.ApiToken,
+		Keys:       c.Keys,
+		HTTPClient: c.HTTPClient,
+		Observer:   c.Observer,
 	}
-
-	return &union.Response{
-		TextResponse: &textResponse,
-	}, nil
 }
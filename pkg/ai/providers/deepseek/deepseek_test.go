@@ -0,0 +1,123 @@
+package deepseek_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/deepseek"
+	dstypes "github.com/muraduiurie/gpt/pkg/ai/types/deepseek"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func newClient(srv *aitest.Server) *deepseek.Client {
+	return &deepseek.Client{ApiToken: aitest.DefaultAPIKey, TextInputEndpoint: srv.Endpoint()}
+}
+
+func request(input string) *union.Request {
+	return &union.Request{TextRequest: &dstypes.TextInputRequest{
+		Messages: []dstypes.TextInputRequestMessage{{Content: input}},
+	}}
+}
+
+func TestAskAI(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello there" }
+
+	resp, err := newClient(srv).AskAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "Hello there" {
+		t.Errorf("Output = %q, want Hello there", resp.Output)
+	}
+	if resp.Provider != deepseek.ProviderName {
+		t.Errorf("Provider = %q, want %q", resp.Provider, deepseek.ProviderName)
+	}
+	if resp.Usage == nil || resp.Usage.OutputTokens == 0 {
+		t.Errorf("Usage = %+v, want output tokens", resp.Usage)
+	}
+	if resp.Metadata.StatusCode != http.StatusOK || resp.Metadata.RequestID != "req_aitest_1" {
+		t.Errorf("Metadata = %+v", resp.Metadata)
+	}
+	if resp.Metadata.RateLimit.LimitRequests == 0 {
+		t.Error("rate-limit headers were not parsed")
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("server got %d requests, want 1", len(reqs))
+	}
+	if got := reqs[0].Header.Get("Authorization"); got != "Bearer "+aitest.DefaultAPIKey {
+		t.Errorf("Authorization = %q", got)
+	}
+	if !strings.Contains(string(reqs[0].Body), `"model":"deepseek-chat"`) {
+		t.Errorf("body %s lacks the default model", reqs[0].Body)
+	}
+}
+
+func TestStreamAI(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello there, how are you?" }
+
+	s, err := newClient(srv).StreamAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	defer s.Close()
+
+	var text strings.Builder
+	var usage *union.Usage
+	for {
+		ev, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		text.WriteString(ev.Delta)
+		if ev.Usage != nil {
+			usage = ev.Usage
+		}
+	}
+	if text.String() != "Hello there, how are you?" {
+		t.Errorf("streamed %q", text.String())
+	}
+	if usage == nil || usage.OutputTokens == 0 {
+		t.Errorf("usage = %+v, want output tokens", usage)
+	}
+}
+
+func TestAskAIErrorStatus(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+	srv.FailNext(http.StatusInternalServerError, 1)
+
+	_, err := newClient(srv).AskAI(request("Say hello"))
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *union.APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusInternalServerError || apiErr.Metadata == nil {
+		t.Errorf("APIError = %+v, want a 500 with metadata", apiErr)
+	}
+}
+
+func TestStreamAIErrorStatus(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+
+	c := newClient(srv)
+	c.ApiToken = "wrong"
+	_, err := c.StreamAI(request("Say hello"))
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want a 401 *union.APIError", err)
+	}
+}
@@ -0,0 +1,20 @@
+package deepseek
+
+import (
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
+	dstypes "github.com/muraduiurie/gpt/pkg/ai/types/deepseek"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// fromPrompt translates a provider-neutral prompt into a chat completions
+// request. The system prompt becomes a leading system message.
+func fromPrompt(p *union.Prompt) *dstypes.TextInputRequest {
+	return &dstypes.TextInputRequest{
+		Model:       dstypes.DeepSeekAIModel(p.Model),
+		Temperature: p.Temperature,
+		MaxTokens:   openaiwire.MaxTokens(p),
+		Messages: openaiwire.PromptMessages(p, func(role, content string) dstypes.TextInputRequestMessage {
+			return dstypes.TextInputRequestMessage{Role: dstypes.DeepSeekAIRole(role), Content: content}
+		}),
+	}
+}
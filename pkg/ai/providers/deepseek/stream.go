@@ -0,0 +1,29 @@
+package deepseek
+
+import (
+	"fmt"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// StreamAI sends a text request with streaming enabled and returns the
+// answer as a stream of text deltas. Usage is requested through
+// stream_options and reported on the last event.
+func (c *Client) StreamAI(opts *union.Request) (union.Stream, error) {
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	streamRequest := *textRequest
+	streamRequest.Stream = true
+	streamRequest.StreamOptions = map[string]bool{"include_usage": true}
+	body, err := streamRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(textRequest.Model), c.Endpoint(), true, opts, body)
+	return c.wire().StreamChat(opts, call)
+}
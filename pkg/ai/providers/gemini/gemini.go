@@ -0,0 +1,253 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/cache"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	gmtypes "github.com/muraduiurie/gpt/pkg/ai/types/gemini"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func init() {
+	cache.RegisterResponse(func() union.Responser { return &gmtypes.TextInputResponse{} })
+}
+
+const (
+	// ProviderName identifies this provider in responses and routing attempts.
+	ProviderName = "gemini"
+	// DefaultTextInputEndpoint is used when TextInputEndpoint is empty. The
+	// model and the method are appended to it, e.g.
+	// `/gemini-2.5-flash:generateContent`.
+	DefaultTextInputEndpoint = "https://generativelanguage.googleapis.com/v1beta/models"
+)
+
+type Client struct {
+	ApiToken          string
+	TextInputEndpoint string
+	// Keys, when set, supplies the API token of each request instead of
+	// ApiToken.
+	Keys *keypool.Pool
+	// HTTPClient is used to send requests. Defaults to a client with a
+	// 300 second timeout.
+	HTTPClient *http.Client
+	// Observer is notified around every request, e.g. for logging.
+	Observer observe.Observer
+}
+
+// Provider returns the provider name, ProviderName.
+func (c *Client) Provider() string {
+	return ProviderName
+}
+
+// Endpoint returns the models endpoint requests are sent under.
+func (c *Client) Endpoint() string {
+	if c.TextInputEndpoint == "" {
+		return DefaultTextInputEndpoint
+	}
+	return strings.TrimSuffix(c.TextInputEndpoint, "/")
+}
+
+// DefaultModel returns the model requests without one are sent to.
+func (c *Client) DefaultModel(opts *union.Request) string {
+	return string(gmtypes.GeminiAIModel2_5Flash)
+}
+
+// AskAI sends a generateContent request for the model of the request and
+// returns the parsed response. An error is returned for invalid input,
+// network issues, or unexpected HTTP status codes.
+func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
+	if c.Keys == nil {
+		return c.askAI(opts, c.ApiToken)
+	}
+
+	lease, err := c.Keys.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.askAI(opts, lease.Token())
+	lease.Release(resp, err)
+
+	return resp, err
+}
+
+func (c *Client) askAI(opts *union.Request, token string) (*union.Response, error) {
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := wireBody(textRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	url := c.methodURL(textRequest.Model, "generateContent")
+	call := observe.NewCall(ProviderName, string(textRequest.Model), url, false, opts, body)
+	ctx := observe.Begin(requestContext(opts), c.Observer, call)
+	start := time.Now()
+	resp, respBody, err := c.do(ctx, call, token, body)
+	observe.Finish(ctx, c.Observer, call, observe.NewResult(resp, respBody, err, time.Since(start)))
+
+	return resp, err
+}
+
+// do sends the request and decodes the response. The raw response body is
+// returned for observers.
+func (c *Client) do(ctx context.Context, call *observe.Call, token string, body []byte) (*union.Response, []byte, error) {
+	start := time.Now()
+	resp, err := c.send(ctx, call, token, body)
+	if err != nil {
+		return nil, nil, &union.RequestError{Err: err, Metadata: &union.Metadata{Latency: time.Since(start)}}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	meta := union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start))
+	if err != nil {
+		return nil, nil, &union.RequestError{Err: fmt.Errorf("read response: %w", err), Metadata: meta}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, respBody, &union.APIError{
+			StatusCode: resp.StatusCode,
+			Body:       respBody,
+			Metadata:   meta,
+		}
+	}
+
+	var textResponse gmtypes.TextInputResponse
+	err = textResponse.Unmarshal(respBody)
+	if err != nil {
+		return nil, respBody, &union.RequestError{Err: fmt.Errorf("failed to unmarshal response: %w", err), Metadata: meta}
+	}
+	text, finishReason := output(&textResponse)
+
+	return &union.Response{
+		TextResponse: &textResponse,
+		Metadata:     meta,
+		Provider:     ProviderName,
+		Output:       text,
+		FinishReason: finishReason,
+		Usage:        usage(textResponse.UsageMetadata),
+	}, respBody, nil
+}
+
+// textRequest returns the validated Gemini request of opts, with defaults
+// filled in.
+func (c *Client) textRequest(opts *union.Request) (*gmtypes.TextInputRequest, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+	requester := opts.TextRequest
+	if requester == nil && opts.Prompt != nil {
+		requester = fromPrompt(opts.Prompt)
+	}
+	textRequest, ok := requester.(*gmtypes.TextInputRequest)
+	if !ok {
+		return nil, fmt.Errorf("*gmtypes.TextInputRequest type conversion failed")
+	}
+
+	if textRequest.Model == "" {
+		textRequest.Model = gmtypes.GeminiAIModel(c.DefaultModel(opts))
+	}
+	if len(textRequest.Contents) == 0 {
+		return nil, errors.New("contents is required")
+	}
+	for i, content := range textRequest.Contents {
+		if content.Role == "" {
+			textRequest.Contents[i].Role = gmtypes.GeminiAIRoleUser
+		}
+		if len(content.Parts) == 0 {
+			return nil, errors.New("parts in content is required")
+		}
+	}
+
+	return textRequest, nil
+}
+
+// wireBody marshals the request without the model, which goes in the URL.
+func wireBody(r *gmtypes.TextInputRequest) ([]byte, error) {
+	wire := *r
+	wire.Model = ""
+	body, err := wire.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	return body, nil
+}
+
+// methodURL returns the URL of a method of model, e.g.
+// `.../models/gemini-2.5-flash:generateContent`.
+func (c *Client) methodURL(model gmtypes.GeminiAIModel, method string) string {
+	return fmt.Sprintf("%s/%s:%s", c.Endpoint(), model, method)
+}
+
+// send posts body to the URL of the call. The extra headers of the call are
+// applied last.
+func (c *Client) send(ctx context.Context, call *observe.Call, token string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, call.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", token)
+	for k, vs := range call.Header {
+		req.Header[k] = vs
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 300 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+
+	return resp, nil
+}
+
+func requestContext(opts *union.Request) context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+func usage(u gmtypes.UsageMetadata) *union.Usage {
+	return &union.Usage{
+		InputTokens:       u.PromptTokenCount,
+		OutputTokens:      u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		TotalTokens:       u.TotalTokenCount,
+		CachedInputTokens: u.CachedContentTokenCount,
+	}
+}
+
+// output returns the text of the first candidate, without thoughts, and its
+// finish reason. A blocked prompt has no candidates; its block reason is
+// returned as the finish reason.
+func output(r *gmtypes.TextInputResponse) (string, string) {
+	if len(r.Candidates) == 0 {
+		if r.PromptFeedback != nil {
+			return "", r.PromptFeedback.BlockReason
+		}
+		return "", ""
+	}
+
+	var sb strings.Builder
+	for _, p := range r.Candidates[0].Content.Parts {
+		if !p.Thought {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String(), r.Candidates[0].FinishReason
+}
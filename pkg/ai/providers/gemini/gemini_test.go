@@ -0,0 +1,172 @@
+package gemini_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/providers/gemini"
+	gmtypes "github.com/muraduiurie/gpt/pkg/ai/types/gemini"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+const apiKey = "gemini-key"
+
+// received is a request received by the test server.
+type received struct {
+	Path, Query, APIKey string
+	Body                map[string]json.RawMessage
+}
+
+// newServer starts a server that records the requests and answers them
+// with handle.
+func newServer(t *testing.T, handle func(w http.ResponseWriter)) (*httptest.Server, *[]received) {
+	t.Helper()
+	var reqs []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		reqs = append(reqs, received{Path: r.URL.Path, Query: r.URL.RawQuery, APIKey: r.Header.Get("x-goog-api-key"), Body: body})
+		handle(w)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+func newClient(srv *httptest.Server) *gemini.Client {
+	return &gemini.Client{ApiToken: apiKey, TextInputEndpoint: srv.URL + "/v1beta/models"}
+}
+
+func request(input string) *union.Request {
+	return &union.Request{TextRequest: &gmtypes.TextInputRequest{
+		Contents: []gmtypes.Content{{Parts: []gmtypes.Part{{Text: input}}}},
+	}}
+}
+
+func TestAskAI(t *testing.T) {
+	srv, reqs := newServer(t, func(w http.ResponseWriter) {
+		fmt.Fprint(w, `{
+			"candidates": [{
+				"content": {"role": "model", "parts": [{"text": "thinking...", "thought": true}, {"text": "Hello "}, {"text": "there"}]},
+				"finishReason": "STOP"
+			}],
+			"usageMetadata": {"promptTokenCount": 3, "candidatesTokenCount": 2, "thoughtsTokenCount": 4, "totalTokenCount": 9}
+		}`)
+	})
+
+	resp, err := newClient(srv).AskAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "Hello there" || resp.FinishReason != "STOP" || resp.Provider != gemini.ProviderName {
+		t.Errorf("response = %q, %q from %q", resp.Output, resp.FinishReason, resp.Provider)
+	}
+	if u := resp.Usage; u.InputTokens != 3 || u.OutputTokens != 6 || u.TotalTokens != 9 {
+		t.Errorf("Usage = %+v, want thoughts counted as output", u)
+	}
+
+	req := (*reqs)[0]
+	if want := "/v1beta/models/gemini-2.5-flash:generateContent"; req.Path != want {
+		t.Errorf("path = %s, want %s", req.Path, want)
+	}
+	if req.APIKey != apiKey {
+		t.Errorf("x-goog-api-key = %q", req.APIKey)
+	}
+	if _, ok := req.Body["model"]; ok {
+		t.Error("body carries the model")
+	}
+	if got := string(req.Body["contents"]); !strings.Contains(got, `"role":"user"`) {
+		t.Errorf("contents = %s, want the user role filled in", got)
+	}
+}
+
+func TestAskAIBlockedPrompt(t *testing.T) {
+	srv, _ := newServer(t, func(w http.ResponseWriter) {
+		fmt.Fprint(w, `{"promptFeedback": {"blockReason": "SAFETY"}}`)
+	})
+
+	resp, err := newClient(srv).AskAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "" || resp.FinishReason != "SAFETY" {
+		t.Errorf("response = %q, %q, want the block reason", resp.Output, resp.FinishReason)
+	}
+}
+
+func TestStreamAI(t *testing.T) {
+	srv, reqs := newServer(t, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"candidates": [{"content": {"parts": [{"text": "Hello"}]}}]}`,
+			`{"candidates": [{"content": {"parts": [{"text": " there"}]}}]}`,
+			`{"candidates": [{"content": {"parts": [{"text": "!"}]}, "finishReason": "STOP"}],
+			  "usageMetadata": {"promptTokenCount": 3, "candidatesTokenCount": 3, "totalTokenCount": 6}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", strings.ReplaceAll(chunk, "\n", ""))
+		}
+	})
+
+	s, err := newClient(srv).StreamAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	defer s.Close()
+
+	var (
+		text strings.Builder
+		last *union.StreamEvent
+	)
+	for {
+		ev, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		text.WriteString(ev.Delta)
+		last = ev
+	}
+	if text.String() != "Hello there!" {
+		t.Errorf("streamed %q", text.String())
+	}
+	if last.FinishReason != "STOP" || last.Usage == nil || last.Usage.TotalTokens != 6 {
+		t.Errorf("last event = %+v, want the finish reason and usage", last)
+	}
+
+	req := (*reqs)[0]
+	if want := "/v1beta/models/gemini-2.5-flash:streamGenerateContent"; req.Path != want || req.Query != "alt=sse" {
+		t.Errorf("URL = %s?%s, want %s?alt=sse", req.Path, req.Query, want)
+	}
+}
+
+func TestAskAIErrorStatus(t *testing.T) {
+	srv, _ := newServer(t, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": {"code": 400, "message": "API key not valid", "status": "INVALID_ARGUMENT"}}`)
+	})
+
+	for name, call := range map[string]func(*union.Request) error{
+		"AskAI": func(r *union.Request) error {
+			_, err := newClient(srv).AskAI(r)
+			return err
+		},
+		"StreamAI": func(r *union.Request) error {
+			_, err := newClient(srv).StreamAI(r)
+			return err
+		},
+	} {
+		var apiErr *union.APIError
+		if err := call(request("Say hello")); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: err = %v, want a 400 *union.APIError", name, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package gemini
+
+import (
+	gmtypes "github.com/muraduiurie/gpt/pkg/ai/types/gemini"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// fromPrompt translates a provider-neutral prompt into a generateContent
+// request. The system prompt becomes the system instruction and assistant
+// messages use the "model" role.
+func fromPrompt(p *union.Prompt) *gmtypes.TextInputRequest {
+	r := &gmtypes.TextInputRequest{
+		Model: gmtypes.GeminiAIModel(p.Model),
+	}
+	if p.System != "" {
+		r.SystemInstruction = &gmtypes.Content{
+			Parts: []gmtypes.Part{{Text: p.System}},
+		}
+	}
+	if p.MaxTokens > 0 || p.Temperature != nil {
+		r.GenerationConfig = &gmtypes.GenerationConfig{Temperature: p.Temperature}
+		if p.MaxTokens > 0 {
+			maxTokens := p.MaxTokens
+			r.GenerationConfig.MaxOutputTokens = &maxTokens
+		}
+	}
+	for _, m := range p.Messages {
+		role := gmtypes.GeminiAIRoleUser
+		if m.Role == union.PromptRoleAssistant {
+			role = gmtypes.GeminiAIRoleModel
+		}
+		r.Contents = append(r.Contents, gmtypes.Content{
+			Role:  role,
+			Parts: []gmtypes.Part{{Text: m.Content}},
+		})
+	}
+
+	return r
+}
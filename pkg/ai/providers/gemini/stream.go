@@ -0,0 +1,125 @@
+package gemini
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/sse"
+	gmtypes "github.com/muraduiurie/gpt/pkg/ai/types/gemini"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// StreamAI sends a streamGenerateContent request and returns the answer as
+// a stream of text deltas. The last event carries the finish reason and the
+// token usage.
+func (c *Client) StreamAI(opts *union.Request) (union.Stream, error) {
+	if c.Keys == nil {
+		s, _, err := c.streamAI(opts, c.ApiToken)
+		return s, err
+	}
+
+	lease, err := c.Keys.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	s, meta, err := c.streamAI(opts, lease.Token())
+	if err != nil {
+		lease.Release(nil, err)
+		return nil, err
+	}
+
+	return lease.Stream(s, meta), nil
+}
+
+func (c *Client) streamAI(opts *union.Request, token string) (union.Stream, *union.Metadata, error) {
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := wireBody(textRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// alt=sse switches the response from a JSON array to server-sent events
+	url := c.methodURL(textRequest.Model, "streamGenerateContent") + "?alt=sse"
+	call := observe.NewCall(ProviderName, string(textRequest.Model), url, true, opts, body)
+	ctx := observe.Begin(requestContext(opts), c.Observer, call)
+	start := time.Now()
+	resp, err := c.send(ctx, call, token, body)
+	if err != nil {
+		err = &union.RequestError{Err: err, Metadata: &union.Metadata{Latency: time.Since(start)}}
+		observe.Finish(ctx, c.Observer, call, observe.NewResult(nil, nil, err, time.Since(start)))
+		return nil, nil, err
+	}
+
+	meta := union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			err = &union.RequestError{Err: fmt.Errorf("read response: %w", err), Metadata: meta}
+		} else {
+			err = &union.APIError{
+				StatusCode: resp.StatusCode,
+				Body:       respBody,
+				Metadata:   meta,
+			}
+		}
+		observe.Finish(ctx, c.Observer, call, observe.NewResult(nil, respBody, err, time.Since(start)))
+		return nil, nil, err
+	}
+
+	s := &stream{
+		body:   resp.Body,
+		events: sse.NewReader(resp.Body),
+	}
+	return observe.Stream(ctx, c.Observer, call, start, meta, s), meta, nil
+}
+
+// stream reads the server-sent events of streamGenerateContent. Each event
+// is a partial response; the stream ends with the connection.
+type stream struct {
+	body   io.ReadCloser
+	events *sse.Reader
+	done   bool
+}
+
+func (s *stream) Recv() (*union.StreamEvent, error) {
+	for !s.done {
+		ev, err := s.events.Next()
+		if err == io.EOF {
+			s.done = true
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var chunk gmtypes.TextInputResponse
+		if err = chunk.Unmarshal(ev.Data); err != nil {
+			return nil, fmt.Errorf("decode stream chunk: %w", err)
+		}
+
+		out := &union.StreamEvent{Raw: ev.Data}
+		out.Delta, out.FinishReason = output(&chunk)
+		if out.FinishReason != "" {
+			out.Usage = usage(chunk.UsageMetadata)
+		}
+		if out.Delta == "" && out.FinishReason == "" {
+			continue
+		}
+
+		return out, nil
+	}
+
+	return nil, io.EOF
+}
+
+func (s *stream) Close() error {
+	s.done = true
+	return s.body.Close()
+}
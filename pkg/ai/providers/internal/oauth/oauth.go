@@ -0,0 +1,118 @@
+// Package oauth holds the OAuth 2.0 plumbing of the providers that
+// authenticate with bearer tokens: Azure OpenAI with Microsoft Entra ID and
+// Claude on Vertex AI.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRefreshMargin is how long before expiry a cached token is
+	// refreshed, at most; short-lived tokens are refreshed halfway through
+	// their lifetime instead.
+	maxRefreshMargin = 5 * time.Minute
+	// defaultLifetime is assumed for tokens issued without `expires_in`.
+	defaultLifetime = time.Hour
+)
+
+// TokenSource supplies bearer tokens.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource returning a fixed token.
+type StaticToken string
+
+func (t StaticToken) Token(context.Context) (string, error) {
+	if t == "" {
+		return "", errors.New("empty access token")
+	}
+	return string(t), nil
+}
+
+// Cache holds a token until shortly before it expires, so requests never
+// carry an expired one. The zero value is empty and ready to use.
+type Cache struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// Token returns the cached token, or one from fetch, which reports the
+// lifetime of the token. Concurrent callers wait for a single fetch.
+func (c *Cache) Token(ctx context.Context, fetch func(ctx context.Context) (string, time.Duration, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+	token, lifetime, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	c.expires = time.Now().Add(lifetime - refreshMargin(lifetime))
+	return c.token, nil
+}
+
+// refreshMargin returns how long before the end of lifetime a token is
+// refreshed: five minutes, or half the lifetime of shorter-lived tokens.
+func refreshMargin(lifetime time.Duration) time.Duration {
+	return min(maxRefreshMargin, lifetime/2)
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// RequestToken posts form to the token endpoint and returns the access
+// token with its lifetime, an hour when the response does not tell. A nil
+// httpClient is a client with a 30 second timeout.
+func RequestToken(ctx context.Context, httpClient *http.Client, tokenURL string, form url.Values) (string, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("do token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("read token response: %w", err)
+	}
+	var tr tokenResponse
+	if err = json.Unmarshal(body, &tr); err != nil {
+		return "", 0, fmt.Errorf("failed to unmarshal token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		return "", 0, fmt.Errorf("token request failed with status %d: %s %s", resp.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+
+	lifetime := time.Duration(tr.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultLifetime
+	}
+	return tr.AccessToken, lifetime, nil
+}
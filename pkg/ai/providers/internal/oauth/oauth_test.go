@@ -0,0 +1,104 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRefreshMargin(t *testing.T) {
+	for _, tc := range []struct {
+		lifetime, want time.Duration
+	}{
+		{time.Hour, 5 * time.Minute},
+		{10 * time.Minute, 5 * time.Minute},
+		{5 * time.Minute, 150 * time.Second},
+		{time.Minute, 30 * time.Second},
+	} {
+		if got := refreshMargin(tc.lifetime); got != tc.want {
+			t.Errorf("refreshMargin(%v) = %v, want %v", tc.lifetime, got, tc.want)
+		}
+	}
+}
+
+func TestCacheKeepsShortLivedTokens(t *testing.T) {
+	var c Cache
+	fetches := 0
+	fetch := func(context.Context) (string, time.Duration, error) {
+		fetches++
+		return fmt.Sprintf("token-%d", fetches), 2 * time.Minute, nil
+	}
+
+	for range 3 {
+		got, err := c.Token(t.Context(), fetch)
+		if err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+		if got != "token-1" {
+			t.Errorf("Token = %q, want token-1", got)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d tokens, want 1", fetches)
+	}
+
+	c.expires = time.Now().Add(-time.Second)
+	if got, _ := c.Token(t.Context(), fetch); got != "token-2" {
+		t.Errorf("Token after expiry = %q, want token-2", got)
+	}
+}
+
+func TestCacheKeepsNoTokenOnError(t *testing.T) {
+	var c Cache
+	if _, err := c.Token(t.Context(), func(context.Context) (string, time.Duration, error) {
+		return "", 0, fmt.Errorf("boom")
+	}); err == nil {
+		t.Fatal("Token succeeded")
+	}
+	if c.token != "" {
+		t.Errorf("cached %q after an error", c.token)
+	}
+}
+
+func TestRequestToken(t *testing.T) {
+	for _, tc := range []struct {
+		name, body string
+		want       time.Duration
+	}{
+		{"expires_in", `{"access_token":"tok","expires_in":120}`, 2 * time.Minute},
+		{"no expires_in", `{"access_token":"tok"}`, defaultLifetime},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+					t.Errorf("form = %v, %v", r.PostForm, err)
+				}
+				fmt.Fprint(w, tc.body)
+			}))
+			defer srv.Close()
+
+			token, lifetime, err := RequestToken(t.Context(), nil, srv.URL, map[string][]string{"grant_type": {"client_credentials"}})
+			if err != nil {
+				t.Fatalf("RequestToken: %v", err)
+			}
+			if token != "tok" || lifetime != tc.want {
+				t.Errorf("RequestToken = %q, %v, want tok, %v", token, lifetime, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequestTokenError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"invalid_client","error_description":"bad secret"}`)
+	}))
+	defer srv.Close()
+
+	if _, _, err := RequestToken(t.Context(), nil, srv.URL, nil); err == nil {
+		t.Fatal("RequestToken succeeded")
+	}
+}
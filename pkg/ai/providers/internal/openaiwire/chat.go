@@ -0,0 +1,174 @@
+package openaiwire
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/sse"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Usage is the token usage of a chat completion. Servers report cached
+// prompt tokens either in prompt_tokens_details (OpenAI) or in
+// prompt_cache_hit_tokens (DeepSeek).
+type Usage struct {
+	PromptTokens         int `json:"prompt_tokens"`
+	CompletionTokens     int `json:"completion_tokens"`
+	TotalTokens          int `json:"total_tokens"`
+	PromptCacheHitTokens int `json:"prompt_cache_hit_tokens"`
+	PromptTokensDetails  struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// Union returns u as a union.Usage.
+func (u *Usage) Union() *union.Usage {
+	cached := u.PromptTokensDetails.CachedTokens
+	if cached == 0 {
+		cached = u.PromptCacheHitTokens
+	}
+	return &union.Usage{
+		InputTokens:       u.PromptTokens,
+		OutputTokens:      u.CompletionTokens,
+		TotalTokens:       u.TotalTokens,
+		CachedInputTokens: cached,
+	}
+}
+
+// completion holds the fields of a chat completion every server returns.
+type completion struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage Usage `json:"usage"`
+}
+
+// Chat sends a chat completions request and decodes the response into
+// textResponse, the provider's own response type. The output is the
+// message of the first choice.
+func (t *Transport) Chat(opts *union.Request, call *observe.Call, textResponse union.Responser) (*union.Response, error) {
+	return t.Do(opts, call, func(meta *union.Metadata, body []byte) (*union.Response, error) {
+		var c completion
+		if err := json.Unmarshal(body, &c); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		if err := textResponse.Unmarshal(body); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+
+		resp := &union.Response{
+			TextResponse: textResponse,
+			Metadata:     meta,
+			Provider:     t.Provider,
+			Usage:        c.Usage.Union(),
+		}
+		if len(c.Choices) > 0 {
+			resp.Output = c.Choices[0].Message.Content
+			resp.FinishReason = c.Choices[0].FinishReason
+		}
+		return resp, nil
+	})
+}
+
+// StreamChat sends a chat completions request with streaming enabled and
+// returns the answer as a stream of text deltas. The body of the call must
+// set "stream", and should request usage through stream_options when the
+// server supports it.
+func (t *Transport) StreamChat(opts *union.Request, call *observe.Call) (union.Stream, error) {
+	return t.Stream(opts, call, func(body io.ReadCloser) union.Stream {
+		return &chatStream{
+			body:   body,
+			events: sse.NewReader(body),
+		}
+	})
+}
+
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+}
+
+type chatStream struct {
+	body   io.ReadCloser
+	events *sse.Reader
+	done   bool
+}
+
+func (s *chatStream) Recv() (*union.StreamEvent, error) {
+	for !s.done {
+		ev, err := s.events.Next()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(ev.Data, []byte("[DONE]")) {
+			s.done = true
+			break
+		}
+
+		var chunk chatStreamChunk
+		if err = json.Unmarshal(ev.Data, &chunk); err != nil {
+			return nil, fmt.Errorf("decode stream chunk: %w", err)
+		}
+
+		out := &union.StreamEvent{Raw: ev.Data}
+		if len(chunk.Choices) > 0 {
+			out.Delta = chunk.Choices[0].Delta.Content
+			if chunk.Choices[0].FinishReason != nil {
+				out.FinishReason = *chunk.Choices[0].FinishReason
+			}
+		}
+		if chunk.Usage != nil {
+			out.Usage = chunk.Usage.Union()
+		}
+		if out.Delta == "" && out.FinishReason == "" && out.Usage == nil {
+			continue
+		}
+
+		return out, nil
+	}
+
+	return nil, io.EOF
+}
+
+func (s *chatStream) Close() error {
+	s.done = true
+	return s.body.Close()
+}
+
+// PromptMessages translates the system prompt and the messages of a
+// provider-neutral prompt into chat messages built by message. The system
+// prompt becomes a leading system message.
+func PromptMessages[M any](p *union.Prompt, message func(role, content string) M) []M {
+	var messages []M
+	if p.System != "" {
+		messages = append(messages, message("system", p.System))
+	}
+	for _, m := range p.Messages {
+		messages = append(messages, message(string(m.Role), m.Content))
+	}
+	return messages
+}
+
+// MaxTokens returns the token limit of a provider-neutral prompt, nil when
+// it is unset.
+func MaxTokens(p *union.Prompt) *int {
+	if p.MaxTokens <= 0 {
+		return nil
+	}
+	maxTokens := p.MaxTokens
+	return &maxTokens
+}
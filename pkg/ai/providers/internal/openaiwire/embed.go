@@ -0,0 +1,135 @@
+package openaiwire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Embed returns the embeddings of the inputs from an `/embeddings`
+// endpoint. Inputs above limits are split into batches sent concurrently,
+// each with its own key of the pool; the vectors are returned in input
+// order and the usage is summed. model is used for requests without one.
+func (t *Transport) Embed(opts *union.EmbedRequest, endpoint, model string, limits embed.Limits) (*union.EmbedResponse, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+	if len(opts.Input) == 0 {
+		return nil, errors.New("input is required")
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return embed.Run(ctx, opts.Input, limits, func(ctx context.Context, batch []string) (*union.EmbedResponse, error) {
+		req := *opts
+		req.Context = ctx
+		req.Input = batch
+		if t.Keys == nil {
+			return t.embed(&req, t.Token, endpoint, model)
+		}
+
+		lease, err := t.Keys.Acquire()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.embed(&req, lease.Token(), endpoint, model)
+		lease.Release(leaseResult(resp), err)
+
+		return resp, err
+	})
+}
+
+// embed sends one batch.
+func (t *Transport) embed(opts *union.EmbedRequest, token, endpoint, model string) (*union.EmbedResponse, error) {
+	embedRequest := &cgtypes.EmbeddingRequest{
+		Model:          cgtypes.ChatGPTAIModel(opts.Model),
+		Input:          opts.Input,
+		EncodingFormat: "float",
+	}
+	if embedRequest.Model == "" {
+		embedRequest.Model = cgtypes.ChatGPTAIModel(model)
+	}
+	if embedRequest.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	if opts.Dimensions > 0 {
+		dimensions := opts.Dimensions
+		embedRequest.Dimensions = &dimensions
+	}
+
+	body, err := embedRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewEmbedCall(t.Provider, string(embedRequest.Model), endpoint, opts, body)
+	ctx := observe.Begin(opts.Context, t.Observer, call)
+	start := time.Now()
+
+	var resp *union.EmbedResponse
+	meta, respBody, err := t.Post(ctx, call, token, "application/json", body)
+	if err == nil {
+		var embedResponse cgtypes.EmbeddingResponse
+		if err = embedResponse.Unmarshal(respBody); err != nil {
+			err = &union.RequestError{Err: fmt.Errorf("failed to unmarshal response: %w", err), Metadata: meta}
+		} else if resp, err = t.embedOutput(&embedResponse, len(opts.Input)); err != nil {
+			err = &union.RequestError{Err: err, Metadata: meta}
+		} else {
+			resp.Metadata = meta
+		}
+	}
+	observe.Finish(ctx, t.Observer, call, observe.NewEmbedResult(resp, respBody, err, time.Since(start)))
+
+	return resp, err
+}
+
+// embedOutput orders the vectors of r by input index. Some servers omit
+// the index; the order of the data is kept then.
+func (t *Transport) embedOutput(r *cgtypes.EmbeddingResponse, n int) (*union.EmbedResponse, error) {
+	indexed := false
+	for _, d := range r.Data {
+		indexed = indexed || d.Index != 0
+	}
+	embeddings := make([][]float32, n)
+	for i, d := range r.Data {
+		idx := d.Index
+		if !indexed {
+			idx = i
+		}
+		if idx < 0 || idx >= n {
+			return nil, fmt.Errorf("embedding index %d out of range", idx)
+		}
+		embeddings[idx] = d.Embedding
+	}
+	for i, e := range embeddings {
+		if e == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+
+	return &union.EmbedResponse{
+		Embeddings: embeddings,
+		Model:      r.Model,
+		Provider:   t.Provider,
+		Usage: &union.Usage{
+			InputTokens: r.Usage.PromptTokens,
+			TotalTokens: r.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// leaseResult carries the metadata and usage of resp for key accounting.
+func leaseResult(resp *union.EmbedResponse) *union.Response {
+	if resp == nil {
+		return nil
+	}
+	return &union.Response{Metadata: resp.Metadata, Usage: resp.Usage, Provider: resp.Provider}
+}
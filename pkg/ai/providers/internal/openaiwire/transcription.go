@@ -0,0 +1,136 @@
+package openaiwire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Transcribe transcribes audio with an `/audio/transcriptions` endpoint,
+// uploading r.File as a multipart form. model is used for requests
+// without one. Timestamp granularities switch an unset format to
+// verbose_json.
+func (t *Transport) Transcribe(ctx context.Context, r *cgtypes.TranscriptionRequest, endpoint string, model cgtypes.ChatGPTAIModel) (*cgtypes.Transcription, error) {
+	if r == nil {
+		return nil, errors.New("nil request")
+	}
+	req := *r
+	if req.Model == "" {
+		req.Model = model
+	}
+	if len(req.TimestampGranularities) > 0 {
+		if req.ResponseFormat == "" {
+			req.ResponseFormat = cgtypes.TranscriptFormatVerboseJSON
+		}
+		if req.ResponseFormat != cgtypes.TranscriptFormatVerboseJSON {
+			return nil, fmt.Errorf("timestamp granularities need the %q format", cgtypes.TranscriptFormatVerboseJSON)
+		}
+	}
+	if err := checkTranscriptFormat(req.ResponseFormat); err != nil {
+		return nil, err
+	}
+
+	form, contentType, err := req.Multipart()
+	if err != nil {
+		return nil, err
+	}
+	logBody, err := req.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(t.Provider, string(req.Model), endpoint, false, &union.Request{Context: ctx}, logBody)
+	call.Operation = observe.OperationTranscription
+	return t.transcribe(ctx, call, contentType, form, req.ResponseFormat)
+}
+
+// Translate transcribes audio into English with an `/audio/translations`
+// endpoint, uploading r.File as a multipart form. model is used for
+// requests without one.
+func (t *Transport) Translate(ctx context.Context, r *cgtypes.TranslationRequest, endpoint string, model cgtypes.ChatGPTAIModel) (*cgtypes.Transcription, error) {
+	if r == nil {
+		return nil, errors.New("nil request")
+	}
+	req := *r
+	if req.Model == "" {
+		req.Model = model
+	}
+	if err := checkTranscriptFormat(req.ResponseFormat); err != nil {
+		return nil, err
+	}
+
+	form, contentType, err := req.Multipart()
+	if err != nil {
+		return nil, err
+	}
+	logBody, err := req.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(t.Provider, string(req.Model), endpoint, false, &union.Request{Context: ctx}, logBody)
+	call.Operation = observe.OperationTranslation
+	return t.transcribe(ctx, call, contentType, form, req.ResponseFormat)
+}
+
+// transcribe sends a transcription or translation form with a key of the
+// pool, if any. Observers see the request fields as JSON, without the
+// audio.
+func (t *Transport) transcribe(ctx context.Context, call *observe.Call, contentType string, form []byte, format cgtypes.TranscriptFormat) (*cgtypes.Transcription, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var transcription *cgtypes.Transcription
+	_, err := t.Lease(func(token string) (*union.Response, error) {
+		ctx := observe.Begin(ctx, t.Observer, call)
+		start := time.Now()
+
+		meta, respBody, err := t.Post(ctx, call, token, contentType, form)
+		if err == nil {
+			transcription, err = cgtypes.UnmarshalTranscription(respBody, format)
+			if err != nil {
+				err = &union.RequestError{Err: fmt.Errorf("failed to unmarshal response: %w", err), Metadata: meta}
+			}
+		}
+		res := observe.NewResult(nil, respBody, err, time.Since(start))
+		if meta != nil {
+			res.Metadata = meta
+		}
+		if transcription != nil {
+			res.Usage = transcriptionUsage(transcription.Usage)
+		}
+		observe.Finish(ctx, t.Observer, call, res)
+
+		return &union.Response{Metadata: res.Metadata, Usage: res.Usage}, err
+	})
+
+	return transcription, err
+}
+
+func checkTranscriptFormat(f cgtypes.TranscriptFormat) error {
+	switch f {
+	case "", cgtypes.TranscriptFormatJSON, cgtypes.TranscriptFormatText, cgtypes.TranscriptFormatVerboseJSON,
+		cgtypes.TranscriptFormatSRT, cgtypes.TranscriptFormatVTT:
+		return nil
+	}
+	return fmt.Errorf("unknown transcript format %q", f)
+}
+
+// transcriptionUsage returns the token usage of u, nil when it is billed
+// by duration.
+func transcriptionUsage(u *cgtypes.TranscriptionUsage) *union.Usage {
+	if u == nil || u.Type != "tokens" {
+		return nil
+	}
+	return &union.Usage{
+		InputTokens:  u.InputTokens,
+		OutputTokens: u.OutputTokens,
+		TotalTokens:  u.TotalTokens,
+	}
+}
@@ -0,0 +1,203 @@
+// Package openaiwire implements the parts of OpenAI's HTTP API shared by
+// the chatgpt, openaicompat, deepseek, mistral and azure clients: sending
+// requests with a key of the pool, chat completions and their streams,
+// embeddings and transcriptions. Each client parameterizes a Transport
+// with its name, credentials and headers.
+package openaiwire
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Transport sends the requests of one client.
+type Transport struct {
+	// Provider is the name reported in responses.
+	Provider string
+	// Token is the API token used when Keys is nil.
+	Token string
+	// Keys, when set, supplies the API token of each request.
+	Keys *keypool.Pool
+	// HTTPClient is used to send requests. Defaults to a client with a
+	// 300 second timeout.
+	HTTPClient *http.Client
+	// Observer is notified around every request.
+	Observer observe.Observer
+	// Authorize sets the credentials of req. Defaults to a Bearer token,
+	// omitted when the token is empty. It may reject the request, e.g. when
+	// the client is missing configuration.
+	Authorize func(ctx context.Context, req *http.Request, token string) error
+}
+
+// HTTP returns the HTTP client requests are sent with.
+func (t *Transport) HTTP() *http.Client {
+	if t.HTTPClient == nil {
+		return &http.Client{Timeout: 300 * time.Second}
+	}
+	return t.HTTPClient
+}
+
+// Send posts body to the endpoint of the call. The extra headers of the
+// call are applied last.
+func (t *Transport) Send(ctx context.Context, call *observe.Call, token, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, call.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if t.Authorize != nil {
+		if err = t.Authorize(ctx, req, token); err != nil {
+			return nil, err
+		}
+	} else if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, vs := range call.Header {
+		req.Header[k] = vs
+	}
+
+	resp, err := t.HTTP().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+
+	return resp, nil
+}
+
+// Post sends the request and reads the response body. Non-2xx responses
+// are returned as *union.APIError, failures to send the request or read
+// the response as *union.RequestError.
+func (t *Transport) Post(ctx context.Context, call *observe.Call, token, contentType string, body []byte) (*union.Metadata, []byte, error) {
+	start := time.Now()
+	resp, err := t.Send(ctx, call, token, contentType, body)
+	if err != nil {
+		return nil, nil, &union.RequestError{Err: err, Metadata: &union.Metadata{Latency: time.Since(start)}}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	meta := union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start))
+	if err != nil {
+		return nil, nil, &union.RequestError{Err: fmt.Errorf("read response: %w", err), Metadata: meta}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, respBody, &union.APIError{
+			StatusCode: resp.StatusCode,
+			Body:       respBody,
+			Metadata:   meta,
+		}
+	}
+
+	return meta, respBody, nil
+}
+
+// Lease calls fn with the API token of a request: a key leased from Keys,
+// released with the response fn returns, or Token when Keys is nil.
+func (t *Transport) Lease(fn func(token string) (*union.Response, error)) (*union.Response, error) {
+	if t.Keys == nil {
+		return fn(t.Token)
+	}
+
+	lease, err := t.Keys.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fn(lease.Token())
+	lease.Release(resp, err)
+
+	return resp, err
+}
+
+// Decoder decodes the body of a successful response.
+type Decoder func(meta *union.Metadata, body []byte) (*union.Response, error)
+
+// Do sends a JSON request with a key of the pool and decodes the response
+// with decode. Observers are notified around the call. Decoding errors are
+// returned as *union.RequestError carrying the metadata of the response.
+func (t *Transport) Do(opts *union.Request, call *observe.Call, decode Decoder) (*union.Response, error) {
+	return t.Lease(func(token string) (*union.Response, error) {
+		ctx := observe.Begin(RequestContext(opts), t.Observer, call)
+		start := time.Now()
+
+		var resp *union.Response
+		meta, respBody, err := t.Post(ctx, call, token, "application/json", call.Body)
+		if err == nil {
+			if resp, err = decode(meta, respBody); err != nil {
+				err = &union.RequestError{Err: err, Metadata: meta}
+			}
+		}
+		observe.Finish(ctx, t.Observer, call, observe.NewResult(resp, respBody, err, time.Since(start)))
+
+		return resp, err
+	})
+}
+
+// Stream sends a streaming JSON request with a key of the pool and wraps
+// the response body with newStream. The key is released when the stream
+// is closed. Non-2xx responses are returned as *union.APIError.
+func (t *Transport) Stream(opts *union.Request, call *observe.Call, newStream func(io.ReadCloser) union.Stream) (union.Stream, error) {
+	if t.Keys == nil {
+		s, _, err := t.openStream(opts, call, t.Token, newStream)
+		return s, err
+	}
+
+	lease, err := t.Keys.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	s, meta, err := t.openStream(opts, call, lease.Token(), newStream)
+	if err != nil {
+		lease.Release(nil, err)
+		return nil, err
+	}
+
+	return lease.Stream(s, meta), nil
+}
+
+func (t *Transport) openStream(opts *union.Request, call *observe.Call, token string, newStream func(io.ReadCloser) union.Stream) (union.Stream, *union.Metadata, error) {
+	ctx := observe.Begin(RequestContext(opts), t.Observer, call)
+	start := time.Now()
+	resp, err := t.Send(ctx, call, token, "application/json", call.Body)
+	if err != nil {
+		err = &union.RequestError{Err: err, Metadata: &union.Metadata{Latency: time.Since(start)}}
+		observe.Finish(ctx, t.Observer, call, observe.NewResult(nil, nil, err, time.Since(start)))
+		return nil, nil, err
+	}
+
+	meta := union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			err = &union.RequestError{Err: fmt.Errorf("read response: %w", err), Metadata: meta}
+		} else {
+			err = &union.APIError{
+				StatusCode: resp.StatusCode,
+				Body:       respBody,
+				Metadata:   meta,
+			}
+		}
+		observe.Finish(ctx, t.Observer, call, observe.NewResult(nil, respBody, err, time.Since(start)))
+		return nil, nil, err
+	}
+
+	return observe.Stream(ctx, t.Observer, call, start, meta, newStream(resp.Body)), meta, nil
+}
+
+// RequestContext returns the context of opts, context.Background() when it
+// has none.
+func RequestContext(opts *union.Request) context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
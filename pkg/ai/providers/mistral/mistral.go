@@ -0,0 +1,162 @@
+package mistral
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/muraduiurie/gpt/pkg/ai/cache"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
+	mstypes "github.com/muraduiurie/gpt/pkg/ai/types/mistral"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func init() {
+	cache.RegisterResponse(func() union.Responser { return &mstypes.TextInputResponse{} })
+}
+
+const (
+	// ProviderName identifies this provider in responses and routing attempts.
+	ProviderName = "mistral"
+	// DefaultTextInputEndpoint is used when TextInputEndpoint is empty.
+	DefaultTextInputEndpoint = "https://api.mistral.ai/v1/chat/completions"
+	// DefaultFIMEndpoint is used when FIMEndpoint is empty.
+	DefaultFIMEndpoint = "https://api.mistral.ai/v1/fim/completions"
+)
+
+// Client sends chat completions (mstypes.TextInputRequest) and
+// fill-in-the-middle completions (mstypes.FIMRequest) to Mistral.
+type Client struct {
+	ApiToken          string
+	TextInputEndpoint string
+	FIMEndpoint       string
+	// Keys, when set, supplies the API token of each request instead of
+	// ApiToken.
+	Keys *keypool.Pool
+	// HTTPClient is used to send requests. Defaults to a client with a
+	// 300 second timeout.
+	HTTPClient *http.Client
+	// Observer is notified around every request, e.g. for logging.
+	Observer observe.Observer
+}
+
+// Provider returns the provider name, ProviderName.
+func (c *Client) Provider() string {
+	return ProviderName
+}
+
+// Endpoint returns the chat completions endpoint.
+func (c *Client) Endpoint() string {
+	if c.TextInputEndpoint == "" {
+		return DefaultTextInputEndpoint
+	}
+	return c.TextInputEndpoint
+}
+
+// DefaultModel returns the model requests without one are sent to:
+// Codestral for fill-in-the-middle requests, Mistral Small otherwise.
+func (c *Client) DefaultModel(opts *union.Request) string {
+	if _, ok := opts.TextRequest.(*mstypes.FIMRequest); ok {
+		return string(mstypes.MistralAIModelCodestral)
+	}
+	return string(mstypes.MistralAIModelSmall)
+}
+
+func (c *Client) fimEndpoint() string {
+	if c.FIMEndpoint == "" {
+		return DefaultFIMEndpoint
+	}
+	return c.FIMEndpoint
+}
+
+// AskAI sends a chat or a FIM request, depending on the request type, and
+// returns the parsed response. An error is returned for invalid input,
+// network issues, or unexpected HTTP status codes.
+func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
+	req, err := c.request(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := req.marshal(false)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(req.model), req.endpoint, false, opts, body)
+	return c.wire().Chat(opts, call, &mstypes.TextInputResponse{})
+}
+
+// request is a validated chat or FIM request and the endpoint it goes to.
+type request struct {
+	chat     *mstypes.TextInputRequest
+	fim      *mstypes.FIMRequest
+	model    mstypes.MistralAIModel
+	endpoint string
+}
+
+// marshal returns the body of the request, with streaming enabled or not.
+func (r *request) marshal(stream bool) ([]byte, error) {
+	if r.fim != nil {
+		fim := *r.fim
+		fim.Stream = stream
+		return fim.Marshal()
+	}
+	chat := *r.chat
+	chat.Stream = stream
+	return chat.Marshal()
+}
+
+// request returns the validated Mistral request of opts, with defaults
+// filled in.
+func (c *Client) request(opts *union.Request) (*request, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+	requester := opts.TextRequest
+	if requester == nil && opts.Prompt != nil {
+		requester = fromPrompt(opts.Prompt)
+	}
+
+	switch r := requester.(type) {
+	case *mstypes.TextInputRequest:
+		if r.Model == "" {
+			r.Model = mstypes.MistralAIModel(c.DefaultModel(opts))
+		}
+		if len(r.Messages) == 0 {
+			return nil, errors.New("messages is required")
+		}
+		for i, m := range r.Messages {
+			if m.Role == "" {
+				r.Messages[i].Role = mstypes.MistralAIRoleUser
+			}
+			if m.Content == "" && len(m.ToolCalls) == 0 {
+				return nil, errors.New("content in message is required")
+			}
+		}
+		return &request{chat: r, model: r.Model, endpoint: c.Endpoint()}, nil
+	case *mstypes.FIMRequest:
+		if r.Model == "" {
+			r.Model = mstypes.MistralAIModel(c.DefaultModel(opts))
+		}
+		if r.Prompt == "" {
+			return nil, errors.New("prompt is required")
+		}
+		return &request{fim: r, model: r.Model, endpoint: c.fimEndpoint()}, nil
+	default:
+		return nil, fmt.Errorf("*mstypes.TextInputRequest or *mstypes.FIMRequest type conversion failed")
+	}
+}
+
+// wire returns the transport requests are sent with.
+func (c *Client) wire() *openaiwire.Transport {
+	return &openaiwire.Transport{
+		Provider:   ProviderName,
+		Token:      c.ApiToken,
+		Keys:       c.Keys,
+		HTTPClient: c.HTTPClient,
+		Observer:   c.Observer,
+	}
+}
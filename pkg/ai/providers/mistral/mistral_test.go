@@ -0,0 +1,172 @@
+package mistral_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/mistral"
+	mstypes "github.com/muraduiurie/gpt/pkg/ai/types/mistral"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func newClient(srv *aitest.Server) *mistral.Client {
+	return &mistral.Client{ApiToken: aitest.DefaultAPIKey, TextInputEndpoint: srv.Endpoint()}
+}
+
+func request(input string) *union.Request {
+	return &union.Request{TextRequest: &mstypes.TextInputRequest{
+		Messages: []mstypes.TextInputRequestMessage{{Content: input}},
+	}}
+}
+
+func fimRequest() *union.Request {
+	return &union.Request{TextRequest: &mstypes.FIMRequest{
+		Prompt: "func add(a, b int) int {",
+		Suffix: "}",
+	}}
+}
+
+func drain(t *testing.T, s union.Stream) string {
+	t.Helper()
+	defer s.Close()
+
+	var text strings.Builder
+	for {
+		ev, err := s.Recv()
+		if err == io.EOF {
+			return text.String()
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		text.WriteString(ev.Delta)
+	}
+}
+
+func TestAskAI(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Bonjour" }
+
+	resp, err := newClient(srv).AskAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "Bonjour" || resp.Provider != mistral.ProviderName {
+		t.Errorf("response = %q from %q", resp.Output, resp.Provider)
+	}
+	if resp.Usage == nil || resp.Usage.OutputTokens == 0 {
+		t.Errorf("Usage = %+v, want output tokens", resp.Usage)
+	}
+
+	req := srv.Requests()[0]
+	if got := req.Header.Get("Authorization"); got != "Bearer "+aitest.DefaultAPIKey {
+		t.Errorf("Authorization = %q", got)
+	}
+	body := string(req.Body)
+	if !strings.Contains(body, `"model":"mistral-small-latest"`) || !strings.Contains(body, `"role":"user"`) {
+		t.Errorf("body %s lacks the default model or role", body)
+	}
+}
+
+func TestStreamAI(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Bonjour, comment allez-vous ?" }
+
+	s, err := newClient(srv).StreamAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	if got := drain(t, s); got != "Bonjour, comment allez-vous ?" {
+		t.Errorf("streamed %q", got)
+	}
+}
+
+func TestAskAIErrorStatus(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+	srv.FailNext(http.StatusTooManyRequests, 1)
+
+	_, err := newClient(srv).AskAI(request("Say hello"))
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want a 429 *union.APIError", err)
+	}
+}
+
+// newFIMServer starts a server for `/v1/fim/completions` answering with
+// completion, and returns the request bodies it receives.
+func newFIMServer(t *testing.T, completion string) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/fim/completions" {
+			t.Errorf("path = %s, want /v1/fim/completions", r.URL.Path)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+
+		if body["stream"] != true {
+			fmt.Fprintf(w, `{"id":"fim-1","object":"chat.completion","model":"codestral-latest",
+				"choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}],
+				"usage":{"prompt_tokens":8,"completion_tokens":4,"total_tokens":12}}`, completion)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, word := range strings.SplitAfter(completion, " ") {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", word)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":8,\"completion_tokens\":4,\"total_tokens\":12}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func TestFIM(t *testing.T) {
+	srv, bodies := newFIMServer(t, "\treturn a + b\n")
+	c := &mistral.Client{ApiToken: aitest.DefaultAPIKey, FIMEndpoint: srv.URL + "/v1/fim/completions"}
+
+	resp, err := c.AskAI(fimRequest())
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "\treturn a + b\n" || resp.Usage.TotalTokens != 12 {
+		t.Errorf("response = %q, usage %+v", resp.Output, resp.Usage)
+	}
+
+	body := (*bodies)[0]
+	if body["model"] != string(mstypes.MistralAIModelCodestral) || body["prompt"] != "func add(a, b int) int {" || body["suffix"] != "}" {
+		t.Errorf("body = %v, want Codestral with the prompt and suffix", body)
+	}
+	if _, ok := body["messages"]; ok {
+		t.Errorf("body = %v carries chat messages", body)
+	}
+}
+
+func TestFIMStream(t *testing.T) {
+	srv, bodies := newFIMServer(t, "return a + b")
+	c := &mistral.Client{ApiToken: aitest.DefaultAPIKey, FIMEndpoint: srv.URL + "/v1/fim/completions"}
+
+	s, err := c.StreamAI(fimRequest())
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	if got := drain(t, s); got != "return a + b" {
+		t.Errorf("streamed %q", got)
+	}
+	if (*bodies)[0]["stream"] != true {
+		t.Errorf("body = %v, want stream", (*bodies)[0])
+	}
+}
@@ -0,0 +1,20 @@
+package mistral
+
+import (
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
+	mstypes "github.com/muraduiurie/gpt/pkg/ai/types/mistral"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// fromPrompt translates a provider-neutral prompt into a chat completions
+// request. The system prompt becomes a leading system message.
+func fromPrompt(p *union.Prompt) *mstypes.TextInputRequest {
+	return &mstypes.TextInputRequest{
+		Model:       mstypes.MistralAIModel(p.Model),
+		Temperature: p.Temperature,
+		MaxTokens:   openaiwire.MaxTokens(p),
+		Messages: openaiwire.PromptMessages(p, func(role, content string) mstypes.TextInputRequestMessage {
+			return mstypes.TextInputRequestMessage{Role: mstypes.MistralAIRole(role), Content: content}
+		}),
+	}
+}
@@ -0,0 +1,25 @@
+package mistral
+
+import (
+	"fmt"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// StreamAI sends a chat or a FIM request with streaming enabled and returns
+// the answer as a stream of text deltas. The last chunk carries the usage.
+func (c *Client) StreamAI(opts *union.Request) (union.Stream, error) {
+	req, err := c.request(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := req.marshal(true)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, string(req.model), req.endpoint, true, opts, body)
+	return c.wire().StreamChat(opts, call)
+}
@@ -0,0 +1,59 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	oltypes "github.com/muraduiurie/gpt/pkg/ai/types/ollama"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// DefaultEmbedLimits are the limits of one `/api/embed` request. A local
+// server embeds one batch at a time, so a single request is in flight.
+var DefaultEmbedLimits = embed.Limits{
+	MaxInputs:   256,
+	MaxTokens:   100000,
+	Concurrency: 1,
+}
+
+// EmbedAI returns the embeddings of the inputs from `/api/embed`. Inputs
+// above the limits of one request are split into batches; the vectors are
+// returned in input order and the usage is summed. Requests without a
+// model use EmbeddingModel, or Model.
+func (c *Client) EmbedAI(opts *union.EmbedRequest) (*union.EmbedResponse, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+	if len(opts.Input) == 0 {
+		return nil, errors.New("input is required")
+	}
+
+	return embed.Run(opts.Context, opts.Input, c.EmbedLimits.Or(DefaultEmbedLimits), func(ctx context.Context, batch []string) (*union.EmbedResponse, error) {
+		req := &oltypes.EmbedRequest{
+			Model: opts.Model,
+			Input: batch,
+		}
+		if req.Model == "" {
+			req.Model = c.EmbeddingModel
+		}
+		if opts.Dimensions > 0 {
+			dimensions := opts.Dimensions
+			req.Dimensions = &dimensions
+		}
+
+		resp, err := c.embed(ctx, req, opts.Header)
+		if err != nil {
+			return nil, err
+		}
+		return &union.EmbedResponse{
+			Embeddings: resp.Embeddings,
+			Model:      resp.Model,
+			Provider:   ProviderName,
+			Usage: &union.Usage{
+				InputTokens: resp.PromptEvalCount,
+				TotalTokens: resp.PromptEvalCount,
+			},
+		}, nil
+	})
+}
@@ -0,0 +1,71 @@
+package ollama_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai"
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/ollama"
+	oltypes "github.com/muraduiurie/gpt/pkg/ai/types/ollama"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+var _ ai.Embedder = (*ollama.Client)(nil)
+
+func TestEmbedAI(t *testing.T) {
+	var requests []oltypes.EmbedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("path = %s, want /api/embed", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Trace"); got != "abc" {
+			t.Errorf("X-Trace = %q, want abc", got)
+		}
+		var req oltypes.EmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		resp := oltypes.EmbedResponse{Model: req.Model, PromptEvalCount: 3 * len(req.Input)}
+		for _, in := range req.Input {
+			resp.Embeddings = append(resp.Embeddings, []float32{float32(len(in))})
+		}
+		_ = json.NewEncoder(w).Encode(&resp)
+	}))
+	defer srv.Close()
+
+	c := &ollama.Client{
+		BaseURL:        srv.URL,
+		Model:          "llama3.2",
+		EmbeddingModel: "nomic-embed-text",
+		EmbedLimits:    embed.Limits{MaxInputs: 2, Concurrency: 1},
+	}
+	resp, err := c.EmbedAI(&union.EmbedRequest{
+		Input:      []string{"a", "bb", "ccc"},
+		Dimensions: 64,
+		Header:     http.Header{"X-Trace": {"abc"}},
+	})
+	if err != nil {
+		t.Fatalf("EmbedAI: %v", err)
+	}
+
+	if got := fmt.Sprint(resp.Embeddings); got != "[[1] [2] [3]]" {
+		t.Errorf("Embeddings = %s, want the vectors in input order", got)
+	}
+	if resp.Usage.InputTokens != 9 || resp.Provider != ollama.ProviderName || resp.Model != "nomic-embed-text" {
+		t.Errorf("response = %+v, usage %+v", resp, resp.Usage)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(requests))
+	}
+	for _, req := range requests {
+		if req.Model != "nomic-embed-text" || req.Dimensions == nil || *req.Dimensions != 64 {
+			t.Errorf("request = %+v", req)
+		}
+	}
+}
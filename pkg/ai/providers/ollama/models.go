@@ -0,0 +1,135 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	oltypes "github.com/muraduiurie/gpt/pkg/ai/types/ollama"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// ListModels returns the models available locally, from `GET /api/tags`.
+func (c *Client) ListModels(ctx context.Context) ([]oltypes.ModelInfo, error) {
+	var list oltypes.ListResponse
+	if err := c.api(ctx, "/api/tags", nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Models, nil
+}
+
+// Show returns the metadata of a local model, from `/api/show`.
+func (c *Client) Show(ctx context.Context, model string) (*oltypes.ShowResponse, error) {
+	if model == "" {
+		return nil, errors.New("model is required")
+	}
+	var show oltypes.ShowResponse
+	if err := c.api(ctx, "/api/show", nil, &oltypes.ShowRequest{Model: model}, &show); err != nil {
+		return nil, err
+	}
+	return &show, nil
+}
+
+// Embed returns the embeddings of the inputs, from `/api/embed`. Requests
+// without a model use Client.Model.
+func (c *Client) Embed(ctx context.Context, r *oltypes.EmbedRequest) (*oltypes.EmbedResponse, error) {
+	return c.embed(ctx, r, nil)
+}
+
+// embed sends an `/api/embed` request with the extra headers.
+func (c *Client) embed(ctx context.Context, r *oltypes.EmbedRequest, header http.Header) (*oltypes.EmbedResponse, error) {
+	if r == nil {
+		return nil, errors.New("nil request")
+	}
+	req := *r
+	if req.Model == "" {
+		req.Model = c.Model
+	}
+	if req.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	if req.KeepAlive == nil {
+		req.KeepAlive = c.KeepAlive
+	}
+	if len(req.Input) == 0 {
+		return nil, errors.New("input is required")
+	}
+
+	var embed oltypes.EmbedResponse
+	if err := c.api(ctx, "/api/embed", header, &req, &embed); err != nil {
+		return nil, err
+	}
+	return &embed, nil
+}
+
+// Pull downloads a model from the registry with `/api/pull`, calling
+// progress, if not nil, with every status update. It returns once the
+// model is available. Without Client.HTTPClient, no timeout applies; ctx
+// bounds the download.
+func (c *Client) Pull(ctx context.Context, model string, progress func(oltypes.PullProgress)) error {
+	if model == "" {
+		return errors.New("model is required")
+	}
+	body, err := json.Marshal(&oltypes.PullRequest{Model: model, Stream: true})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.url("/api/pull"), nil, body)
+	if err != nil {
+		return err
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		return &union.APIError{
+			StatusCode: resp.StatusCode,
+			Body:       respBody,
+			Metadata:   union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start)),
+		}
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lines.Scan() {
+		if len(lines.Bytes()) == 0 {
+			continue
+		}
+		var p oltypes.PullProgress
+		if err = json.Unmarshal(lines.Bytes(), &p); err != nil {
+			return fmt.Errorf("decode pull progress: %w", err)
+		}
+		if p.Error != "" {
+			return fmt.Errorf("pull %s: %s", model, p.Error)
+		}
+		if progress != nil {
+			progress(p)
+		}
+		if p.Status == "success" {
+			return nil
+		}
+	}
+	if err = lines.Err(); err != nil {
+		return fmt.Errorf("read pull progress: %w", err)
+	}
+	return io.ErrUnexpectedEOF
+}
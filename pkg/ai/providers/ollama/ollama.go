@@ -0,0 +1,296 @@
+// Package ollama is a client for the native API of a local Ollama server:
+// chat, model listing, pulling and metadata, and embeddings.
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/cache"
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	oltypes "github.com/muraduiurie/gpt/pkg/ai/types/ollama"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func init() {
+	cache.RegisterResponse(func() union.Responser { return &oltypes.TextInputResponse{} })
+}
+
+const (
+	// ProviderName identifies this provider in responses and routing attempts.
+	ProviderName = "ollama"
+	// DefaultBaseURL is used when BaseURL is empty.
+	DefaultBaseURL = "http://localhost:11434"
+)
+
+type Client struct {
+	// BaseURL is the server root. Defaults to DefaultBaseURL.
+	BaseURL string
+	// TextInputEndpoint overrides the chat endpoint, BaseURL + "/api/chat".
+	TextInputEndpoint string
+	// ApiToken is sent as a Bearer token, e.g. to an authenticating proxy.
+	// A local server needs none.
+	ApiToken string
+	// Model is used for requests without a model.
+	Model string
+	// NumCtx is the context window of requests that set none. Zero keeps
+	// the model's default.
+	NumCtx int
+	// KeepAlive is the keep_alive of requests that set none.
+	KeepAlive *oltypes.Duration
+	// EmbeddingModel is used for embedding requests without a model.
+	// Defaults to Model.
+	EmbeddingModel string
+	// EmbedLimits bound the requests of one EmbedAI call. Zero fields take
+	// the values of DefaultEmbedLimits.
+	EmbedLimits embed.Limits
+	// HTTPClient is used to send requests. Defaults to a client with a
+	// 300 second timeout; Pull uses no timeout by default.
+	HTTPClient *http.Client
+	// Observer is notified around every chat request, e.g. for logging.
+	Observer observe.Observer
+}
+
+// Provider returns the provider name, ProviderName.
+func (c *Client) Provider() string {
+	return ProviderName
+}
+
+// Endpoint returns the chat endpoint requests are sent to.
+func (c *Client) Endpoint() string {
+	if c.TextInputEndpoint != "" {
+		return c.TextInputEndpoint
+	}
+	return c.url("/api/chat")
+}
+
+// DefaultModel returns the model requests without one are sent to.
+func (c *Client) DefaultModel(opts *union.Request) string {
+	return c.Model
+}
+
+func (c *Client) url(path string) string {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
+
+// AskAI sends an `/api/chat` request without streaming and returns the
+// parsed response. An error is returned for invalid input, network issues,
+// or unexpected HTTP status codes.
+func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	wire := *textRequest
+	wire.Stream = false
+	body, err := wire.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, textRequest.Model, c.Endpoint(), false, opts, body)
+	ctx := observe.Begin(requestContext(opts), c.Observer, call)
+	start := time.Now()
+	resp, respBody, err := c.do(ctx, call, body)
+	observe.Finish(ctx, c.Observer, call, observe.NewResult(resp, respBody, err, time.Since(start)))
+
+	return resp, err
+}
+
+// do sends the request and decodes the response. The raw response body is
+// returned for observers.
+func (c *Client) do(ctx context.Context, call *observe.Call, body []byte) (*union.Response, []byte, error) {
+	start := time.Now()
+	resp, err := c.send(ctx, http.MethodPost, call.Endpoint, call.Header, body)
+	if err != nil {
+		return nil, nil, &union.RequestError{Err: err, Metadata: &union.Metadata{Latency: time.Since(start)}}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	meta := union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start))
+	if err != nil {
+		return nil, nil, &union.RequestError{Err: fmt.Errorf("read response: %w", err), Metadata: meta}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, respBody, &union.APIError{
+			StatusCode: resp.StatusCode,
+			Body:       respBody,
+			Metadata:   meta,
+		}
+	}
+
+	var textResponse oltypes.TextInputResponse
+	err = textResponse.Unmarshal(respBody)
+	if err != nil {
+		return nil, respBody, &union.RequestError{Err: fmt.Errorf("failed to unmarshal response: %w", err), Metadata: meta}
+	}
+
+	return &union.Response{
+		TextResponse: &textResponse,
+		Metadata:     meta,
+		Provider:     ProviderName,
+		Output:       textResponse.Message.Content,
+		FinishReason: textResponse.DoneReason,
+		Usage:        usage(&textResponse),
+	}, respBody, nil
+}
+
+// textRequest returns the validated Ollama request of opts, with the
+// client defaults filled in.
+func (c *Client) textRequest(opts *union.Request) (*oltypes.TextInputRequest, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+	requester := opts.TextRequest
+	if requester == nil && opts.Prompt != nil {
+		requester = fromPrompt(opts.Prompt)
+	}
+	textRequest, ok := requester.(*oltypes.TextInputRequest)
+	if !ok {
+		return nil, fmt.Errorf("*oltypes.TextInputRequest type conversion failed")
+	}
+
+	if textRequest.Model == "" {
+		if c.Model == "" {
+			return nil, errors.New("model is required")
+		}
+		textRequest.Model = c.Model
+	}
+	if c.NumCtx > 0 && (textRequest.Options == nil || textRequest.Options.NumCtx == nil) {
+		if textRequest.Options == nil {
+			textRequest.Options = &oltypes.Options{}
+		}
+		numCtx := c.NumCtx
+		textRequest.Options.NumCtx = &numCtx
+	}
+	if textRequest.KeepAlive == nil {
+		textRequest.KeepAlive = c.KeepAlive
+	}
+	if len(textRequest.Messages) == 0 {
+		return nil, errors.New("messages is required")
+	}
+	for i, m := range textRequest.Messages {
+		if m.Role == "" {
+			textRequest.Messages[i].Role = oltypes.OllamaAIRoleUser
+		}
+		if m.Content == "" && len(m.Images) == 0 && len(m.ToolCalls) == 0 {
+			return nil, errors.New("content in message is required")
+		}
+	}
+
+	return textRequest, nil
+}
+
+// send sends body to url. The extra headers are applied last.
+func (c *Client) send(ctx context.Context, method, url string, header http.Header, body []byte) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, url, header, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+
+	return resp, nil
+}
+
+// newRequest returns a request of body to url, with the JSON content type
+// and the API token. The extra headers are applied last.
+func (c *Client) newRequest(ctx context.Context, method, url string, header http.Header, body []byte) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.ApiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.ApiToken)
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+
+	return req, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return &http.Client{Timeout: 300 * time.Second}
+}
+
+// api sends in as JSON to an API path and decodes the response into out.
+// A nil in sends a GET request. The extra headers are applied last.
+func (c *Client) api(ctx context.Context, path string, header http.Header, in, out interface{}) error {
+	method := http.MethodGet
+	var body []byte
+	if in != nil {
+		method = http.MethodPost
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = b
+	}
+
+	start := time.Now()
+	resp, err := c.send(ctx, method, c.url(path), header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &union.APIError{
+			StatusCode: resp.StatusCode,
+			Body:       respBody,
+			Metadata:   union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start)),
+		}
+	}
+
+	if err = json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+func requestContext(opts *union.Request) context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+func usage(r *oltypes.TextInputResponse) *union.Usage {
+	return &union.Usage{
+		InputTokens:  r.PromptEvalCount,
+		OutputTokens: r.EvalCount,
+		TotalTokens:  r.PromptEvalCount + r.EvalCount,
+	}
+}
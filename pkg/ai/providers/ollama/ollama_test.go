@@ -0,0 +1,226 @@
+package ollama_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/providers/ollama"
+	oltypes "github.com/muraduiurie/gpt/pkg/ai/types/ollama"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// newServer starts a server answering every request with the lines, one
+// JSON object each, and returns the request bodies it receives.
+func newServer(t *testing.T, path string, lines ...string) (*httptest.Server, *[]map[string]json.RawMessage) {
+	t.Helper()
+	var bodies []map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			t.Errorf("path = %s, want %s", r.URL.Path, path)
+		}
+		var body map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func request(input string) *union.Request {
+	return &union.Request{TextRequest: &oltypes.TextInputRequest{
+		Messages: []oltypes.TextInputRequestMessage{{Content: input}},
+	}}
+}
+
+func TestAskAI(t *testing.T) {
+	srv, bodies := newServer(t, "/api/chat",
+		`{"model":"llama3.2","message":{"role":"assistant","content":"Hello there"},"done":true,"done_reason":"stop","prompt_eval_count":3,"eval_count":2}`)
+	keepAlive := oltypes.Duration(10 * time.Minute)
+	c := &ollama.Client{BaseURL: srv.URL, Model: "llama3.2", NumCtx: 8192, KeepAlive: &keepAlive}
+
+	resp, err := c.AskAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "Hello there" || resp.FinishReason != "stop" || resp.Provider != ollama.ProviderName {
+		t.Errorf("response = %q, %q from %q", resp.Output, resp.FinishReason, resp.Provider)
+	}
+	if u := resp.Usage; u.InputTokens != 3 || u.OutputTokens != 2 || u.TotalTokens != 5 {
+		t.Errorf("Usage = %+v", u)
+	}
+
+	body := (*bodies)[0]
+	for key, want := range map[string]string{
+		"model":      `"llama3.2"`,
+		"stream":     "false",
+		"keep_alive": `"10m0s"`,
+		"options":    `{"num_ctx":8192}`,
+	} {
+		if got := string(body[key]); got != want {
+			t.Errorf("%s = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestAskAIKeepsRequestKeepAlive(t *testing.T) {
+	srv, bodies := newServer(t, "/api/chat", `{"message":{"content":"ok"},"done":true}`)
+	clientKeepAlive := oltypes.Duration(10 * time.Minute)
+	c := &ollama.Client{BaseURL: srv.URL, Model: "llama3.2", KeepAlive: &clientKeepAlive}
+
+	unload := oltypes.Duration(0)
+	if _, err := c.AskAI(&union.Request{TextRequest: &oltypes.TextInputRequest{
+		Messages:  []oltypes.TextInputRequestMessage{{Content: "Say hello"}},
+		KeepAlive: &unload,
+	}}); err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if got := string((*bodies)[0]["keep_alive"]); got != `"0s"` {
+		t.Errorf("keep_alive = %s, want the request's 0s", got)
+	}
+}
+
+func TestStreamAI(t *testing.T) {
+	srv, bodies := newServer(t, "/api/chat",
+		`{"message":{"role":"assistant","content":"Hello"},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":false}`,
+		`{"message":{"role":"assistant","content":" there"},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":3,"eval_count":2}`)
+	c := &ollama.Client{BaseURL: srv.URL, Model: "llama3.2"}
+
+	s, err := c.StreamAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	defer s.Close()
+
+	var (
+		text strings.Builder
+		last *union.StreamEvent
+	)
+	for {
+		ev, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		text.WriteString(ev.Delta)
+		last = ev
+	}
+	if text.String() != "Hello there" {
+		t.Errorf("streamed %q", text.String())
+	}
+	if last.FinishReason != "stop" || last.Usage == nil || last.Usage.TotalTokens != 5 {
+		t.Errorf("last event = %+v, want the done reason and usage", last)
+	}
+	if got := string((*bodies)[0]["stream"]); got != "true" {
+		t.Errorf("stream = %s, want true", got)
+	}
+}
+
+func TestStreamAIError(t *testing.T) {
+	srv, _ := newServer(t, "/api/chat",
+		`{"message":{"content":"Hel"},"done":false}`,
+		`{"error":"model runner has unexpectedly stopped"}`)
+	c := &ollama.Client{BaseURL: srv.URL, Model: "llama3.2"}
+
+	s, err := c.StreamAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.Recv(); err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if _, err := s.Recv(); err == nil || !strings.Contains(err.Error(), "unexpectedly stopped") {
+		t.Errorf("err = %v, want the stream error", err)
+	}
+}
+
+func TestAskAIErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model \"llama9\" not found, try pulling it first"}`)
+	}))
+	defer srv.Close()
+
+	_, err := (&ollama.Client{BaseURL: srv.URL, Model: "llama9"}).AskAI(request("Say hello"))
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("err = %v, want a 404 *union.APIError", err)
+	}
+}
+
+func TestPull(t *testing.T) {
+	srv, bodies := newServer(t, "/api/pull",
+		`{"status":"pulling manifest"}`,
+		`{"status":"pulling dde5aa3fc5ff","digest":"sha256:dde5aa3fc5ff","total":2000,"completed":1000}`,
+		`{"status":"pulling dde5aa3fc5ff","digest":"sha256:dde5aa3fc5ff","total":2000,"completed":2000}`,
+		`{"status":"verifying sha256 digest"}`,
+		`{"status":"success"}`)
+	c := &ollama.Client{BaseURL: srv.URL}
+
+	var progress []oltypes.PullProgress
+	if err := c.Pull(t.Context(), "llama3.2", func(p oltypes.PullProgress) {
+		progress = append(progress, p)
+	}); err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+
+	if len(progress) != 5 || progress[2].Completed != 2000 || progress[4].Status != "success" {
+		t.Errorf("progress = %+v", progress)
+	}
+	body := (*bodies)[0]
+	if string(body["model"]) != `"llama3.2"` || string(body["stream"]) != "true" {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestPullError(t *testing.T) {
+	for name, lines := range map[string][]string{
+		"error":     {`{"status":"pulling manifest"}`, `{"error":"pull model manifest: file does not exist"}`},
+		"truncated": {`{"status":"pulling manifest"}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv, _ := newServer(t, "/api/pull", lines...)
+			if err := (&ollama.Client{BaseURL: srv.URL}).Pull(t.Context(), "llama9", nil); err == nil {
+				t.Error("Pull succeeded")
+			}
+		})
+	}
+}
+
+func TestListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/tags" {
+			t.Errorf("request = %s %s, want GET /api/tags", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"models":[{"name":"llama3.2:latest","model":"llama3.2:latest","size":2019393189}]}`)
+	}))
+	defer srv.Close()
+
+	models, err := (&ollama.Client{BaseURL: srv.URL}).ListModels(t.Context())
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if len(models) != 1 || models[0].Name != "llama3.2:latest" || models[0].Size != 2019393189 {
+		t.Errorf("models = %+v", models)
+	}
+}
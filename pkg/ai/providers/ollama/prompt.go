@@ -0,0 +1,36 @@
+package ollama
+
+import (
+	oltypes "github.com/muraduiurie/gpt/pkg/ai/types/ollama"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// fromPrompt translates a provider-neutral prompt into a chat request. The
+// system prompt becomes a leading system message; the sampling settings go
+// in the options.
+func fromPrompt(p *union.Prompt) *oltypes.TextInputRequest {
+	r := &oltypes.TextInputRequest{
+		Model: p.Model,
+	}
+	if p.Temperature != nil || p.MaxTokens > 0 {
+		r.Options = &oltypes.Options{Temperature: p.Temperature}
+		if p.MaxTokens > 0 {
+			maxTokens := p.MaxTokens
+			r.Options.NumPredict = &maxTokens
+		}
+	}
+	if p.System != "" {
+		r.Messages = append(r.Messages, oltypes.TextInputRequestMessage{
+			Role:    oltypes.OllamaAIRoleSystem,
+			Content: p.System,
+		})
+	}
+	for _, m := range p.Messages {
+		r.Messages = append(r.Messages, oltypes.TextInputRequestMessage{
+			Role:    oltypes.OllamaAIRole(m.Role),
+			Content: m.Content,
+		})
+	}
+
+	return r
+}
@@ -0,0 +1,125 @@
+package ollama
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	oltypes "github.com/muraduiurie/gpt/pkg/ai/types/ollama"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// StreamAI sends an `/api/chat` request with streaming enabled and returns
+// the answer as a stream of text deltas. Ollama streams newline-delimited
+// JSON; the last chunk carries the done reason and the token counts.
+func (c *Client) StreamAI(opts *union.Request) (union.Stream, error) {
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	wire := *textRequest
+	wire.Stream = true
+	body, err := wire.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(ProviderName, textRequest.Model, c.Endpoint(), true, opts, body)
+	ctx := observe.Begin(requestContext(opts), c.Observer, call)
+	start := time.Now()
+	resp, err := c.send(ctx, http.MethodPost, call.Endpoint, call.Header, body)
+	if err != nil {
+		err = &union.RequestError{Err: err, Metadata: &union.Metadata{Latency: time.Since(start)}}
+		observe.Finish(ctx, c.Observer, call, observe.NewResult(nil, nil, err, time.Since(start)))
+		return nil, err
+	}
+
+	meta := union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			err = &union.RequestError{Err: fmt.Errorf("read response: %w", err), Metadata: meta}
+		} else {
+			err = &union.APIError{
+				StatusCode: resp.StatusCode,
+				Body:       respBody,
+				Metadata:   meta,
+			}
+		}
+		observe.Finish(ctx, c.Observer, call, observe.NewResult(nil, respBody, err, time.Since(start)))
+		return nil, err
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	s := &stream{
+		body:  resp.Body,
+		lines: lines,
+	}
+	return observe.Stream(ctx, c.Observer, call, start, meta, s), nil
+}
+
+type streamChunk struct {
+	oltypes.TextInputResponse
+	Error string `json:"error"`
+}
+
+type stream struct {
+	body  io.ReadCloser
+	lines *bufio.Scanner
+	done  bool
+}
+
+func (s *stream) Recv() (*union.StreamEvent, error) {
+	for !s.done {
+		if !s.lines.Scan() {
+			if err := s.lines.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.ErrUnexpectedEOF
+		}
+		line := s.lines.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			s.done = true
+			return nil, errors.New("stream error: " + chunk.Error)
+		}
+
+		raw := append([]byte(nil), line...)
+		if chunk.Done {
+			s.done = true
+			return &union.StreamEvent{
+				Delta:        chunk.Message.Content,
+				FinishReason: chunk.DoneReason,
+				Usage:        usage(&chunk.TextInputResponse),
+				Raw:          raw,
+			}, nil
+		}
+		if chunk.Message.Content == "" {
+			continue
+		}
+
+		return &union.StreamEvent{Delta: chunk.Message.Content, Raw: raw}, nil
+	}
+
+	return nil, io.EOF
+}
+
+func (s *stream) Close() error {
+	s.done = true
+	return s.body.Close()
+}
@@ -0,0 +1,32 @@
+package openaicompat
+
+import (
+	"strings"
+
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// DefaultEmbedLimits are conservative limits for one `/embeddings` request;
+// local servers often accept far fewer inputs than OpenAI.
+var DefaultEmbedLimits = embed.Limits{
+	MaxInputs:   256,
+	MaxTokens:   100000,
+	Concurrency: 2,
+}
+
+// EmbedAI returns the embeddings of the inputs from the `/embeddings`
+// endpoint of the server. Inputs above the limits of one request are split
+// into batches sent concurrently; the vectors are returned in input order
+// and the usage is summed. Requests without a model use EmbeddingModel.
+func (c *Client) EmbedAI(opts *union.EmbedRequest) (*union.EmbedResponse, error) {
+	return c.wire().Embed(opts, c.embeddingsEndpoint(), c.EmbeddingModel, c.EmbedLimits.Or(DefaultEmbedLimits))
+}
+
+// embeddingsEndpoint returns the endpoint embedding requests are sent to.
+func (c *Client) embeddingsEndpoint() string {
+	if c.EmbeddingsEndpoint != "" {
+		return c.EmbeddingsEndpoint
+	}
+	return strings.TrimSuffix(c.BaseURL, "/") + "/embeddings"
+}
@@ -0,0 +1,231 @@
+// Package openaicompat is a client for servers implementing OpenAI's
+// `/v1/chat/completions` API, such as Ollama, vLLM, llama.cpp server,
+// LM Studio, Groq, Together and OpenRouter.
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/cache"
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
+	octypes "github.com/muraduiurie/gpt/pkg/ai/types/openaicompat"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func init() {
+	cache.RegisterResponse(func() union.Responser { return &octypes.TextInputResponse{} })
+}
+
+// ProviderName identifies this provider in responses and routing attempts
+// unless Client.Name is set.
+const ProviderName = "openai-compatible"
+
+type Client struct {
+	// Name identifies the server in responses, routing attempts, logs and
+	// metrics, e.g. "groq". Defaults to ProviderName.
+	Name string
+	// BaseURL is the API root, e.g. "http://localhost:8080/v1". Requests go
+	// to BaseURL + "/chat/completions" unless TextInputEndpoint is set.
+	BaseURL           string
+	TextInputEndpoint string
+	// ApiToken is sent as a Bearer token. Local servers usually need none.
+	ApiToken string
+	// Keys, when set, supplies the API token of each request instead of
+	// ApiToken.
+	Keys *keypool.Pool
+	// Header is sent with every request, e.g. the `HTTP-Referer` and
+	// `X-Title` headers of OpenRouter.
+	Header http.Header
+	// Models are the models served. The first one is used for requests
+	// without a model.
+	Models []string
+	// EmbeddingsEndpoint is where EmbedAI sends requests. Defaults to
+	// BaseURL + "/embeddings".
+	EmbeddingsEndpoint string
+	// EmbeddingModel is used for embedding requests without a model.
+	EmbeddingModel string
+	// EmbedLimits bound the requests of one EmbedAI call. Zero fields take
+	// the values of DefaultEmbedLimits.
+	EmbedLimits embed.Limits
+	// TranscriptionModel is used for transcription and translation
+	// requests without a model. Defaults to "whisper-1", which many
+	// Whisper servers accept for their loaded model.
+	TranscriptionModel string
+	// HTTPClient is used to send requests. Defaults to a client with a
+	// 300 second timeout.
+	HTTPClient *http.Client
+	// Observer is notified around every request, e.g. for logging.
+	Observer observe.Observer
+}
+
+// Provider returns Name, or ProviderName when it is empty.
+func (c *Client) Provider() string {
+	if c.Name == "" {
+		return ProviderName
+	}
+	return c.Name
+}
+
+// Endpoint returns the chat completions endpoint requests are sent to.
+func (c *Client) Endpoint() string {
+	if c.TextInputEndpoint != "" {
+		return c.TextInputEndpoint
+	}
+	return strings.TrimSuffix(c.BaseURL, "/") + "/chat/completions"
+}
+
+// DefaultModel returns the model requests without one are sent to, the
+// first of Models.
+func (c *Client) DefaultModel(opts *union.Request) string {
+	if len(c.Models) == 0 {
+		return ""
+	}
+	return c.Models[0]
+}
+
+// AskAI sends a chat completions request to the server and returns the
+// parsed response. An error is returned for invalid input, network issues,
+// or unexpected HTTP status codes.
+func (c *Client) AskAI(opts *union.Request) (*union.Response, error) {
+	textRequest, err := c.textRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := textRequest.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	call := observe.NewCall(c.Provider(), textRequest.Model, c.Endpoint(), false, opts, body)
+	return c.wire().Chat(opts, call, &octypes.TextInputResponse{})
+}
+
+// ListModels returns the IDs of the models the server reports on
+// `GET /models`, relative to BaseURL.
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	if c.BaseURL == "" {
+		return nil, errors.New("base URL is required")
+	}
+
+	token := c.ApiToken
+	if c.Keys != nil {
+		lease, err := c.Keys.Acquire()
+		if err != nil {
+			return nil, err
+		}
+		defer lease.Release(nil, nil)
+		token = lease.Token()
+	}
+
+	url := strings.TrimSuffix(c.BaseURL, "/") + "/models"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if err = c.authorize(ctx, req, token); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := c.wire().HTTP().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &union.APIError{
+			StatusCode: resp.StatusCode,
+			Body:       body,
+			Metadata:   union.NewMetadata(resp.Header, resp.StatusCode, time.Since(start)),
+		}
+	}
+
+	var list octypes.ModelList
+	if err = json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	ids := make([]string, len(list.Data))
+	for i, m := range list.Data {
+		ids[i] = m.Id
+	}
+
+	return ids, nil
+}
+
+// textRequest returns the validated chat completions request of opts, with
+// defaults filled in.
+func (c *Client) textRequest(opts *union.Request) (*octypes.TextInputRequest, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+	requester := opts.TextRequest
+	if requester == nil && opts.Prompt != nil {
+		requester = fromPrompt(opts.Prompt)
+	}
+	textRequest, ok := requester.(*octypes.TextInputRequest)
+	if !ok {
+		return nil, fmt.Errorf("*octypes.TextInputRequest type conversion failed")
+	}
+
+	if textRequest.Model == "" {
+		if len(c.Models) == 0 {
+			return nil, fmt.Errorf("model is required")
+		}
+		textRequest.Model = c.Models[0]
+	}
+	if len(textRequest.Messages) == 0 {
+		return nil, fmt.Errorf("messages is required")
+	}
+	for i, m := range textRequest.Messages {
+		if m.Role == "" {
+			textRequest.Messages[i].Role = octypes.RoleUser
+		}
+		if m.Content == "" {
+			return nil, fmt.Errorf("content in message is required")
+		}
+	}
+
+	return textRequest, nil
+}
+
+// wire returns the transport requests are sent with.
+func (c *Client) wire() *openaiwire.Transport {
+	return &openaiwire.Transport{
+		Provider:   c.Provider(),
+		Token:      c.ApiToken,
+		Keys:       c.Keys,
+		HTTPClient: c.HTTPClient,
+		Observer:   c.Observer,
+		Authorize:  c.authorize,
+	}
+}
+
+// authorize sets the bearer token, if any, and the custom headers of req.
+func (c *Client) authorize(_ context.Context, req *http.Request, token string) error {
+	if req.URL.Host == "" {
+		return errors.New("base URL is required")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, vs := range c.Header {
+		req.Header[http.CanonicalHeaderKey(k)] = vs
+	}
+	return nil
+}
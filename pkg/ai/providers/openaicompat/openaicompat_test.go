@@ -0,0 +1,181 @@
+package openaicompat_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/openaicompat"
+	octypes "github.com/muraduiurie/gpt/pkg/ai/types/openaicompat"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func newClient(srv *aitest.Server) *openaicompat.Client {
+	return &openaicompat.Client{
+		Name:     "groq",
+		BaseURL:  srv.URL + "/",
+		ApiToken: aitest.DefaultAPIKey,
+		Header:   http.Header{"x-title": {"gpt tests"}},
+		Models:   []string{"llama-3.3-70b", "mixtral-8x7b"},
+	}
+}
+
+func request(input string) *union.Request {
+	return &union.Request{TextRequest: &octypes.TextInputRequest{
+		Messages: []octypes.TextInputRequestMessage{{Content: input}},
+	}}
+}
+
+func TestAskAI(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello there" }
+
+	resp, err := newClient(srv).AskAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Output != "Hello there" || resp.Provider != "groq" {
+		t.Errorf("response = %q from %q, want the client name", resp.Output, resp.Provider)
+	}
+	if resp.Usage == nil || resp.Usage.OutputTokens == 0 {
+		t.Errorf("Usage = %+v, want output tokens", resp.Usage)
+	}
+
+	req := srv.Requests()[0]
+	if req.Path != "/chat/completions" {
+		t.Errorf("path = %s, want /chat/completions under the base URL", req.Path)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer "+aitest.DefaultAPIKey {
+		t.Errorf("Authorization = %q", got)
+	}
+	if got := req.Header.Get("X-Title"); got != "gpt tests" {
+		t.Errorf("X-Title = %q, want the custom header", got)
+	}
+	if !strings.Contains(string(req.Body), `"model":"llama-3.3-70b"`) {
+		t.Errorf("body %s lacks the first model", req.Body)
+	}
+}
+
+func TestStreamAI(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello there, how are you?" }
+
+	s, err := newClient(srv).StreamAI(request("Say hello"))
+	if err != nil {
+		t.Fatalf("StreamAI: %v", err)
+	}
+	defer s.Close()
+
+	var (
+		text  strings.Builder
+		usage *union.Usage
+	)
+	for {
+		ev, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		text.WriteString(ev.Delta)
+		if ev.Usage != nil {
+			usage = ev.Usage
+		}
+	}
+	if text.String() != "Hello there, how are you?" {
+		t.Errorf("streamed %q", text.String())
+	}
+	if usage == nil || usage.OutputTokens == 0 {
+		t.Errorf("usage = %+v, want output tokens", usage)
+	}
+}
+
+func TestAskAIErrorStatus(t *testing.T) {
+	srv := aitest.NewDeepSeekServer()
+	defer srv.Close()
+
+	c := newClient(srv)
+	c.ApiToken = "wrong"
+	_, err := c.AskAI(request("Say hello"))
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want a 401 *union.APIError", err)
+	}
+}
+
+func TestAskAIWithoutModel(t *testing.T) {
+	c := &openaicompat.Client{BaseURL: "http://localhost:8080/v1"}
+	if _, err := c.AskAI(request("Say hello")); err == nil {
+		t.Error("AskAI without a model or Models succeeded")
+	}
+}
+
+func TestListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/models" {
+			t.Errorf("request = %s %s, want GET /v1/models", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"llama-3.3-70b","object":"model"},{"id":"mixtral-8x7b","object":"model"}]}`)
+	}))
+	defer srv.Close()
+
+	models, err := (&openaicompat.Client{BaseURL: srv.URL + "/v1"}).ListModels(t.Context())
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if fmt.Sprint(models) != "[llama-3.3-70b mixtral-8x7b]" {
+		t.Errorf("models = %v", models)
+	}
+}
+
+func TestEmbedAI(t *testing.T) {
+	var batches [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("path = %s, want /v1/embeddings", r.URL.Path)
+		}
+		var req octypes.EmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		batches = append(batches, req.Input)
+
+		// no index, as some servers answer
+		data := make([]map[string]interface{}, len(req.Input))
+		for i, in := range req.Input {
+			data[i] = map[string]interface{}{"embedding": []float32{float32(len(in))}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"model": req.Model,
+			"data":  data,
+			"usage": map[string]int{"prompt_tokens": len(req.Input), "total_tokens": len(req.Input)},
+		})
+	}))
+	defer srv.Close()
+
+	c := &openaicompat.Client{
+		BaseURL:        srv.URL + "/v1",
+		EmbeddingModel: "nomic-embed-text",
+		EmbedLimits:    embed.Limits{MaxInputs: 2, Concurrency: 1},
+	}
+	resp, err := c.EmbedAI(&union.EmbedRequest{Input: []string{"a", "bb", "ccc"}})
+	if err != nil {
+		t.Fatalf("EmbedAI: %v", err)
+	}
+	if fmt.Sprint(resp.Embeddings) != "[[1] [2] [3]]" || resp.Usage.InputTokens != 3 || resp.Model != "nomic-embed-text" {
+		t.Errorf("response = %+v, usage %+v", resp, resp.Usage)
+	}
+	if len(batches) != 2 {
+		t.Errorf("sent %d requests, want 2", len(batches))
+	}
+}
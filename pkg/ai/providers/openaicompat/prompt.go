@@ -0,0 +1,20 @@
+package openaicompat
+
+import (
+	"github.com/muraduiurie/gpt/pkg/ai/providers/internal/openaiwire"
+	octypes "github.com/muraduiurie/gpt/pkg/ai/types/openaicompat"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// fromPrompt translates a provider-neutral prompt into a chat completions
+// request. The system prompt becomes a leading system message.
+func fromPrompt(p *union.Prompt) *octypes.TextInputRequest {
+	return &octypes.TextInputRequest{
+		Model:       p.Model,
+		Temperature: p.Temperature,
+		MaxTokens:   openaiwire.MaxTokens(p),
+		Messages: openaiwire.PromptMessages(p, func(role, content string) octypes.TextInputRequestMessage {
+			return octypes.TextInputRequestMessage{Role: octypes.Role(role), Content: content}
+		}),
+	}
+}
@@ -0,0 +1,35 @@
+package openaicompat
+
+import (
+	"context"
+	"strings"
+
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	octypes "github.com/muraduiurie/gpt/pkg/ai/types/openaicompat"
+)
+
+// Transcribe transcribes audio with the `/audio/transcriptions` endpoint
+// of a Whisper server, relative to BaseURL, uploading r.File as a
+// multipart form. Timestamp granularities switch an unset format to
+// verbose_json.
+func (c *Client) Transcribe(ctx context.Context, r *octypes.TranscriptionRequest) (*octypes.Transcription, error) {
+	return c.wire().Transcribe(ctx, r, c.url("/audio/transcriptions"), c.transcriptionModel())
+}
+
+// Translate transcribes audio into English with the `/audio/translations`
+// endpoint of a Whisper server, relative to BaseURL.
+func (c *Client) Translate(ctx context.Context, r *octypes.TranslationRequest) (*octypes.Transcription, error) {
+	return c.wire().Translate(ctx, r, c.url("/audio/translations"), c.transcriptionModel())
+}
+
+func (c *Client) transcriptionModel() cgtypes.ChatGPTAIModel {
+	if c.TranscriptionModel != "" {
+		return cgtypes.ChatGPTAIModel(c.TranscriptionModel)
+	}
+	return cgtypes.AiModelWhisper1
+}
+
+// url returns an endpoint relative to BaseURL.
+func (c *Client) url(path string) string {
+	return strings.TrimSuffix(c.BaseURL, "/") + path
+}
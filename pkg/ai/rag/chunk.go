@@ -0,0 +1,227 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/muraduiurie/gpt/pkg/ai/tokens"
+)
+
+// Chunker splits documents into chunks of about Size tokens, consecutive
+// chunks sharing up to Overlap tokens so that a passage cut at a boundary
+// is whole in one of them. Text is cut between paragraphs where possible,
+// then between sentences, then between words. Token counts are estimates;
+// see tokens.Estimate.
+type Chunker struct {
+	// Size defaults to 512 tokens.
+	Size int
+	// Overlap defaults to 64 tokens. It is capped at half of Size.
+	Overlap int
+}
+
+// Chunk is a piece of a document.
+type Chunk struct {
+	// ID is the source and the index of the chunk, "source#index".
+	ID     string
+	Source string
+	Title  string
+	// Index is the position of the chunk in the document, from zero.
+	Index    int
+	Text     string
+	Metadata map[string]string
+}
+
+// Split returns the chunks of doc.
+func (c Chunker) Split(doc Document) []Chunk {
+	size, overlap := c.Size, c.Overlap
+	if size <= 0 {
+		size = 512
+	}
+	if overlap <= 0 {
+		overlap = 64
+	}
+	if overlap > size/2 {
+		overlap = size / 2
+	}
+
+	var (
+		chunks []Chunk
+		cur    []segment
+		n      int
+		// carried is the number of segments of cur repeated from the
+		// previous chunk
+		carried int
+	)
+	flush := func() {
+		chunks = append(chunks, Chunk{
+			ID:       fmt.Sprintf("%s#%d", doc.Source, len(chunks)),
+			Source:   doc.Source,
+			Title:    doc.Title,
+			Index:    len(chunks),
+			Text:     join(cur),
+			Metadata: doc.Metadata,
+		})
+
+		// carry the trailing segments that fit in the overlap, then the end
+		// of the segment before them that still fits
+		keep, kept := len(cur), 0
+		for keep > 0 && kept+cur[keep-1].tokens <= overlap {
+			keep--
+			kept += cur[keep].tokens
+		}
+		carry := append([]segment(nil), cur[keep:]...)
+		if keep > 0 {
+			if t, ok := tail(cur[keep-1], overlap-kept); ok {
+				carry = append([]segment{t}, carry...)
+				kept += t.tokens
+			}
+		}
+		cur = carry
+		n, carried = kept, len(cur)
+	}
+
+	for _, seg := range segments(doc.Text, size) {
+		if n+seg.tokens > size {
+			if len(cur) > carried {
+				flush()
+			}
+			// drop an overlap that leaves no room for the segment
+			if n+seg.tokens > size {
+				cur, n, carried = nil, 0, 0
+			}
+		}
+		cur = append(cur, seg)
+		n += seg.tokens
+	}
+	if len(cur) > carried {
+		flush()
+	}
+	return chunks
+}
+
+// segment is a piece of text that is not cut, with the separator that
+// precedes it in the document.
+type segment struct {
+	text   string
+	sep    string
+	tokens int
+}
+
+func join(segs []segment) string {
+	var sb strings.Builder
+	for i, s := range segs {
+		if i > 0 {
+			sb.WriteString(s.sep)
+		}
+		sb.WriteString(s.text)
+	}
+	return sb.String()
+}
+
+// segments cuts text into paragraphs, and those above size into sentences
+// and then words.
+func segments(text string, size int) []segment {
+	var out []segment
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		if t := tokens.Estimate(para); t <= size {
+			out = append(out, segment{text: para, sep: "\n\n", tokens: t})
+			continue
+		}
+
+		sep := "\n\n"
+		for _, sentence := range sentences(para) {
+			if t := tokens.Estimate(sentence); t <= size {
+				out = append(out, segment{text: sentence, sep: sep, tokens: t})
+				sep = " "
+				continue
+			}
+			for _, words := range wordWindows(sentence, size) {
+				out = append(out, segment{text: words, sep: sep, tokens: tokens.Estimate(words)})
+				sep = " "
+			}
+		}
+	}
+	return out
+}
+
+// tail returns the trailing sentences of seg that fit in budget tokens or,
+// when not even its last sentence fits, its trailing words.
+func tail(seg segment, budget int) (segment, bool) {
+	text, ok := trailing(sentences(seg.text), budget)
+	if !ok {
+		text, ok = trailing(strings.Fields(seg.text), budget)
+	}
+	if !ok {
+		return segment{}, false
+	}
+	return segment{text: text, sep: seg.sep, tokens: tokens.Estimate(text)}, true
+}
+
+// trailing joins the last parts that fit in budget tokens.
+func trailing(parts []string, budget int) (string, bool) {
+	var text string
+	for start := len(parts) - 1; start >= 0; start-- {
+		joined := strings.Join(parts[start:], " ")
+		if tokens.Estimate(joined) > budget {
+			break
+		}
+		text = joined
+	}
+	return text, text != ""
+}
+
+// sentences cuts a paragraph after sentence punctuation followed by a
+// space, and at line breaks.
+func sentences(para string) []string {
+	var (
+		out   []string
+		start int
+	)
+	for i := 0; i < len(para); i++ {
+		end := false
+		switch para[i] {
+		case '\n':
+			end = true
+		case '.', '!', '?':
+			end = i+1 < len(para) && (para[i+1] == ' ' || para[i+1] == '\n')
+		}
+		if !end {
+			continue
+		}
+		if s := strings.TrimSpace(para[start : i+1]); s != "" {
+			out = append(out, s)
+		}
+		start = i + 1
+	}
+	if s := strings.TrimSpace(para[start:]); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// wordWindows cuts text into runs of words of at most size tokens. A
+// single word above size is a run of its own.
+func wordWindows(text string, size int) []string {
+	var (
+		out []string
+		cur []string
+		n   int
+	)
+	for _, w := range strings.Fields(text) {
+		t := tokens.Estimate(w)
+		if n+t > size && len(cur) > 0 {
+			out = append(out, strings.Join(cur, " "))
+			cur, n = nil, 0
+		}
+		cur = append(cur, w)
+		n += t
+	}
+	if len(cur) > 0 {
+		out = append(out, strings.Join(cur, " "))
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/tokens"
+)
+
+// paragraph returns n sentences of about ten tokens each.
+func paragraph(prefix string, n int) string {
+	s := make([]string, n)
+	for i := range s {
+		s[i] = fmt.Sprintf("Sentence %s%d carries a few words of text.", prefix, i)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestSplitSizes(t *testing.T) {
+	paras := []string{paragraph("a", 4), paragraph("b", 4), paragraph("c", 4), paragraph("d", 4)}
+	chunks := Chunker{Size: 100, Overlap: 20}.Split(Document{Source: "doc", Text: strings.Join(paras, "\n\n")})
+
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	for i, c := range chunks {
+		if c.ID != fmt.Sprintf("doc#%d", i) || c.Index != i {
+			t.Errorf("chunk %d: ID %s, Index %d", i, c.ID, c.Index)
+		}
+		if n := tokens.Estimate(c.Text); n > 100 {
+			t.Errorf("chunk %d has %d tokens, want at most 100", i, n)
+		}
+	}
+	if !strings.Contains(chunks[len(chunks)-1].Text, "Sentence d3") {
+		t.Error("the end of the document is missing")
+	}
+}
+
+// carried returns the text of a chunk before its first paragraph break,
+// which the tests below fill with the overlap.
+func carried(c Chunk) string {
+	text, _, _ := strings.Cut(c.Text, "\n\n")
+	return text
+}
+
+func TestSplitOverlapCarriesSentences(t *testing.T) {
+	// every paragraph is larger than the overlap, so only its trailing
+	// sentences can be carried
+	paras := []string{paragraph("a", 4), paragraph("b", 4), paragraph("c", 4)}
+	chunks := Chunker{Size: 100, Overlap: 20}.Split(Document{Source: "doc", Text: strings.Join(paras, "\n\n")})
+
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	for i := 1; i < len(chunks); i++ {
+		c := carried(chunks[i])
+		if !strings.HasPrefix(c, "Sentence ") || !strings.HasSuffix(chunks[i-1].Text, c) || tokens.Estimate(c) > 20 {
+			t.Errorf("chunk %d starts with %q, want the last sentences of chunk %d within the overlap", i, c, i-1)
+		}
+	}
+}
+
+func TestSplitOverlapCarriesWords(t *testing.T) {
+	// paragraphs of a single long sentence can only carry words
+	words := func(prefix string) string {
+		w := make([]string, 30)
+		for i := range w {
+			w[i] = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return strings.Join(w, " ")
+	}
+	chunks := Chunker{Size: 100, Overlap: 20}.Split(Document{Source: "doc", Text: words("alpha") + "\n\n" + words("beta")})
+
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(chunks))
+	}
+	c := carried(chunks[1])
+	if !strings.HasPrefix(c, "alpha") || !strings.HasSuffix(chunks[0].Text, " "+c) || tokens.Estimate(c) > 20 {
+		t.Errorf("chunk 1 starts with %q, want the last words of chunk 0 within the overlap", c)
+	}
+}
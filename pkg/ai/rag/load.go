@@ -0,0 +1,227 @@
+package rag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Format is the format of a document's content.
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// Document is a text to index.
+type Document struct {
+	// Source identifies the document, e.g. its path or URL. Indexing a
+	// document replaces the chunks of an earlier one with the same source.
+	Source string
+	Title  string
+	Text   string
+	// Metadata is copied to every chunk, for filtering searches.
+	Metadata map[string]string
+}
+
+// LoadFile reads a document from a file, with the format of its extension:
+// ".md" and ".markdown" are markdown, ".html" and ".htm" HTML, anything
+// else plain text. The source is the path.
+func LoadFile(path string) (Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("open document: %w", err)
+	}
+	defer f.Close()
+
+	doc, err := Load(f, path, formatOf(path))
+	if err != nil {
+		return Document{}, fmt.Errorf("load %s: %w", path, err)
+	}
+	if doc.Title == "" {
+		doc.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return doc, nil
+}
+
+// Load reads a document of the given format from r. Markdown is kept as
+// is, less its front matter, and titled by its first heading. HTML is
+// reduced to its text, with headings and list items in markdown so that
+// chunks keep the structure; scripts, styles and navigation are dropped.
+func Load(r io.Reader, source string, format Format) (Document, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Document{}, fmt.Errorf("read document: %w", err)
+	}
+	doc := Document{Source: source}
+
+	switch format {
+	case FormatText, "":
+		doc.Text = normalize(string(b))
+	case FormatMarkdown:
+		doc.Text = normalize(stripFrontMatter(string(b)))
+		doc.Title = markdownTitle(doc.Text)
+	case FormatHTML:
+		doc.Title, doc.Text, err = htmlText(b)
+		if err != nil {
+			return Document{}, err
+		}
+	default:
+		return Document{}, fmt.Errorf("unknown format %q", format)
+	}
+	return doc, nil
+}
+
+func formatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".html", ".htm":
+		return FormatHTML
+	default:
+		return FormatText
+	}
+}
+
+// normalize unifies line endings and trims trailing spaces.
+func normalize(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// stripFrontMatter removes a leading YAML front matter block.
+func stripFrontMatter(s string) string {
+	if !strings.HasPrefix(s, "---\n") && !strings.HasPrefix(s, "---\r\n") {
+		return s
+	}
+	rest := s[strings.Index(s, "\n")+1:]
+	for off := 0; off < len(rest); {
+		end := strings.IndexByte(rest[off:], '\n')
+		line := rest[off:]
+		if end >= 0 {
+			line = rest[off : off+end]
+		}
+		if strings.TrimSpace(line) == "---" {
+			if end < 0 {
+				return ""
+			}
+			return rest[off+end+1:]
+		}
+		if end < 0 {
+			break
+		}
+		off += end + 1
+	}
+	return s
+}
+
+// markdownTitle returns the text of the first level one heading.
+func markdownTitle(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(line[2:])
+		}
+	}
+	return ""
+}
+
+// htmlText returns the title and the text of an HTML page.
+func htmlText(b []byte) (string, string, error) {
+	root, err := html.Parse(bytes.NewReader(b))
+	if err != nil {
+		return "", "", fmt.Errorf("parse html: %w", err)
+	}
+
+	var (
+		title string
+		sb    strings.Builder
+		walk  func(n *html.Node)
+	)
+	block := func(prefix string) {
+		s := sb.String()
+		if s != "" && !strings.HasSuffix(s, "\n\n") {
+			if strings.HasSuffix(s, "\n") {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		sb.WriteString(prefix)
+	}
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			text := strings.Join(strings.Fields(n.Data), " ")
+			if text == "" {
+				return
+			}
+			s := sb.String()
+			if s != "" && !strings.HasSuffix(s, "\n") && !strings.HasSuffix(s, " ") && !strings.HasPrefix(text, ".") && !strings.HasPrefix(text, ",") {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(text)
+			return
+		case html.ElementNode:
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Nav, atom.Svg, atom.Iframe:
+				return
+			case atom.Title:
+				if title == "" && n.FirstChild != nil {
+					title = strings.Join(strings.Fields(n.FirstChild.Data), " ")
+				}
+				return
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				block(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+			case atom.Li:
+				if !strings.HasSuffix(sb.String(), "\n") && sb.Len() > 0 {
+					sb.WriteString("\n")
+				}
+				sb.WriteString("- ")
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c)
+				}
+				sb.WriteString("\n")
+				return
+			case atom.Br:
+				sb.WriteString("\n")
+				return
+			case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer,
+				atom.Ul, atom.Ol, atom.Table, atom.Tr, atom.Pre, atom.Blockquote, atom.Dl, atom.Dt, atom.Dd:
+				block("")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode && isHeading(n.DataAtom) {
+			block("")
+		}
+	}
+	walk(root)
+
+	text := normalize(sb.String())
+	if title == "" {
+		title = markdownTitle(text)
+	}
+	return title, text, nil
+}
+
+func isHeading(a atom.Atom) bool {
+	switch a {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+  <title> Go  Concurrency </title>
+  <style>p { color: red }</style>
+  <script>var tracking = 1;</script>
+</head>
+<body>
+  <nav><a href="/">Home</a> <a href="/blog">Blog</a></nav>
+  <main>
+    <h1>Goroutines</h1>
+    <p>Goroutines are <b>lightweight</b> threads.</p>
+    <h2>Channels</h2>
+    <ul><li>Unbuffered</li><li>Buffered, with a <code>cap</code></li></ul>
+    <p>Line one<br>Line two</p>
+  </main>
+  <footer>Copyright</footer>
+</body>
+</html>`
+
+func TestLoadHTML(t *testing.T) {
+	doc, err := Load(strings.NewReader(page), "https://example.com/go", FormatHTML)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if doc.Title != "Go Concurrency" {
+		t.Errorf("Title = %q, want the <title>", doc.Title)
+	}
+	want := "# Goroutines\n\n" +
+		"Goroutines are lightweight threads.\n\n" +
+		"## Channels\n\n" +
+		"- Unbuffered\n" +
+		"- Buffered, with a cap\n\n" +
+		"Line one\nLine two\n\n" +
+		"Copyright"
+	if doc.Text != want {
+		t.Errorf("Text = %q, want %q", doc.Text, want)
+	}
+}
+
+func TestLoadFileHTML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.htm")
+	if err := os.WriteFile(path, []byte(`<p>Untitled notes.</p><h2>Details</h2>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if doc.Source != path || doc.Title != "notes" {
+		t.Errorf("document = %q titled %q, want the path titled by the file name", doc.Source, doc.Title)
+	}
+	if doc.Text != "Untitled notes.\n\n## Details" {
+		t.Errorf("Text = %q", doc.Text)
+	}
+}
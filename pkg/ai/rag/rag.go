@@ -0,0 +1,301 @@
+// Package rag answers questions from a corpus of documents: they are
+// loaded, cut into chunks, embedded and stored in a vector store; the
+// chunks most similar to a question are then numbered into a prompt, and
+// any AIAgent answers it citing them.
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/muraduiurie/gpt/pkg/ai"
+	"github.com/muraduiurie/gpt/pkg/ai/tokens"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+	"github.com/muraduiurie/gpt/pkg/ai/vectorstore"
+)
+
+// Metadata keys the pipeline sets on stored chunks, besides the metadata
+// of their document.
+const (
+	MetaSource = "source"
+	MetaTitle  = "title"
+	MetaChunk  = "chunk"
+)
+
+// DefaultSystem is the system prompt of a Pipeline without one.
+const DefaultSystem = "Answer the question using only the numbered sources provided. " +
+	"Cite the sources supporting each statement by their number in square brackets, e.g. [1] or [2][3]. " +
+	"If the sources do not contain the answer, say that you don't know."
+
+// Pipeline indexes documents and answers questions about them.
+type Pipeline struct {
+	// Agent answers the questions. It must accept provider-neutral prompts
+	// (see ai.CapabilityPrompt).
+	Agent ai.AIAgent
+	// Store holds the chunks. It needs an Embedder; see
+	// vectorstore.Options.
+	Store   *vectorstore.Store
+	Chunker Chunker
+	// K is the number of chunks retrieved per question. Defaults to 4.
+	K int
+	// MinScore drops chunks scoring below it when set.
+	MinScore *float32
+	// MaxContextTokens bounds the estimated size of the sources in the
+	// prompt; the least similar chunks are left out first. Zero means no
+	// bound.
+	MaxContextTokens int
+	// System defaults to DefaultSystem.
+	System string
+	// Model, MaxTokens and Temperature are passed to the agent in the
+	// prompt.
+	Model       string
+	MaxTokens   int
+	Temperature *float64
+}
+
+// Request is a question to a Pipeline.
+type Request struct {
+	Question string
+	// Filter restricts the search to chunks with the given metadata.
+	Filter vectorstore.Filter
+	// K overrides Pipeline.K.
+	K int
+	// History holds earlier turns of the conversation, sent before the
+	// question.
+	History []union.PromptMessage
+}
+
+// Answer is the answer to a question with the sources it was given.
+type Answer struct {
+	// Text is the answer, union.Response.Output.
+	Text string
+	// Sources are the chunks put in the prompt, numbered from one in the
+	// order of similarity.
+	Sources  []Source
+	Response *union.Response
+}
+
+// Source is a chunk given to the agent.
+type Source struct {
+	// N is the number the answer cites the chunk by.
+	N     int
+	Chunk Chunk
+	Score float32
+	// Cited reports whether the answer cites the chunk.
+	Cited bool
+}
+
+// Cited returns the sources the answer cites.
+func (a *Answer) Cited() []Source {
+	var out []Source
+	for _, s := range a.Sources {
+		if s.Cited {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Index chunks the documents and stores them with their embeddings. The
+// chunks of earlier documents with the same sources are replaced. It
+// returns the embedding usage.
+func (p *Pipeline) Index(ctx context.Context, docs ...Document) (*union.Usage, error) {
+	if p.Store == nil {
+		return nil, errors.New("no vector store configured")
+	}
+
+	var (
+		stored []vectorstore.Document
+		counts = map[string]int{}
+	)
+	for _, doc := range docs {
+		if doc.Source == "" {
+			return nil, errors.New("document source is required")
+		}
+		chunks := p.Chunker.Split(doc)
+		for _, c := range chunks {
+			stored = append(stored, vectorstore.Document{
+				ID:       c.ID,
+				Text:     c.Text,
+				Metadata: chunkMetadata(c),
+			})
+		}
+		counts[doc.Source] = len(chunks)
+	}
+
+	var usage *union.Usage
+	if len(stored) > 0 {
+		var err error
+		usage, err = p.Store.AddTexts(ctx, stored...)
+		if err != nil {
+			return nil, fmt.Errorf("index: %w", err)
+		}
+	}
+	// the new chunks replaced the old ones by ID; drop those left over
+	for source, n := range counts {
+		for i := n; p.Store.Delete(fmt.Sprintf("%s#%d", source, i)) > 0; i++ {
+		}
+	}
+	return usage, nil
+}
+
+// Ask retrieves the chunks most similar to the question and asks the agent
+// to answer from them.
+func (p *Pipeline) Ask(ctx context.Context, req *Request) (*Answer, error) {
+	if req == nil || strings.TrimSpace(req.Question) == "" {
+		return nil, errors.New("question is required")
+	}
+	if p.Agent == nil {
+		return nil, errors.New("no agent configured")
+	}
+	if p.Store == nil {
+		return nil, errors.New("no vector store configured")
+	}
+
+	k := req.K
+	if k <= 0 {
+		k = p.K
+	}
+	if k <= 0 {
+		k = 4
+	}
+	results, err := p.Store.SearchText(ctx, req.Question, vectorstore.Query{
+		K:        k,
+		Filter:   req.Filter,
+		MinScore: p.MinScore,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+
+	sources := p.sources(results)
+	resp, err := p.Agent.AskAI(&union.Request{
+		Prompt:  p.prompt(req, sources),
+		Context: ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cited := citations(resp.Output)
+	for i := range sources {
+		sources[i].Cited = cited[sources[i].N]
+	}
+	return &Answer{
+		Text:     resp.Output,
+		Sources:  sources,
+		Response: resp,
+	}, nil
+}
+
+// sources numbers the results, within MaxContextTokens.
+func (p *Pipeline) sources(results []vectorstore.Result) []Source {
+	var (
+		out    []Source
+		budget int
+	)
+	for _, r := range results {
+		c := chunkOf(r.Document)
+		n := tokens.Estimate(c.Text)
+		if p.MaxContextTokens > 0 && budget+n > p.MaxContextTokens && len(out) > 0 {
+			break
+		}
+		budget += n
+		out = append(out, Source{N: len(out) + 1, Chunk: c, Score: r.Score})
+	}
+	return out
+}
+
+// prompt puts the numbered sources and the question in the last user
+// message.
+func (p *Pipeline) prompt(req *Request, sources []Source) *union.Prompt {
+	var sb strings.Builder
+	sb.WriteString("Sources:\n")
+	if len(sources) == 0 {
+		sb.WriteString("(none found)\n")
+	}
+	for _, s := range sources {
+		fmt.Fprintf(&sb, "\n[%d] %s\n%s\n", s.N, sourceLabel(s.Chunk), s.Chunk.Text)
+	}
+	sb.WriteString("\nQuestion: ")
+	sb.WriteString(req.Question)
+
+	system := p.System
+	if system == "" {
+		system = DefaultSystem
+	}
+	messages := append([]union.PromptMessage(nil), req.History...)
+	messages = append(messages, union.PromptMessage{
+		Role:    union.PromptRoleUser,
+		Content: sb.String(),
+	})
+
+	return &union.Prompt{
+		Model:       p.Model,
+		System:      system,
+		Messages:    messages,
+		MaxTokens:   p.MaxTokens,
+		Temperature: p.Temperature,
+	}
+}
+
+func sourceLabel(c Chunk) string {
+	if c.Title == "" || c.Title == c.Source {
+		return c.Source
+	}
+	return c.Title + " (" + c.Source + ")"
+}
+
+// citationPattern matches citations such as "[1]" and "[1, 3]".
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// citations returns the source numbers cited in text.
+func citations(text string) map[int]bool {
+	cited := map[int]bool{}
+	for _, m := range citationPattern.FindAllStringSubmatch(text, -1) {
+		for _, part := range strings.Split(m[1], ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+				cited[n] = true
+			}
+		}
+	}
+	return cited
+}
+
+// chunkMetadata returns the metadata a chunk is stored with.
+func chunkMetadata(c Chunk) map[string]string {
+	md := make(map[string]string, len(c.Metadata)+3)
+	for k, v := range c.Metadata {
+		md[k] = v
+	}
+	md[MetaSource] = c.Source
+	md[MetaChunk] = strconv.Itoa(c.Index)
+	if c.Title != "" {
+		md[MetaTitle] = c.Title
+	}
+	return md
+}
+
+// chunkOf rebuilds a chunk from its stored document.
+func chunkOf(d vectorstore.Document) Chunk {
+	c := Chunk{
+		ID:       d.ID,
+		Source:   d.Metadata[MetaSource],
+		Title:    d.Metadata[MetaTitle],
+		Text:     d.Text,
+		Metadata: map[string]string{},
+	}
+	c.Index, _ = strconv.Atoi(d.Metadata[MetaChunk])
+	for k, v := range d.Metadata {
+		switch k {
+		case MetaSource, MetaTitle, MetaChunk:
+		default:
+			c.Metadata[k] = v
+		}
+	}
+	return c
+}
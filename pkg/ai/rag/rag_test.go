@@ -0,0 +1,177 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/tokens"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+	"github.com/muraduiurie/gpt/pkg/ai/vectorstore"
+)
+
+// fakeEmbedder embeds a text as the vector of its first word, so tests
+// decide how similar texts are.
+type fakeEmbedder map[string][]float32
+
+func (e fakeEmbedder) EmbedAI(opts *union.EmbedRequest) (*union.EmbedResponse, error) {
+	resp := &union.EmbedResponse{Usage: &union.Usage{}}
+	for _, in := range opts.Input {
+		word, _, _ := strings.Cut(in, " ")
+		v, ok := e[word]
+		if !ok {
+			return nil, fmt.Errorf("no vector for %q", word)
+		}
+		resp.Embeddings = append(resp.Embeddings, v)
+		resp.Usage.InputTokens += tokens.Estimate(in)
+	}
+	return resp, nil
+}
+
+var vectors = fakeEmbedder{
+	"Goroutines": {1, 0, 0},
+	"Channels":   {0.9, 0.1, 0},
+	"Ownership":  {0, 1, 0},
+	"How":        {1, 0, 0},
+	"Sentence":   {0, 0, 1},
+}
+
+func newPipeline(t *testing.T, agent *aitest.Agent) *Pipeline {
+	t.Helper()
+	store, err := vectorstore.New(vectorstore.Options{Embedder: vectors})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Pipeline{Agent: agent, Store: store}
+}
+
+func TestIndexRemovesLeftoverChunks(t *testing.T) {
+	p := newPipeline(t, aitest.New())
+	p.Chunker = Chunker{Size: 40, Overlap: 10}
+
+	long := Document{Source: "guide.md", Text: strings.Join([]string{
+		paragraph("a", 3), paragraph("b", 3), paragraph("c", 3), paragraph("d", 3),
+	}, "\n\n")}
+	other := Document{Source: "other.md", Text: "Ownership moves values."}
+	if _, err := p.Index(t.Context(), long, other); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if p.Store.Len() < 4 {
+		t.Fatalf("stored %d chunks, want the long document in several", p.Store.Len())
+	}
+
+	usage, err := p.Index(t.Context(), Document{Source: "guide.md", Text: "Sentence one."})
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if usage == nil || usage.InputTokens == 0 {
+		t.Errorf("usage = %+v, want the embedding usage", usage)
+	}
+	if p.Store.Len() != 2 {
+		t.Errorf("stored %d chunks, want 2", p.Store.Len())
+	}
+	if d, ok := p.Store.Get("guide.md#0"); !ok || d.Text != "Sentence one." {
+		t.Errorf("guide.md#0 = %+v, want the new text", d)
+	}
+	if _, ok := p.Store.Get("guide.md#1"); ok {
+		t.Error("guide.md#1 was left over")
+	}
+	if _, ok := p.Store.Get("other.md#0"); !ok {
+		t.Error("the chunk of another source was removed")
+	}
+}
+
+func TestAskCitations(t *testing.T) {
+	agent := aitest.New()
+	agent.Enqueue(aitest.Response(nil, nil).WithOutput("Goroutines are cheap [1, 3]; see also [1]."))
+	p := newPipeline(t, agent)
+	if _, err := p.Index(t.Context(),
+		Document{Source: "goroutines.md", Title: "Goroutines", Text: "Goroutines are lightweight threads."},
+		Document{Source: "channels.md", Text: "Channels connect goroutines."},
+		Document{Source: "ownership.md", Text: "Ownership moves values."},
+	); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+
+	answer, err := p.Ask(t.Context(), &Request{Question: "How cheap are goroutines?"})
+	if err != nil {
+		t.Fatalf("Ask: %v", err)
+	}
+	if answer.Text != "Goroutines are cheap [1, 3]; see also [1]." {
+		t.Errorf("Text = %q", answer.Text)
+	}
+
+	var order []string
+	for _, s := range answer.Sources {
+		order = append(order, fmt.Sprintf("%d:%s:%t", s.N, s.Chunk.Source, s.Cited))
+	}
+	if want := "[1:goroutines.md:true 2:channels.md:false 3:ownership.md:true]"; fmt.Sprint(order) != want {
+		t.Errorf("sources = %v, want %s", order, want)
+	}
+	if cited := answer.Cited(); len(cited) != 2 || cited[0].N != 1 || cited[1].N != 3 {
+		t.Errorf("Cited = %+v, want sources 1 and 3", cited)
+	}
+
+	prompt := agent.LastRequest().Prompt
+	if prompt.System != DefaultSystem {
+		t.Errorf("System = %q", prompt.System)
+	}
+	content := prompt.Messages[len(prompt.Messages)-1].Content
+	for _, want := range []string{
+		"[1] Goroutines (goroutines.md)\nGoroutines are lightweight threads.",
+		"[2] channels.md\nChannels connect goroutines.",
+		"Question: How cheap are goroutines?",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestAskMaxContextTokens(t *testing.T) {
+	agent := aitest.New()
+	agent.Enqueue(aitest.Response(nil, nil).WithOutput("Goroutines [1]."))
+	p := newPipeline(t, agent)
+	docs := []Document{
+		{Source: "goroutines.md", Text: "Goroutines are lightweight threads managed by the Go runtime."},
+		{Source: "channels.md", Text: "Channels connect goroutines and synchronize them."},
+		{Source: "ownership.md", Text: "Ownership moves values between variables."},
+	}
+	if _, err := p.Index(t.Context(), docs...); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	// room for the two most similar chunks, not the third
+	p.MaxContextTokens = tokens.Estimate(docs[0].Text) + tokens.Estimate(docs[1].Text)
+
+	answer, err := p.Ask(t.Context(), &Request{Question: "How do goroutines work?"})
+	if err != nil {
+		t.Fatalf("Ask: %v", err)
+	}
+	if len(answer.Sources) != 2 || answer.Sources[1].Chunk.Source != "channels.md" {
+		t.Errorf("sources = %+v, want the two most similar chunks", answer.Sources)
+	}
+	if content := agent.LastRequest().Prompt.Messages[0].Content; strings.Contains(content, "Ownership") {
+		t.Errorf("prompt carries the chunk over the budget:\n%s", content)
+	}
+
+	// the most similar chunk is kept even above the budget
+	agent.Enqueue(aitest.Response(nil, nil).WithOutput("Goroutines [1]."))
+	p.MaxContextTokens = 1
+	answer, err = p.Ask(t.Context(), &Request{Question: "How do goroutines work?"})
+	if err != nil {
+		t.Fatalf("Ask: %v", err)
+	}
+	if len(answer.Sources) != 1 || answer.Sources[0].Chunk.Source != "goroutines.md" {
+		t.Errorf("sources = %+v, want the most similar chunk", answer.Sources)
+	}
+}
+
+func TestCitations(t *testing.T) {
+	got := citations("First [1], then [2, 4] and [ 5 ,6], not [x] or [7a].")
+	for n, want := range map[int]bool{1: true, 2: true, 3: false, 4: true, 5: false, 6: false, 7: false} {
+		if got[n] != want {
+			t.Errorf("cited[%d] = %t, want %t", n, got[n], want)
+		}
+	}
+}
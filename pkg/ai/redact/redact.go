@@ -0,0 +1,85 @@
+// Package redact masks secrets and personal data before they are logged or
+// exported.
+package redact
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+var (
+	// Secrets matches provider API keys, including the bare 32 character
+	// keys of Mistral and Azure OpenAI, and bearer tokens. It is always
+	// applied by a Redactor.
+	Secrets = regexp.MustCompile(`(?i)\b(sk-(ant-|proj-)?[A-Za-z0-9_\-]{16,}|AIza[0-9A-Za-z_\-]{35}|[A-Za-z0-9]{32}\b|bearer\s+[A-Za-z0-9._\-~+/]{8,}=*)`)
+
+	// Email matches e-mail addresses.
+	Email = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// Phone matches international and North American phone numbers.
+	Phone = regexp.MustCompile(`\+?\d{1,3}[\s.\-]?\(?\d{2,4}\)?[\s.\-]?\d{3,4}[\s.\-]?\d{3,4}`)
+	// CreditCard matches 13 to 19 digit card numbers, optionally grouped.
+	CreditCard = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+	// IPv4 matches IPv4 addresses.
+	IPv4 = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+)
+
+// sensitiveHeaders never have their values logged.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"X-Api-Key":           true,
+	"Api-Key":             true,
+	"X-Goog-Api-Key":      true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// IsSensitiveHeader reports whether the header carries credentials.
+func IsSensitiveHeader(name string) bool {
+	return sensitiveHeaders[http.CanonicalHeaderKey(name)]
+}
+
+// Redactor masks secrets and configured patterns. The zero value masks
+// secrets only.
+type Redactor struct {
+	// Patterns are masked in addition to Secrets, e.g. Email or Phone.
+	Patterns []*regexp.Regexp
+}
+
+// New returns a Redactor masking secrets and the given patterns.
+func New(patterns ...*regexp.Regexp) *Redactor {
+	return &Redactor{Patterns: patterns}
+}
+
+// String returns s with secrets and configured patterns masked.
+func (r *Redactor) String(s string) string {
+	s = Secrets.ReplaceAllString(s, Mask)
+	if r == nil {
+		return s
+	}
+	for _, p := range r.Patterns {
+		s = p.ReplaceAllString(s, Mask)
+	}
+	return s
+}
+
+// Header returns a copy of h with credential headers masked and the other
+// values redacted.
+func (r *Redactor) Header(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, vs := range h {
+		if IsSensitiveHeader(k) {
+			out[k] = []string{Mask}
+			continue
+		}
+		masked := make([]string, len(vs))
+		for i, v := range vs {
+			masked[i] = r.String(v)
+		}
+		out[k] = masked
+	}
+	return out
+}
@@ -0,0 +1,509 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/embed"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/azure"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/claude"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/deepseek"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/gemini"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/mistral"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/ollama"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/openaicompat"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	oltypes "github.com/muraduiurie/gpt/pkg/ai/types/ollama"
+)
+
+// Capability is a feature a provider supports.
+type Capability string
+
+const (
+	// CapabilityText means the agent answers text requests (AskAI).
+	CapabilityText Capability = "text"
+	// CapabilityStreaming means the agent implements StreamingAIAgent.
+	CapabilityStreaming Capability = "streaming"
+	// CapabilityPrompt means the agent accepts provider-neutral prompts
+	// (union.Request.Prompt), so it can be used behind a Router.
+	CapabilityPrompt Capability = "prompt"
+	// CapabilityEmbeddings means the agent implements Embedder.
+	CapabilityEmbeddings Capability = "embeddings"
+)
+
+// ProviderConfig is what a Factory builds an agent from. NewAIAgent fills
+// it from AIOpts and `config.yaml`.
+type ProviderConfig struct {
+	ApiToken          string
+	TextInputEndpoint string
+	// Keys is set when several API tokens are configured.
+	Keys       *keypool.Pool
+	HTTPClient *http.Client
+	Observer   observe.Observer
+	// Settings holds the provider-specific settings: the `config.yaml` keys
+	// starting with the provider's config prefix, with the prefix and its
+	// underscore stripped, overridden by AIOpts.Settings.
+	Settings map[string]string
+}
+
+// Factory creates the agent of a provider.
+type Factory func(cfg ProviderConfig) (AIAgent, error)
+
+// Provider describes a registered provider.
+type Provider struct {
+	Model Model
+	// ConfigPrefix prefixes the provider's keys in `config.yaml`, e.g.
+	// "openai" for `openai_api_token`.
+	ConfigPrefix string
+	// DefaultEndpoint is the endpoint the agent uses when none is
+	// configured, for display; factories apply it themselves.
+	DefaultEndpoint string
+	Capabilities    []Capability
+	// OptionalToken disables the missing API token check, for providers
+	// that authenticate otherwise or not at all.
+	OptionalToken bool
+	New           Factory
+}
+
+// Has reports whether the provider supports c.
+func (p Provider) Has(c Capability) bool {
+	for _, pc := range p.Capabilities {
+		if pc == c {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[Model]Provider{}
+)
+
+func init() {
+	chatCapabilities := []Capability{CapabilityText, CapabilityStreaming, CapabilityPrompt}
+
+	Register(Provider{
+		Model:           ModelChatGPT,
+		ConfigPrefix:    "openai",
+		DefaultEndpoint: chatgpt.DefaultTextInputEndpoint,
+		Capabilities:    append(chatCapabilities, CapabilityEmbeddings),
+		New: func(cfg ProviderConfig) (AIAgent, error) {
+			mode := chatgpt.Mode(cfg.Settings["api_mode"])
+			switch mode {
+			case "", chatgpt.ModeResponses, chatgpt.ModeChatCompletions:
+			default:
+				return nil, fmt.Errorf("unknown `openai_api_mode` %q: use %q or %q", mode, chatgpt.ModeResponses, chatgpt.ModeChatCompletions)
+			}
+
+			limits, err := embedLimits(cfg.Settings, "openai_")
+			if err != nil {
+				return nil, err
+			}
+
+			return &chatgpt.Client{
+				ApiToken:                 cfg.ApiToken,
+				TextInputEndpoint:        cfg.TextInputEndpoint,
+				Mode:                     mode,
+				EmbeddingsEndpoint:       cfg.Settings["embeddings_endpoint"],
+				EmbeddingModel:           cgtypes.ChatGPTAIModel(cfg.Settings["embedding_model"]),
+				EmbedLimits:              limits,
+				SpeechEndpoint:           cfg.Settings["speech_endpoint"],
+				TranscriptionsEndpoint:   cfg.Settings["transcriptions_endpoint"],
+				TranslationsEndpoint:     cfg.Settings["translations_endpoint"],
+				ImageGenerationsEndpoint: cfg.Settings["image_generations_endpoint"],
+				ImageEditsEndpoint:       cfg.Settings["image_edits_endpoint"],
+				Keys:                     cfg.Keys,
+				HTTPClient:               cfg.HTTPClient,
+				Observer:                 cfg.Observer,
+			}, nil
+		},
+	})
+	Register(Provider{
+		Model:           ModelDeepSeek,
+		ConfigPrefix:    "deepseek",
+		DefaultEndpoint: deepseek.DefaultTextInputEndpoint,
+		Capabilities:    chatCapabilities,
+		New: func(cfg ProviderConfig) (AIAgent, error) {
+			return &deepseek.Client{
+				ApiToken:          cfg.ApiToken,
+				TextInputEndpoint: cfg.TextInputEndpoint,
+				Keys:              cfg.Keys,
+				HTTPClient:        cfg.HTTPClient,
+				Observer:          cfg.Observer,
+			}, nil
+		},
+	})
+	Register(Provider{
+		Model:           ModelClaude,
+		ConfigPrefix:    "claude",
+		DefaultEndpoint: claude.DefaultTextInputEndpoint,
+		Capabilities:    chatCapabilities,
+		OptionalToken:   true,
+		New: func(cfg ProviderConfig) (AIAgent, error) {
+			c := &claude.Client{
+				ApiToken:          cfg.ApiToken,
+				TextInputEndpoint: cfg.TextInputEndpoint,
+				Platform:          claude.Platform(cfg.Settings["platform"]),
+				Keys:              cfg.Keys,
+				HTTPClient:        cfg.HTTPClient,
+				Observer:          cfg.Observer,
+			}
+
+			switch c.Platform {
+			case "", claude.PlatformAnthropic:
+				if c.ApiToken == "" && c.Keys == nil {
+					return nil, errors.New("missing API token: set `claude_api_token` in `config.yaml`")
+				}
+			case claude.PlatformBedrock:
+				c.Bedrock = claude.Bedrock{
+					Region:      settingOrEnv(cfg.Settings, "bedrock_region", "AWS_REGION", "AWS_DEFAULT_REGION"),
+					Credentials: bedrockCredentials(cfg.Settings),
+					Models:      settingsMap(cfg.Settings, "bedrock_models."),
+				}
+			case claude.PlatformVertex:
+				ts, err := vertexTokenSource(cfg.Settings)
+				if err != nil {
+					return nil, err
+				}
+				c.Vertex = claude.Vertex{
+					ProjectID:   settingOrEnv(cfg.Settings, "vertex_project_id", "ANTHROPIC_VERTEX_PROJECT_ID", "GOOGLE_CLOUD_PROJECT"),
+					Region:      settingOrEnv(cfg.Settings, "vertex_region", "CLOUD_ML_REGION"),
+					TokenSource: ts,
+					Models:      settingsMap(cfg.Settings, "vertex_models."),
+				}
+			default:
+				return nil, fmt.Errorf("unknown `claude_platform` %q: use %q, %q or %q", c.Platform, claude.PlatformAnthropic, claude.PlatformBedrock, claude.PlatformVertex)
+			}
+			return c, nil
+		},
+	})
+	Register(Provider{
+		Model:           ModelGemini,
+		ConfigPrefix:    "gemini",
+		DefaultEndpoint: gemini.DefaultTextInputEndpoint,
+		Capabilities:    chatCapabilities,
+		New: func(cfg ProviderConfig) (AIAgent, error) {
+			return &gemini.Client{
+				ApiToken:          cfg.ApiToken,
+				TextInputEndpoint: cfg.TextInputEndpoint,
+				Keys:              cfg.Keys,
+				HTTPClient:        cfg.HTTPClient,
+				Observer:          cfg.Observer,
+			}, nil
+		},
+	})
+	Register(Provider{
+		Model:           ModelMistral,
+		ConfigPrefix:    "mistral",
+		DefaultEndpoint: mistral.DefaultTextInputEndpoint,
+		Capabilities:    chatCapabilities,
+		New: func(cfg ProviderConfig) (AIAgent, error) {
+			return &mistral.Client{
+				ApiToken:          cfg.ApiToken,
+				TextInputEndpoint: cfg.TextInputEndpoint,
+				FIMEndpoint:       cfg.Settings["fim_endpoint"],
+				Keys:              cfg.Keys,
+				HTTPClient:        cfg.HTTPClient,
+				Observer:          cfg.Observer,
+			}, nil
+		},
+	})
+	Register(Provider{
+		Model:         ModelOpenAICompatible,
+		ConfigPrefix:  "openai_compatible",
+		Capabilities:  append(chatCapabilities, CapabilityEmbeddings),
+		OptionalToken: true,
+		New: func(cfg ProviderConfig) (AIAgent, error) {
+			limits, err := embedLimits(cfg.Settings, "openai_compatible_")
+			if err != nil {
+				return nil, err
+			}
+
+			c := &openaicompat.Client{
+				Name:               cfg.Settings["name"],
+				BaseURL:            cfg.Settings["base_url"],
+				TextInputEndpoint:  cfg.TextInputEndpoint,
+				ApiToken:           cfg.ApiToken,
+				Keys:               cfg.Keys,
+				Header:             settingsHeader(cfg.Settings),
+				Models:             settingsList(cfg.Settings["models"]),
+				EmbeddingsEndpoint: cfg.Settings["embeddings_endpoint"],
+				EmbeddingModel:     cfg.Settings["embedding_model"],
+				EmbedLimits:        limits,
+				TranscriptionModel: cfg.Settings["transcription_model"],
+				HTTPClient:         cfg.HTTPClient,
+				Observer:           cfg.Observer,
+			}
+			if c.BaseURL == "" && c.TextInputEndpoint == "" {
+				return nil, errors.New("missing base URL: set `openai_compatible_base_url` in `config.yaml`")
+			}
+			return c, nil
+		},
+	})
+	Register(Provider{
+		Model:         ModelAzure,
+		ConfigPrefix:  "azure",
+		Capabilities:  chatCapabilities,
+		OptionalToken: true,
+		New: func(cfg ProviderConfig) (AIAgent, error) {
+			c := &azure.Client{
+				ApiToken:          cfg.ApiToken,
+				TextInputEndpoint: cfg.TextInputEndpoint,
+				APIVersion:        cfg.Settings["api_version"],
+				Deployments:       settingsMap(cfg.Settings, "deployments."),
+				AuthMode:          azure.AuthMode(cfg.Settings["auth_mode"]),
+				Keys:              cfg.Keys,
+				HTTPClient:        cfg.HTTPClient,
+				Observer:          cfg.Observer,
+			}
+			if c.TextInputEndpoint == "" {
+				return nil, errors.New("missing resource endpoint: set `azure_text_input_endpoint` in `config.yaml`")
+			}
+
+			switch c.AuthMode {
+			case "", azure.AuthAPIKey:
+				if c.ApiToken == "" && c.Keys == nil {
+					return nil, errors.New("missing API token: set `azure_api_token` in `config.yaml`")
+				}
+			case azure.AuthEntra:
+				c.TokenSource = entraTokenSource(cfg.Settings)
+			default:
+				return nil, fmt.Errorf("unknown `azure_auth_mode` %q: use %q or %q", c.AuthMode, azure.AuthAPIKey, azure.AuthEntra)
+			}
+			return c, nil
+		},
+	})
+	Register(Provider{
+		Model:           ModelOllama,
+		ConfigPrefix:    "ollama",
+		DefaultEndpoint: ollama.DefaultBaseURL + "/api/chat",
+		Capabilities:    append(chatCapabilities, CapabilityEmbeddings),
+		OptionalToken:   true,
+		New: func(cfg ProviderConfig) (AIAgent, error) {
+			limits, err := embedLimits(cfg.Settings, "ollama_")
+			if err != nil {
+				return nil, err
+			}
+
+			c := &ollama.Client{
+				BaseURL:           cfg.Settings["base_url"],
+				TextInputEndpoint: cfg.TextInputEndpoint,
+				ApiToken:          cfg.ApiToken,
+				Model:             cfg.Settings["model"],
+				EmbeddingModel:    cfg.Settings["embedding_model"],
+				EmbedLimits:       limits,
+				HTTPClient:        cfg.HTTPClient,
+				Observer:          cfg.Observer,
+			}
+			if v := cfg.Settings["num_ctx"]; v != "" {
+				numCtx, err := strconv.Atoi(v)
+				if err != nil {
+					return nil, fmt.Errorf("invalid `ollama_num_ctx` %q: %w", v, err)
+				}
+				c.NumCtx = numCtx
+			}
+			if v := cfg.Settings["keep_alive"]; v != "" {
+				keepAlive, err := parseKeepAlive(v)
+				if err != nil {
+					return nil, fmt.Errorf("invalid `ollama_keep_alive` %q: %w", v, err)
+				}
+				c.KeepAlive = &keepAlive
+			}
+			return c, nil
+		},
+	})
+}
+
+// embedLimits returns the embedding batch limits of the `embed_max_inputs`,
+// `embed_max_tokens` and `embed_concurrency` settings. prefix is the config
+// prefix, for error messages.
+func embedLimits(settings map[string]string, prefix string) (embed.Limits, error) {
+	var l embed.Limits
+	for key, dst := range map[string]*int{
+		"embed_max_inputs":  &l.MaxInputs,
+		"embed_max_tokens":  &l.MaxTokens,
+		"embed_concurrency": &l.Concurrency,
+	} {
+		v := settings[key]
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return embed.Limits{}, fmt.Errorf("invalid `%s%s` %q: want a non-negative integer", prefix, key, v)
+		}
+		*dst = n
+	}
+	return l, nil
+}
+
+// parseKeepAlive parses a keep_alive setting: a duration such as "10m", or
+// a number of seconds, negative to keep the model loaded.
+func parseKeepAlive(s string) (oltypes.Duration, error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		if seconds < 0 {
+			return -1, nil
+		}
+		return oltypes.Duration(seconds * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return oltypes.Duration(d), nil
+}
+
+// entraTokenSource returns the Entra ID token source of the azure settings:
+// a fixed `entra_token`, or the client credentials of a service principal,
+// falling back to the AZURE_TENANT_ID, AZURE_CLIENT_ID and
+// AZURE_CLIENT_SECRET environment variables.
+func entraTokenSource(settings map[string]string) azure.TokenSource {
+	if token := settings["entra_token"]; token != "" {
+		return azure.StaticToken(token)
+	}
+
+	return &azure.ClientCredentials{
+		TenantID:     settingOrEnv(settings, "tenant_id", "AZURE_TENANT_ID"),
+		ClientID:     settingOrEnv(settings, "client_id", "AZURE_CLIENT_ID"),
+		ClientSecret: settingOrEnv(settings, "client_secret", "AZURE_CLIENT_SECRET"),
+		Authority:    settings["authority"],
+	}
+}
+
+// vertexTokenSource returns the token source of the claude Vertex settings:
+// a fixed `vertex_access_token`, or the credentials file named by
+// `vertex_credentials_file` or GOOGLE_APPLICATION_CREDENTIALS.
+func vertexTokenSource(settings map[string]string) (claude.TokenSource, error) {
+	if token := settings["vertex_access_token"]; token != "" {
+		return claude.StaticToken(token), nil
+	}
+	path := settingOrEnv(settings, "vertex_credentials_file", "GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		return nil, errors.New("missing Vertex credentials: set `claude_vertex_access_token` or `claude_vertex_credentials_file` in `config.yaml`")
+	}
+	return claude.GoogleCredentialsFromFile(path)
+}
+
+// settingOrEnv returns the setting key or, when it is empty, the first
+// environment variable of envs that is set.
+func settingOrEnv(settings map[string]string, key string, envs ...string) string {
+	if v := settings[key]; v != "" {
+		return v
+	}
+	for _, env := range envs {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// bedrockCredentials returns the AWS credentials of the settings or, when
+// none is set, of the environment. The two sources are never mixed, so a
+// session token from the environment is not paired with configured keys.
+func bedrockCredentials(settings map[string]string) claude.AWSCredentials {
+	creds := claude.AWSCredentials{
+		AccessKeyID:     settings["bedrock_access_key_id"],
+		SecretAccessKey: settings["bedrock_secret_access_key"],
+		SessionToken:    settings["bedrock_session_token"],
+	}
+	if creds != (claude.AWSCredentials{}) {
+		return creds
+	}
+	return claude.AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Register makes a provider available to NewAIAgent under p.Model,
+// replacing any provider registered under the same name. The built-in
+// providers are registered already. It panics if p has no Model, config
+// prefix or factory.
+func Register(p Provider) {
+	if p.Model == "" || p.ConfigPrefix == "" || p.New == nil {
+		panic("ai: Register requires Model, ConfigPrefix and New")
+	}
+
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[p.Model] = p
+}
+
+// LookupProvider returns the provider registered under model.
+func LookupProvider(model Model) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[model]
+	return p, ok
+}
+
+// Providers returns the registered providers, sorted by model name.
+func Providers() []Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	out := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
+
+// Models returns the names NewAIAgent accepts, sorted.
+func Models() []Model {
+	ps := Providers()
+	out := make([]Model, len(ps))
+	for i, p := range ps {
+		out[i] = p.Model
+	}
+	return out
+}
+
+// settingsHeader returns the `headers.<name>` settings as a header, i.e. a
+// `headers` map under the provider prefix in `config.yaml`.
+func settingsHeader(settings map[string]string) http.Header {
+	h := http.Header{}
+	for k, v := range settings {
+		if name, ok := strings.CutPrefix(k, "headers."); ok {
+			h.Set(name, v)
+		}
+	}
+	return h
+}
+
+// settingsMap returns the settings starting with prefix, keyed by the rest
+// of their key, i.e. a map under the provider prefix in `config.yaml`.
+func settingsMap(settings map[string]string, prefix string) map[string]string {
+	m := map[string]string{}
+	for k, v := range settings {
+		if name, ok := strings.CutPrefix(k, prefix); ok {
+			m[name] = v
+		}
+	}
+	return m
+}
+
+// settingsList splits a comma-separated setting.
+func settingsList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package ai_test
+
+import (
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/claude"
+)
+
+func bedrockAgent(t *testing.T, settings map[string]string) *claude.Client {
+	t.Helper()
+	settings["platform"] = "bedrock"
+	settings["bedrock_region"] = "us-east-1"
+	a, err := ai.NewAIAgent(ai.ModelClaude, &ai.AIOpts{Settings: settings})
+	if err != nil {
+		t.Fatalf("NewAIAgent: %v", err)
+	}
+	return a.(*claude.Client)
+}
+
+func TestBedrockCredentialsFromConfig(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	t.Setenv("AWS_SESSION_TOKEN", "env-session")
+
+	c := bedrockAgent(t, map[string]string{
+		"bedrock_access_key_id":     "config-key",
+		"bedrock_secret_access_key": "config-secret",
+	})
+	want := claude.AWSCredentials{AccessKeyID: "config-key", SecretAccessKey: "config-secret"}
+	if c.Bedrock.Credentials != want {
+		t.Errorf("Credentials = %+v, want %+v", c.Bedrock.Credentials, want)
+	}
+}
+
+func TestBedrockCredentialsFromEnv(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	t.Setenv("AWS_SESSION_TOKEN", "env-session")
+
+	c := bedrockAgent(t, map[string]string{})
+	want := claude.AWSCredentials{AccessKeyID: "env-key", SecretAccessKey: "env-secret", SessionToken: "env-session"}
+	if c.Bedrock.Credentials != want {
+		t.Errorf("Credentials = %+v, want %+v", c.Bedrock.Credentials, want)
+	}
+}
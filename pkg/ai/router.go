@@ -0,0 +1,156 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// Router is an AIAgent that fails over across an ordered list of agents. A
+// request is sent to the first agent; on a retryable error (see IsRetryable)
+// it moves on to the next one. Since each backend expects its own request
+// type, the router only accepts provider-neutral requests (union.Request
+// with Prompt set).
+type Router struct {
+	Agents []AIAgent
+	// AttemptTimeout bounds each individual attempt. Zero means no timeout
+	// beyond the one of the request context.
+	AttemptTimeout time.Duration
+	// Retryable decides whether to fail over after an error. Defaults to
+	// IsRetryable.
+	Retryable func(err error) bool
+}
+
+// NewRouter returns a Router that tries agents in the given order.
+func NewRouter(agents ...AIAgent) *Router {
+	return &Router{Agents: agents}
+}
+
+// RouterError is returned when every attempt of a Router failed, or when an
+// attempt failed with a non-retryable error.
+type RouterError struct {
+	Attempts []union.Attempt
+	// NotRetried reports that the router stopped at the non-retryable error
+	// of the last attempt.
+	NotRetried bool
+	// Exhausted reports that every agent of the router was tried.
+	Exhausted bool
+}
+
+func (e *RouterError) Error() string {
+	parts := make([]string, 0, len(e.Attempts))
+	for i, a := range e.Attempts {
+		if e.NotRetried && i == len(e.Attempts)-1 {
+			parts = append(parts, fmt.Sprintf("provider %s failed (not retried): %s", a.Provider, a.Error))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("provider %s failed: %s", a.Provider, a.Error))
+	}
+	msg := strings.Join(parts, "; ")
+	if e.Exhausted && !e.NotRetried {
+		return "all providers failed: " + msg
+	}
+	return msg
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RouterError) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// AskAI sends the prompt to each agent in turn until one succeeds. The
+// returned response records the provider that answered and the failed
+// attempts that preceded it. When the request context is done before an
+// attempt, its error is returned, wrapping the RouterError of the attempts
+// made so far.
+func (r *Router) AskAI(opts *union.Request) (*union.Response, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts")
+	}
+	if opts.Prompt == nil {
+		return nil, errors.New("router requires a provider-neutral Prompt")
+	}
+	if len(r.Agents) == 0 {
+		return nil, errors.New("router has no agents")
+	}
+
+	retryable := r.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var attempts []union.Attempt
+	for _, agent := range r.Agents {
+		if err := ctx.Err(); err != nil {
+			if len(attempts) == 0 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %w", err, &RouterError{Attempts: attempts})
+		}
+
+		start := time.Now()
+		resp, err := r.attempt(ctx, agent, opts, attempts)
+		if err == nil {
+			resp.Attempts = attempts
+			if resp.Provider == "" {
+				resp.Provider = agentName(agent)
+			}
+			return resp, nil
+		}
+
+		attempts = append(attempts, union.Attempt{
+			Provider: agentName(agent),
+			Err:      err,
+			Error:    err.Error(),
+			Latency:  time.Since(start),
+		})
+		if !retryable(err) {
+			return nil, &RouterError{Attempts: attempts, NotRetried: true}
+		}
+	}
+
+	return nil, &RouterError{Attempts: attempts, Exhausted: true}
+}
+
+// attempt sends opts to agent. Attempts after the first are marked with
+// observe.WithRetry so observers can count them.
+func (r *Router) attempt(ctx context.Context, agent AIAgent, opts *union.Request, failed []union.Attempt) (*union.Response, error) {
+	if len(failed) > 0 {
+		ctx = observe.WithRetry(ctx, failed[len(failed)-1].Provider)
+	}
+	if r.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.AttemptTimeout)
+		defer cancel()
+	}
+
+	// each attempt gets its own copy so providers can fill in defaults
+	prompt := *opts.Prompt
+	prompt.Messages = append([]union.PromptMessage(nil), opts.Prompt.Messages...)
+
+	req := *opts
+	req.Prompt = &prompt
+	req.Context = ctx
+
+	return agent.AskAI(&req)
+}
+
+// agentName returns the provider name of an agent, falling back to its type.
+func agentName(agent AIAgent) string {
+	if p, ok := agent.(providerNamer); ok {
+		return p.Provider()
+	}
+	return fmt.Sprintf("%T", agent)
+}
@@ -0,0 +1,173 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muraduiurie/gpt/pkg/ai"
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/keypool"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+func prompt(ctx context.Context) *union.Request {
+	return &union.Request{
+		Context: ctx,
+		Prompt: &union.Prompt{
+			Messages: []union.PromptMessage{{Role: union.PromptRoleUser, Content: "hello"}},
+		},
+	}
+}
+
+// text returns a reply without a provider, so the agent name is reported.
+func text(output string) aitest.Reply {
+	return aitest.Response(nil, nil).WithOutput(output)
+}
+
+func agent(name string, replies ...aitest.Reply) *aitest.Agent {
+	a := aitest.New()
+	a.Name = name
+	a.Enqueue(replies...)
+	return a
+}
+
+func TestRouterFailsOver(t *testing.T) {
+	first := agent("first", aitest.Error(aitest.APIError(http.StatusServiceUnavailable, "overloaded")))
+	second := agent("second", aitest.Error(aitest.RateLimited(time.Second)))
+	third := agent("third", text("hi"))
+
+	resp, err := ai.NewRouter(first, second, third).AskAI(prompt(nil))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Provider != "third" {
+		t.Errorf("Provider = %q, want third", resp.Provider)
+	}
+	if resp.Output != "hi" {
+		t.Errorf("Output = %q, want hi", resp.Output)
+	}
+	if len(resp.Attempts) != 2 || resp.Attempts[0].Provider != "first" || resp.Attempts[1].Provider != "second" {
+		t.Errorf("Attempts = %+v, want first and second", resp.Attempts)
+	}
+}
+
+func TestRouterFailsOverWhenNoKeys(t *testing.T) {
+	first := agent("first", aitest.Error(fmt.Errorf("%w: pool is empty", keypool.ErrNoKeys)))
+	second := agent("second", text("hi"))
+
+	resp, err := ai.NewRouter(first, second).AskAI(prompt(nil))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Provider != "second" {
+		t.Errorf("Provider = %q, want second", resp.Provider)
+	}
+}
+
+func TestRouterStopsOnNonRetryableError(t *testing.T) {
+	first := agent("first", aitest.Error(aitest.APIError(http.StatusBadRequest, "bad request")))
+	second := agent("second", text("hi"))
+
+	_, err := ai.NewRouter(first, second).AskAI(prompt(nil))
+	var routerErr *ai.RouterError
+	if !errors.As(err, &routerErr) {
+		t.Fatalf("err = %v, want *ai.RouterError", err)
+	}
+	if len(routerErr.Attempts) != 1 {
+		t.Errorf("Attempts = %d, want 1", len(routerErr.Attempts))
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "provider first failed (not retried): ") {
+		t.Errorf("Error() = %q, want it to name the attempt that was not retried", msg)
+	}
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v, want the 400 of the first agent", err)
+	}
+	if n := len(second.Requests()); n != 0 {
+		t.Errorf("second agent got %d requests, want 0", n)
+	}
+}
+
+func TestRouterAllFail(t *testing.T) {
+	first := agent("first", aitest.Error(aitest.APIError(http.StatusInternalServerError, "boom")))
+	second := agent("second", aitest.Error(aitest.APIError(http.StatusBadGateway, "boom")))
+
+	_, err := ai.NewRouter(first, second).AskAI(prompt(nil))
+	var routerErr *ai.RouterError
+	if !errors.As(err, &routerErr) || len(routerErr.Attempts) != 2 {
+		t.Fatalf("err = %v, want *ai.RouterError with 2 attempts", err)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "all providers failed: provider first failed: ") || !strings.Contains(msg, "; provider second failed: ") {
+		t.Errorf("Error() = %q, want both attempts", msg)
+	}
+	var apiErr *union.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("err = %v, want it to unwrap to the last attempt", err)
+	}
+}
+
+func TestRouterAttemptTimeout(t *testing.T) {
+	first := agent("first", text("slow").WithDelay(time.Second))
+	second := agent("second", text("fast"))
+
+	r := ai.NewRouter(first, second)
+	r.AttemptTimeout = 10 * time.Millisecond
+	resp, err := r.AskAI(prompt(nil))
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+	if resp.Provider != "second" {
+		t.Errorf("Provider = %q, want second", resp.Provider)
+	}
+	if len(resp.Attempts) != 1 || !errors.Is(resp.Attempts[0].Err, context.DeadlineExceeded) {
+		t.Errorf("Attempts = %+v, want a deadline exceeded attempt", resp.Attempts)
+	}
+}
+
+func TestRouterContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	first := agent("first", text("hi"))
+
+	_, err := ai.NewRouter(first).AskAI(prompt(ctx))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if n := len(first.Requests()); n != 0 {
+		t.Errorf("agent got %d requests, want 0", n)
+	}
+}
+
+func TestRouterContextDoneAfterAttempt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	first := aitest.New()
+	first.Name = "first"
+	first.When(aitest.Any()).Reply(aitest.Error(aitest.APIError(http.StatusInternalServerError, "boom")))
+	second := agent("second", text("hi"))
+
+	r := ai.NewRouter(first, second)
+	r.Retryable = func(err error) bool {
+		cancel()
+		return true
+	}
+	_, err := r.AskAI(prompt(ctx))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	var routerErr *ai.RouterError
+	if !errors.As(err, &routerErr) || len(routerErr.Attempts) != 1 {
+		t.Errorf("err = %v, want the RouterError of the first attempt", err)
+	}
+}
+
+func TestRouterRequiresPrompt(t *testing.T) {
+	_, err := ai.NewRouter(aitest.New()).AskAI(&union.Request{})
+	if err == nil {
+		t.Fatal("AskAI without a Prompt succeeded")
+	}
+}
@@ -0,0 +1,92 @@
+// Package sse reads and writes server-sent events, the format the providers
+// use to stream responses.
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Event is a single server-sent event.
+type Event struct {
+	Event string
+	Data  []byte
+	ID    string
+}
+
+// Reader decodes events from a stream.
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Next returns the next event. Comment lines and events without data are
+// skipped. It returns io.EOF at the end of the stream.
+func (r *Reader) Next() (*Event, error) {
+	var ev Event
+	var data [][]byte
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err == io.EOF && len(data) > 0 {
+				ev.Data = bytes.Join(data, []byte("\n"))
+				return &ev, nil
+			}
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		if len(line) == 0 {
+			if len(data) == 0 {
+				ev = Event{}
+				continue
+			}
+			ev.Data = bytes.Join(data, []byte("\n"))
+			return &ev, nil
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			ev.Event = string(value)
+		case "data":
+			data = append(data, append([]byte(nil), value...))
+		case "id":
+			ev.ID = string(value)
+		}
+	}
+}
+
+// Write encodes ev to w and flushes it if w is an http.Flusher.
+func Write(w io.Writer, ev Event) error {
+	var sb strings.Builder
+	if ev.ID != "" {
+		fmt.Fprintf(&sb, "id: %s\n", ev.ID)
+	}
+	if ev.Event != "" {
+		fmt.Fprintf(&sb, "event: %s\n", ev.Event)
+	}
+	for _, line := range bytes.Split(ev.Data, []byte("\n")) {
+		fmt.Fprintf(&sb, "data: %s\n", line)
+	}
+	sb.WriteString("\n")
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// Package tokens estimates token counts without a model tokenizer, for
+// budgeting requests and sizing chunks.
+package tokens
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Estimate returns an estimate of the number of tokens of s for BPE
+// tokenizers such as OpenAI's: about four characters per token, but never
+// fewer tokens than words. It errs on the high side for English text.
+func Estimate(s string) int {
+	if s == "" {
+		return 0
+	}
+	byChars := (utf8.RuneCountInString(s) + 3) / 4
+	byWords := len(strings.Fields(s))
+	if byWords > byChars {
+		return byWords
+	}
+	return byChars
+}
@@ -0,0 +1,257 @@
+// Package tracing traces provider calls with OpenTelemetry, following the
+// GenAI semantic conventions.
+//
+// Every call gets a client span named "chat <model>" carrying gen_ai.system,
+// gen_ai.request.model, the token usage and the finish reasons. The trace
+// context is injected into the request headers so providers and proxies
+// that accept it can join the trace. Prompts and completions are only
+// recorded, as span events, when Options.RecordContent is set.
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans.
+const ScopeName = "github.com/muraduiurie/gpt/pkg/ai/tracing"
+
+// Attribute keys from the GenAI and general semantic conventions.
+const (
+	AttrSystem             = attribute.Key("gen_ai.system")
+	AttrOperationName      = attribute.Key("gen_ai.operation.name")
+	AttrRequestModel       = attribute.Key("gen_ai.request.model")
+	AttrRequestMaxTokens   = attribute.Key("gen_ai.request.max_tokens")
+	AttrRequestTemperature = attribute.Key("gen_ai.request.temperature")
+	AttrFinishReasons      = attribute.Key("gen_ai.response.finish_reasons")
+	AttrUsageInputTokens   = attribute.Key("gen_ai.usage.input_tokens")
+	AttrUsageOutputTokens  = attribute.Key("gen_ai.usage.output_tokens")
+	AttrPrompt             = attribute.Key("gen_ai.prompt")
+	AttrCompletion         = attribute.Key("gen_ai.completion")
+	AttrServerAddress      = attribute.Key("server.address")
+	AttrServerPort         = attribute.Key("server.port")
+	AttrErrorType          = attribute.Key("error.type")
+	AttrHTTPStatusCode     = attribute.Key("http.response.status_code")
+)
+
+// Attribute keys this package adds on top of the conventions.
+const (
+	AttrRequestStream    = attribute.Key("gen_ai.request.stream")
+	AttrRequestID        = attribute.Key("gen_ai.provider.request_id")
+	AttrTimeToFirstToken = attribute.Key("gen_ai.response.time_to_first_token")
+)
+
+// Span event names used when content recording is enabled.
+const (
+	EventPrompt     = "gen_ai.content.prompt"
+	EventCompletion = "gen_ai.content.completion"
+)
+
+type Options struct {
+	// TracerProvider creates the tracer. Defaults to the global provider.
+	TracerProvider trace.TracerProvider
+	// Propagator injects the trace context into the request headers.
+	// Defaults to the global propagator.
+	Propagator propagation.TextMapPropagator
+	// RecordContent records the request body and the generated text as
+	// span events. They may contain personal data, so it is off by default.
+	RecordContent bool
+}
+
+// Observer creates a span for every provider call.
+type Observer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	opts       Options
+}
+
+// New returns an Observer tracing with the tracer provider of opts.
+func New(opts Options) *Observer {
+	tp := opts.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	propagator := opts.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	return &Observer{
+		tracer:     tp.Tracer(ScopeName),
+		propagator: propagator,
+		opts:       opts,
+	}
+}
+
+// Start starts the span of the call and injects its context into the
+// request headers.
+func (o *Observer) Start(ctx context.Context, call *observe.Call) context.Context {
+	attrs := []attribute.KeyValue{
+		AttrSystem.String(system(call.Provider)),
+		AttrOperationName.String(call.OperationName()),
+		AttrRequestModel.String(call.Model),
+		AttrRequestStream.Bool(call.Stream),
+	}
+	attrs = append(attrs, serverAttrs(call.Endpoint)...)
+	maxTokens, temperature := requestParams(call)
+	if maxTokens > 0 {
+		attrs = append(attrs, AttrRequestMaxTokens.Int(maxTokens))
+	}
+	if temperature != nil {
+		attrs = append(attrs, AttrRequestTemperature.Float64(*temperature))
+	}
+
+	ctx, span := o.tracer.Start(ctx, call.OperationName()+" "+call.Model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	if o.opts.RecordContent {
+		span.AddEvent(EventPrompt, trace.WithAttributes(AttrPrompt.String(string(call.Body))))
+	}
+	o.propagator.Inject(ctx, propagation.HeaderCarrier(call.Header))
+
+	return ctx
+}
+
+// End records the outcome of the call and ends its span.
+func (o *Observer) End(ctx context.Context, call *observe.Call, res *observe.Result) {
+	span := trace.SpanFromContext(ctx)
+
+	if res.Metadata != nil {
+		if res.Metadata.StatusCode != 0 {
+			span.SetAttributes(AttrHTTPStatusCode.Int(res.Metadata.StatusCode))
+		}
+		if res.Metadata.RequestID != "" {
+			span.SetAttributes(AttrRequestID.String(res.Metadata.RequestID))
+		}
+	}
+	if res.Usage != nil {
+		span.SetAttributes(
+			AttrUsageInputTokens.Int(res.Usage.InputTokens),
+			AttrUsageOutputTokens.Int(res.Usage.OutputTokens),
+		)
+	}
+	if len(res.FinishReasons) > 0 {
+		span.SetAttributes(AttrFinishReasons.StringSlice(res.FinishReasons))
+	}
+	if res.TimeToFirstToken > 0 {
+		span.SetAttributes(AttrTimeToFirstToken.Float64(res.TimeToFirstToken.Seconds()))
+	}
+	if o.opts.RecordContent && res.Output != "" {
+		span.AddEvent(EventCompletion, trace.WithAttributes(AttrCompletion.String(res.Output)))
+	}
+
+	if res.Err != nil {
+		span.SetAttributes(AttrErrorType.String(errorType(res.Err)))
+		span.RecordError(res.Err)
+		span.SetStatus(codes.Error, res.Err.Error())
+	}
+	span.End()
+}
+
+// systems maps provider names to the gen_ai.system values of the
+// conventions. Other providers are reported by name.
+var systems = map[string]string{
+	"chatgpt": "openai",
+	"claude":  "anthropic",
+	"azure":   "az.ai.openai",
+	"gemini":  "gcp.gemini",
+	"mistral": "mistral_ai",
+}
+
+func system(provider string) string {
+	if s, ok := systems[provider]; ok {
+		return s
+	}
+	return provider
+}
+
+// requestParams returns the max tokens and the temperature of the call,
+// read from the request body so typed requests of every provider are
+// covered, or from the prompt when the body is not JSON.
+func requestParams(call *observe.Call) (int, *float64) {
+	var fields struct {
+		MaxTokens           *int     `json:"max_tokens"`
+		MaxOutputTokens     *int     `json:"max_output_tokens"`
+		MaxCompletionTokens *int     `json:"max_completion_tokens"`
+		Temperature         *float64 `json:"temperature"`
+		GenerationConfig    struct {
+			MaxOutputTokens *int     `json:"maxOutputTokens"`
+			Temperature     *float64 `json:"temperature"`
+		} `json:"generationConfig"`
+		Options struct {
+			NumPredict  *int     `json:"num_predict"`
+			Temperature *float64 `json:"temperature"`
+		} `json:"options"`
+	}
+	if err := json.Unmarshal(call.Body, &fields); err != nil {
+		if call.Request != nil && call.Request.Prompt != nil {
+			return call.Request.Prompt.MaxTokens, call.Request.Prompt.Temperature
+		}
+		return 0, nil
+	}
+
+	var maxTokens int
+	for _, n := range []*int{fields.MaxTokens, fields.MaxOutputTokens, fields.MaxCompletionTokens, fields.GenerationConfig.MaxOutputTokens, fields.Options.NumPredict} {
+		if n != nil {
+			maxTokens = *n
+			break
+		}
+	}
+	var temperature *float64
+	for _, t := range []*float64{fields.Temperature, fields.GenerationConfig.Temperature, fields.Options.Temperature} {
+		if t != nil {
+			temperature = t
+			break
+		}
+	}
+	return maxTokens, temperature
+}
+
+// errorType returns the status code of API errors and a generic type
+// otherwise, as the conventions recommend low-cardinality values.
+func errorType(err error) string {
+	var apiErr *union.APIError
+	if errors.As(err, &apiErr) {
+		return strconv.Itoa(apiErr.StatusCode)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+	return "_OTHER"
+}
+
+func serverAttrs(endpoint string) []attribute.KeyValue {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{AttrServerAddress.String(u.Hostname())}
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		}
+	}
+	if n, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, AttrServerPort.Int(n))
+	}
+	return attrs
+}
@@ -0,0 +1,172 @@
+package tracing_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/muraduiurie/gpt/pkg/ai/aitest"
+	"github.com/muraduiurie/gpt/pkg/ai/observe"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/chatgpt"
+	"github.com/muraduiurie/gpt/pkg/ai/providers/claude"
+	"github.com/muraduiurie/gpt/pkg/ai/tracing"
+	cgtypes "github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+	cltypes "github.com/muraduiurie/gpt/pkg/ai/types/claude"
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newObserver(t *testing.T) (observe.Observer, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(t.Context()) })
+	return tracing.New(tracing.Options{
+		TracerProvider: tp,
+		Propagator:     propagation.TraceContext{},
+	}), exporter
+}
+
+func span(t *testing.T, exporter *tracetest.InMemoryExporter) tracetest.SpanStub {
+	t.Helper()
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+func attrs(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range s.Attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestClaudeSpan(t *testing.T) {
+	srv := aitest.NewAnthropicServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello there" }
+	observer, exporter := newObserver(t)
+	c := &claude.Client{ApiToken: aitest.DefaultAPIKey, TextInputEndpoint: srv.Endpoint(), Observer: observer}
+
+	temperature := 0.2
+	_, err := c.AskAI(&union.Request{TextRequest: &cltypes.TextInputRequest{
+		Model:       cltypes.ClaudeAIModelSonnet4_20250514,
+		MaxTokens:   256,
+		Temperature: &temperature,
+		Messages:    []cltypes.TextInputRequestMessage{{Content: "Say hello"}},
+	}})
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+
+	s := span(t, exporter)
+	if want := "chat " + string(cltypes.ClaudeAIModelSonnet4_20250514); s.Name != want {
+		t.Errorf("Name = %q, want %q", s.Name, want)
+	}
+	a := attrs(s)
+	if got := a[tracing.AttrSystem].AsString(); got != "anthropic" {
+		t.Errorf("gen_ai.system = %q, want anthropic", got)
+	}
+	if got := a[tracing.AttrRequestMaxTokens].AsInt64(); got != 256 {
+		t.Errorf("gen_ai.request.max_tokens = %d, want 256", got)
+	}
+	if got := a[tracing.AttrRequestTemperature].AsFloat64(); got != 0.2 {
+		t.Errorf("gen_ai.request.temperature = %v, want 0.2", got)
+	}
+	if a[tracing.AttrUsageInputTokens].AsInt64() == 0 || a[tracing.AttrUsageOutputTokens].AsInt64() == 0 {
+		t.Errorf("usage attributes missing: %v", s.Attributes)
+	}
+	if got := a[tracing.AttrHTTPStatusCode].AsInt64(); got != http.StatusOK {
+		t.Errorf("http.response.status_code = %d, want 200", got)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Header.Get("Traceparent") == "" {
+		t.Error("trace context was not sent to the provider")
+	}
+}
+
+func TestChatGPTSystem(t *testing.T) {
+	srv := aitest.NewOpenAIServer()
+	defer srv.Close()
+	observer, exporter := newObserver(t)
+	c := &chatgpt.Client{ApiToken: aitest.DefaultAPIKey, TextInputEndpoint: srv.Endpoint(), Observer: observer}
+
+	maxTokens := 64
+	_, err := c.AskAI(&union.Request{TextRequest: &cgtypes.TextInputRequest{
+		Model:           cgtypes.AiModelGpt4_1,
+		Input:           "Say hello",
+		MaxOutputTokens: &maxTokens,
+	}})
+	if err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+
+	a := attrs(span(t, exporter))
+	if got := a[tracing.AttrSystem].AsString(); got != "openai" {
+		t.Errorf("gen_ai.system = %q, want openai", got)
+	}
+	if got := a[tracing.AttrRequestMaxTokens].AsInt64(); got != 64 {
+		t.Errorf("gen_ai.request.max_tokens = %d, want 64", got)
+	}
+	if _, ok := a[tracing.AttrRequestTemperature]; ok {
+		t.Error("gen_ai.request.temperature recorded for a request without one")
+	}
+}
+
+func TestErrorSpan(t *testing.T) {
+	srv := aitest.NewAnthropicServer()
+	defer srv.Close()
+	srv.FailNext(http.StatusServiceUnavailable, 1)
+	observer, exporter := newObserver(t)
+	c := &claude.Client{ApiToken: aitest.DefaultAPIKey, TextInputEndpoint: srv.Endpoint(), Observer: observer}
+
+	_, err := c.AskAI(&union.Request{TextRequest: &cltypes.TextInputRequest{
+		Messages: []cltypes.TextInputRequestMessage{{Content: "Say hello"}},
+	}})
+	if err == nil {
+		t.Fatal("AskAI succeeded")
+	}
+
+	s := span(t, exporter)
+	if s.Status.Code != codes.Error {
+		t.Errorf("status = %v, want Error", s.Status.Code)
+	}
+	if got := attrs(s)[tracing.AttrErrorType].AsString(); got != "503" {
+		t.Errorf("error.type = %q, want 503", got)
+	}
+}
+
+func TestRecordContent(t *testing.T) {
+	srv := aitest.NewAnthropicServer()
+	defer srv.Close()
+	srv.Respond = func(string) string { return "Hello there" }
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(t.Context())
+	c := &claude.Client{
+		ApiToken:          aitest.DefaultAPIKey,
+		TextInputEndpoint: srv.Endpoint(),
+		Observer:          tracing.New(tracing.Options{TracerProvider: tp, RecordContent: true}),
+	}
+
+	if _, err := c.AskAI(&union.Request{TextRequest: &cltypes.TextInputRequest{
+		Messages: []cltypes.TextInputRequestMessage{{Content: "Say hello"}},
+	}}); err != nil {
+		t.Fatalf("AskAI: %v", err)
+	}
+
+	var events []string
+	for _, ev := range span(t, exporter).Events {
+		events = append(events, ev.Name)
+	}
+	if len(events) != 2 || events[0] != tracing.EventPrompt || events[1] != tracing.EventCompletion {
+		t.Errorf("events = %v, want the prompt and the completion", events)
+	}
+}
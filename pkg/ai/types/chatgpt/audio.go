@@ -0,0 +1,58 @@
+package chatgpt
+
+import "encoding/json"
+
+type (
+	// Voice is a text-to-speech voice.
+	Voice string
+	// AudioFormat is the encoding of generated speech.
+	AudioFormat string
+)
+
+const (
+	// AiModelGpt4oMiniTTS is the speech model that follows instructions.
+	AiModelGpt4oMiniTTS ChatGPTAIModel = "gpt-4o-mini-tts"
+
+	// voices
+	VoiceAlloy   Voice = "alloy"
+	VoiceAsh     Voice = "ash"
+	VoiceBallad  Voice = "ballad"
+	VoiceCoral   Voice = "coral"
+	VoiceEcho    Voice = "echo"
+	VoiceFable   Voice = "fable"
+	VoiceNova    Voice = "nova"
+	VoiceOnyx    Voice = "onyx"
+	VoiceSage    Voice = "sage"
+	VoiceShimmer Voice = "shimmer"
+	VoiceVerse   Voice = "verse"
+
+	// audio formats
+	AudioFormatMP3  AudioFormat = "mp3"
+	AudioFormatOpus AudioFormat = "opus"
+	AudioFormatAAC  AudioFormat = "aac"
+	AudioFormatFLAC AudioFormat = "flac"
+	AudioFormatWAV  AudioFormat = "wav"
+	// AudioFormatPCM is raw 24kHz 16-bit signed little-endian mono samples.
+	AudioFormatPCM AudioFormat = "pcm"
+)
+
+// speech (`/v1/audio/speech`)
+
+func (t *SpeechRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+type SpeechRequest struct {
+	// Model is AiModelTTS1, AiModelTTS1_HD or AiModelGpt4oMiniTTS.
+	Model ChatGPTAIModel `json:"model"`
+	// Input is the text to speak, up to 4096 characters.
+	Input string `json:"input"`
+	Voice Voice  `json:"voice"`
+	// ResponseFormat defaults to AudioFormatMP3.
+	ResponseFormat AudioFormat `json:"response_format,omitempty"`
+	// Speed is between 0.25 and 4.0; 1.0 is the normal speed.
+	Speed *float64 `json:"speed,omitempty"`
+	// Instructions control the tone of the voice, e.g. "Speak calmly".
+	// AiModelGpt4oMiniTTS only.
+	Instructions string `json:"instructions,omitempty"`
+}
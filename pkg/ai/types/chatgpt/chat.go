@@ -0,0 +1,120 @@
+package chatgpt
+
+import "encoding/json"
+
+const (
+	// ChatGPTAIRoleTool is the role of tool results in chat completions.
+	ChatGPTAIRoleTool ChatGPTAIRole = "tool"
+)
+
+// chat completions (`/v1/chat/completions`)
+
+func (t *ChatCompletionRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+type ChatCompletionRequest struct {
+	Model               ChatGPTAIModel                `json:"model"`
+	Messages            []ChatCompletionMessage       `json:"messages"`
+	N                   *int                          `json:"n,omitempty"`
+	LogitBias           map[string]int                `json:"logit_bias,omitempty"`
+	Seed                *int                          `json:"seed,omitempty"`
+	ResponseFormat      *ChatCompletionResponseFormat `json:"response_format,omitempty"`
+	Tools               []ChatCompletionTool          `json:"tools,omitempty"`
+	ToolChoice          interface{}                   `json:"tool_choice,omitempty"`
+	ParallelToolCalls   *bool                         `json:"parallel_tool_calls,omitempty"`
+	MaxCompletionTokens *int                          `json:"max_completion_tokens,omitempty"`
+	Temperature         *float64                      `json:"temperature,omitempty"`
+	TopP                *float64                      `json:"top_p,omitempty"`
+	FrequencyPenalty    *float64                      `json:"frequency_penalty,omitempty"`
+	PresencePenalty     *float64                      `json:"presence_penalty,omitempty"`
+	Stop                []string                      `json:"stop,omitempty"`
+	User                string                        `json:"user,omitempty"`
+	Stream              bool                          `json:"stream,omitempty"`
+	StreamOptions       *ChatCompletionStreamOptions  `json:"stream_options,omitempty"`
+}
+
+type ChatCompletionMessage struct {
+	Role       ChatGPTAIRole            `json:"role"`
+	Content    string                   `json:"content"`
+	Name       string                   `json:"name,omitempty"`
+	ToolCalls  []ChatCompletionToolCall `json:"tool_calls,omitempty"`
+	ToolCallId string                   `json:"tool_call_id,omitempty"`
+	Refusal    string                   `json:"refusal,omitempty"`
+}
+
+type ChatCompletionResponseFormat struct {
+	// Type is "text", "json_object" or "json_schema".
+	Type       string                    `json:"type"`
+	JSONSchema *ChatCompletionJSONSchema `json:"json_schema,omitempty"`
+}
+
+type ChatCompletionJSONSchema struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Schema      interface{} `json:"schema,omitempty"`
+	Strict      *bool       `json:"strict,omitempty"`
+}
+
+type ChatCompletionTool struct {
+	// Type is "function".
+	Type     string                 `json:"type"`
+	Function ChatCompletionFunction `json:"function"`
+}
+
+type ChatCompletionFunction struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Parameters  interface{} `json:"parameters,omitempty"`
+	Strict      *bool       `json:"strict,omitempty"`
+}
+
+type ChatCompletionToolCall struct {
+	Id       string                         `json:"id"`
+	Type     string                         `json:"type"`
+	Function ChatCompletionToolCallFunction `json:"function"`
+}
+
+type ChatCompletionToolCallFunction struct {
+	Name string `json:"name"`
+	// Arguments is the JSON encoded arguments object.
+	Arguments string `json:"arguments"`
+}
+
+type ChatCompletionStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+func (t *ChatCompletionResponse) Unmarshal(b []byte) error {
+	return json.Unmarshal(b, t)
+}
+
+type ChatCompletionResponse struct {
+	Id                string                 `json:"id"`
+	Object            string                 `json:"object"`
+	Created           int                    `json:"created"`
+	Model             ChatGPTAIModel         `json:"model"`
+	Choices           []ChatCompletionChoice `json:"choices"`
+	Usage             ChatCompletionUsage    `json:"usage"`
+	SystemFingerprint string                 `json:"system_fingerprint"`
+	ServiceTier       string                 `json:"service_tier,omitempty"`
+}
+
+type ChatCompletionChoice struct {
+	Index        int                   `json:"index"`
+	Message      ChatCompletionMessage `json:"message"`
+	Logprobs     interface{}           `json:"logprobs"`
+	FinishReason string                `json:"finish_reason"`
+}
+
+type ChatCompletionUsage struct {
+	PromptTokens            int                                    `json:"prompt_tokens"`
+	CompletionTokens        int                                    `json:"completion_tokens"`
+	TotalTokens             int                                    `json:"total_tokens"`
+	PromptTokensDetails     ChatCompletionUsagePromptTokensDetails `json:"prompt_tokens_details"`
+	CompletionTokensDetails ResponseUsageOutputTokensDetails       `json:"completion_tokens_details"`
+}
+
+type ChatCompletionUsagePromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
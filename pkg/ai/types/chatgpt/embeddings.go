@@ -0,0 +1,50 @@
+package chatgpt
+
+import "encoding/json"
+
+const (
+	// embedding models
+	AiModelTextEmbedding3Small ChatGPTAIModel = "text-embedding-3-small"
+	AiModelTextEmbedding3Large ChatGPTAIModel = "text-embedding-3-large"
+	AiModelTextEmbeddingAda002 ChatGPTAIModel = "text-embedding-ada-002"
+)
+
+// embeddings (`/v1/embeddings`)
+
+func (t *EmbeddingRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+type EmbeddingRequest struct {
+	Model ChatGPTAIModel `json:"model"`
+	Input []string       `json:"input"`
+	// Dimensions shortens the vectors; text-embedding-3 models only.
+	Dimensions *int `json:"dimensions,omitempty"`
+	// EncodingFormat is "float" or "base64". The client only decodes
+	// "float".
+	EncodingFormat string `json:"encoding_format,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+func (t *EmbeddingResponse) Unmarshal(b []byte) error {
+	return json.Unmarshal(b, t)
+}
+
+type EmbeddingResponse struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
+}
+
+type EmbeddingData struct {
+	Object string `json:"object"`
+	// Index is the position of the input the vector belongs to.
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
@@ -0,0 +1,196 @@
+package chatgpt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+type (
+	ImageSize       string
+	ImageQuality    string
+	ImageBackground string
+	ImageFormat     string
+)
+
+const (
+	// image models
+	AiModelGptImage1 ChatGPTAIModel = "gpt-image-1"
+	AiModelDallE3    ChatGPTAIModel = "dall-e-3"
+	AiModelDallE2    ChatGPTAIModel = "dall-e-2"
+
+	// image sizes; gpt-image-1 supports auto and the first three, dall-e-3
+	// 1024x1024 and the wide and tall ones, dall-e-2 the square ones
+	ImageSizeAuto      ImageSize = "auto"
+	ImageSize1024      ImageSize = "1024x1024"
+	ImageSize1536x1024 ImageSize = "1536x1024"
+	ImageSize1024x1536 ImageSize = "1024x1536"
+	ImageSize1792x1024 ImageSize = "1792x1024"
+	ImageSize1024x1792 ImageSize = "1024x1792"
+	ImageSize512       ImageSize = "512x512"
+	ImageSize256       ImageSize = "256x256"
+
+	// image qualities; low, medium and high are gpt-image-1's, hd and
+	// standard dall-e-3's
+	ImageQualityAuto     ImageQuality = "auto"
+	ImageQualityLow      ImageQuality = "low"
+	ImageQualityMedium   ImageQuality = "medium"
+	ImageQualityHigh     ImageQuality = "high"
+	ImageQualityHD       ImageQuality = "hd"
+	ImageQualityStandard ImageQuality = "standard"
+
+	// image backgrounds, gpt-image-1 only; transparent needs png or webp
+	ImageBackgroundAuto        ImageBackground = "auto"
+	ImageBackgroundTransparent ImageBackground = "transparent"
+	ImageBackgroundOpaque      ImageBackground = "opaque"
+
+	// image output formats, gpt-image-1 only
+	ImageFormatPNG  ImageFormat = "png"
+	ImageFormatJPEG ImageFormat = "jpeg"
+	ImageFormatWebP ImageFormat = "webp"
+)
+
+// image generation (`/v1/images/generations`)
+
+func (t *ImageRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+type ImageRequest struct {
+	Model  ChatGPTAIModel `json:"model"`
+	Prompt string         `json:"prompt"`
+	// N is the number of images, 1 to 10; dall-e-3 makes only one.
+	N          *int            `json:"n,omitempty"`
+	Size       ImageSize       `json:"size,omitempty"`
+	Quality    ImageQuality    `json:"quality,omitempty"`
+	Background ImageBackground `json:"background,omitempty"`
+	// OutputFormat defaults to png.
+	OutputFormat ImageFormat `json:"output_format,omitempty"`
+	// OutputCompression is the jpeg or webp compression, 0 to 100%.
+	OutputCompression *int `json:"output_compression,omitempty"`
+	// Moderation is "auto" or "low", gpt-image-1 only.
+	Moderation string `json:"moderation,omitempty"`
+	// ResponseFormat is "url" or "b64_json", for the dall-e models;
+	// gpt-image-1 always returns base64.
+	ResponseFormat string `json:"response_format,omitempty"`
+	// Style is "vivid" or "natural", dall-e-3 only.
+	Style string `json:"style,omitempty"`
+	User  string `json:"user,omitempty"`
+}
+
+// image editing (`/v1/images/edits`), sent as a multipart form
+
+// ImageFile is an image to upload.
+type ImageFile struct {
+	File io.Reader
+	// FileName tells the image format by its extension. Defaults to the
+	// name of File when it is an *os.File.
+	FileName string
+}
+
+type ImageEditRequest struct {
+	// Images are the images to edit: png, webp or jpg under 50MB, up to 16
+	// for gpt-image-1; one square png under 4MB for dall-e-2.
+	Images []ImageFile `json:"-"`
+	// Mask is a png with the size of the first image whose transparent
+	// areas mark where to edit.
+	Mask         *ImageFile      `json:"-"`
+	Model        ChatGPTAIModel  `json:"model"`
+	Prompt       string          `json:"prompt"`
+	N            *int            `json:"n,omitempty"`
+	Size         ImageSize       `json:"size,omitempty"`
+	Quality      ImageQuality    `json:"quality,omitempty"`
+	Background   ImageBackground `json:"background,omitempty"`
+	OutputFormat ImageFormat     `json:"output_format,omitempty"`
+	// InputFidelity is "high" or "low": how closely to keep the style and
+	// features of the images, gpt-image-1 only.
+	InputFidelity  string `json:"input_fidelity,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+func (t *ImageEditRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// Multipart returns the multipart form of the request and its content
+// type. Several images are sent as `image[]`.
+func (t *ImageEditRequest) Multipart() ([]byte, string, error) {
+	if len(t.Images) == 0 {
+		return nil, "", errors.New("image is required")
+	}
+	fields := [][2]string{
+		{"model", string(t.Model)},
+		{"prompt", t.Prompt},
+		{"size", string(t.Size)},
+		{"quality", string(t.Quality)},
+		{"background", string(t.Background)},
+		{"output_format", string(t.OutputFormat)},
+		{"input_fidelity", t.InputFidelity},
+		{"response_format", t.ResponseFormat},
+		{"user", t.User},
+	}
+	if t.N != nil {
+		fields = append(fields, [2]string{"n", strconv.Itoa(*t.N)})
+	}
+
+	field := "image"
+	if len(t.Images) > 1 {
+		field = "image[]"
+	}
+	var files []formFile
+	for _, img := range t.Images {
+		files = append(files, formFile{field: field, name: img.FileName, r: img.File})
+	}
+	if t.Mask != nil {
+		files = append(files, formFile{field: "mask", name: t.Mask.FileName, r: t.Mask.File})
+	}
+	return multipartForm(fields, files...)
+}
+
+func (t *ImageResponse) Unmarshal(b []byte) error {
+	return json.Unmarshal(b, t)
+}
+
+type ImageResponse struct {
+	Created int64       `json:"created"`
+	Data    []ImageData `json:"data"`
+	// Background, OutputFormat, Quality and Size are the settings used by
+	// gpt-image-1.
+	Background   ImageBackground `json:"background,omitempty"`
+	OutputFormat ImageFormat     `json:"output_format,omitempty"`
+	Quality      ImageQuality    `json:"quality,omitempty"`
+	Size         ImageSize       `json:"size,omitempty"`
+	Usage        *ImageUsage     `json:"usage,omitempty"`
+}
+
+// ImageData is a generated image, either base64 encoded or at a URL valid
+// for an hour.
+type ImageData struct {
+	B64JSON string `json:"b64_json,omitempty"`
+	URL     string `json:"url,omitempty"`
+	// RevisedPrompt is the prompt dall-e-3 actually used.
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// Decode returns the bytes of a base64 encoded image.
+func (d *ImageData) Decode() ([]byte, error) {
+	if d.B64JSON == "" {
+		return nil, errors.New("image is not base64 encoded")
+	}
+	return base64.StdEncoding.DecodeString(d.B64JSON)
+}
+
+type ImageUsage struct {
+	InputTokens        int                    `json:"input_tokens"`
+	OutputTokens       int                    `json:"output_tokens"`
+	TotalTokens        int                    `json:"total_tokens"`
+	InputTokensDetails ImageUsageInputDetails `json:"input_tokens_details"`
+}
+
+type ImageUsageInputDetails struct {
+	TextTokens  int `json:"text_tokens"`
+	ImageTokens int `json:"image_tokens"`
+}
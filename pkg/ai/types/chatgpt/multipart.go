@@ -0,0 +1,79 @@
+package chatgpt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// quoteEscaper escapes the quoted parameters of a part, as mime/multipart
+// does.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// formFile is a file field of a multipart form.
+type formFile struct {
+	field string
+	// name tells the file format, and the content type of the part, by its
+	// extension. Defaults to the name of r when it is an *os.File.
+	name string
+	r    io.Reader
+}
+
+// multipartForm writes the non-empty fields and the files, and returns the
+// form with its content type.
+func multipartForm(fields [][2]string, files ...formFile) ([]byte, string, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		if err := mw.WriteField(f[0], f[1]); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for _, f := range files {
+		if f.r == nil {
+			return nil, "", fmt.Errorf("%s is required", f.field)
+		}
+		name := f.name
+		if name == "" {
+			if osFile, ok := f.r.(*os.File); ok {
+				name = filepath.Base(osFile.Name())
+			}
+		}
+		if name == "" {
+			return nil, "", errors.New("file name is required: its extension tells the file format")
+		}
+
+		// the API checks the content type of images, which CreateFormFile
+		// always sets to application/octet-stream
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(f.field), quoteEscaper.Replace(name)))
+		header.Set("Content-Type", contentType)
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err = io.Copy(part, f.r); err != nil {
+			return nil, "", fmt.Errorf("read %s: %w", name, err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
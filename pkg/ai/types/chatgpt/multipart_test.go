@@ -0,0 +1,57 @@
+package chatgpt
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+func TestMultipartFormContentTypes(t *testing.T) {
+	body, contentType, err := multipartForm([][2]string{{"prompt", "a cat"}, {"size", ""}},
+		formFile{field: "image[]", name: "cat.png", r: strings.NewReader("png")},
+		formFile{field: "mask", name: `my "mask".webp`, r: strings.NewReader("webp")},
+		formFile{field: "file", name: "data.unknownext", r: strings.NewReader("data")},
+	)
+	if err != nil {
+		t.Fatalf("multipartForm: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("content type %q: %v", contentType, err)
+	}
+
+	want := []struct{ field, name, contentType, content string }{
+		{"prompt", "", "", "a cat"},
+		{"image[]", "cat.png", "image/png", "png"},
+		{"mask", `my "mask".webp`, "image/webp", "webp"},
+		{"file", "data.unknownext", "application/octet-stream", "data"},
+	}
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for _, w := range want {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		if part.FormName() != w.field || part.FileName() != w.name {
+			t.Errorf("part %s %q, want %s %q", part.FormName(), part.FileName(), w.field, w.name)
+		}
+		if w.contentType != "" && part.Header.Get("Content-Type") != w.contentType {
+			t.Errorf("%s: Content-Type = %q, want %q", w.field, part.Header.Get("Content-Type"), w.contentType)
+		}
+		if b, _ := io.ReadAll(part); string(b) != w.content {
+			t.Errorf("%s: content = %q, want %q", w.field, b, w.content)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("NextPart = %v, want io.EOF after the files", err)
+	}
+}
+
+func TestMultipartFormRequiresName(t *testing.T) {
+	if _, _, err := multipartForm(nil, formFile{field: "image", r: strings.NewReader("png")}); err == nil {
+		t.Error("multipartForm accepted a file without a name")
+	}
+}
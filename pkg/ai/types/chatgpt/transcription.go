@@ -0,0 +1,165 @@
+package chatgpt
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// TranscriptFormat is the format of a transcription response.
+type TranscriptFormat string
+
+const (
+	// transcription models
+	AiModelWhisper1            ChatGPTAIModel = "whisper-1"
+	AiModelGpt4oTranscribe     ChatGPTAIModel = "gpt-4o-transcribe"
+	AiModelGpt4oMiniTranscribe ChatGPTAIModel = "gpt-4o-mini-transcribe"
+
+	// transcript formats
+	TranscriptFormatJSON TranscriptFormat = "json"
+	TranscriptFormatText TranscriptFormat = "text"
+	// TranscriptFormatVerboseJSON adds the language, the duration, the
+	// segments and the words; whisper-1 only.
+	TranscriptFormatVerboseJSON TranscriptFormat = "verbose_json"
+	TranscriptFormatSRT         TranscriptFormat = "srt"
+	TranscriptFormatVTT         TranscriptFormat = "vtt"
+
+	// timestamp granularities of verbose_json transcriptions
+	GranularityWord    = "word"
+	GranularitySegment = "segment"
+)
+
+// transcriptions (`/v1/audio/transcriptions`) and translations
+// (`/v1/audio/translations`), sent as multipart forms
+
+// TranscriptionRequest transcribes audio in its language.
+type TranscriptionRequest struct {
+	// File is the audio: flac, mp3, mp4, mpeg, mpga, m4a, ogg, wav or webm,
+	// up to 25 MB.
+	File io.Reader `json:"-"`
+	// FileName tells the audio format by its extension. Defaults to the
+	// name of File when it is an *os.File.
+	FileName string         `json:"file_name"`
+	Model    ChatGPTAIModel `json:"model"`
+	// Language is the ISO-639-1 code of the audio language, e.g. "en".
+	// Setting it improves accuracy and latency.
+	Language string `json:"language,omitempty"`
+	// Prompt guides the style, or continues a previous segment.
+	Prompt         string           `json:"prompt,omitempty"`
+	ResponseFormat TranscriptFormat `json:"response_format,omitempty"`
+	Temperature    *float64         `json:"temperature,omitempty"`
+	// TimestampGranularities are GranularityWord and GranularitySegment;
+	// they need TranscriptFormatVerboseJSON.
+	TimestampGranularities []string `json:"timestamp_granularities,omitempty"`
+}
+
+func (t *TranscriptionRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// Multipart returns the multipart form of the request and its content
+// type.
+func (t *TranscriptionRequest) Multipart() ([]byte, string, error) {
+	fields := [][2]string{
+		{"model", string(t.Model)},
+		{"language", t.Language},
+		{"prompt", t.Prompt},
+		{"response_format", string(t.ResponseFormat)},
+	}
+	if t.Temperature != nil {
+		fields = append(fields, [2]string{"temperature", strconv.FormatFloat(*t.Temperature, 'f', -1, 64)})
+	}
+	for _, g := range t.TimestampGranularities {
+		fields = append(fields, [2]string{"timestamp_granularities[]", g})
+	}
+	return multipartForm(fields, formFile{field: "file", name: t.FileName, r: t.File})
+}
+
+// TranslationRequest transcribes audio into English.
+type TranslationRequest struct {
+	// File and FileName are as in TranscriptionRequest.
+	File     io.Reader      `json:"-"`
+	FileName string         `json:"file_name"`
+	Model    ChatGPTAIModel `json:"model"`
+	// Prompt guides the style; it should be in English.
+	Prompt         string           `json:"prompt,omitempty"`
+	ResponseFormat TranscriptFormat `json:"response_format,omitempty"`
+	Temperature    *float64         `json:"temperature,omitempty"`
+}
+
+func (t *TranslationRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// Multipart returns the multipart form of the request and its content
+// type.
+func (t *TranslationRequest) Multipart() ([]byte, string, error) {
+	fields := [][2]string{
+		{"model", string(t.Model)},
+		{"prompt", t.Prompt},
+		{"response_format", string(t.ResponseFormat)},
+	}
+	if t.Temperature != nil {
+		fields = append(fields, [2]string{"temperature", strconv.FormatFloat(*t.Temperature, 'f', -1, 64)})
+	}
+	return multipartForm(fields, formFile{field: "file", name: t.FileName, r: t.File})
+}
+
+// Transcription is a transcription or a translation. For the text, srt and
+// vtt formats only Text is set, holding the whole response.
+type Transcription struct {
+	Text string `json:"text"`
+	// Language, Duration, Segments and Words are set by verbose_json.
+	Language string `json:"language,omitempty"`
+	// Duration is the length of the audio in seconds.
+	Duration float64                `json:"duration,omitempty"`
+	Segments []TranscriptionSegment `json:"segments,omitempty"`
+	Words    []TranscriptionWord    `json:"words,omitempty"`
+	Usage    *TranscriptionUsage    `json:"usage,omitempty"`
+}
+
+// TranscriptionSegment is a span of the audio. Times are in seconds.
+type TranscriptionSegment struct {
+	Id               int     `json:"id"`
+	Seek             int     `json:"seek"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Tokens           []int   `json:"tokens,omitempty"`
+	Temperature      float64 `json:"temperature"`
+	AvgLogprob       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+	// NoSpeechProb is the probability that the segment is silence.
+	NoSpeechProb float64 `json:"no_speech_prob"`
+}
+
+// TranscriptionWord is a word with its times in seconds.
+type TranscriptionWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// TranscriptionUsage is billed in tokens by the gpt-4o models and in
+// seconds of audio by whisper-1.
+type TranscriptionUsage struct {
+	// Type is "tokens" or "duration".
+	Type         string  `json:"type"`
+	InputTokens  int     `json:"input_tokens,omitempty"`
+	OutputTokens int     `json:"output_tokens,omitempty"`
+	TotalTokens  int     `json:"total_tokens,omitempty"`
+	Seconds      float64 `json:"seconds,omitempty"`
+}
+
+// UnmarshalTranscription decodes a response of the given format.
+func UnmarshalTranscription(b []byte, format TranscriptFormat) (*Transcription, error) {
+	switch format {
+	case TranscriptFormatText, TranscriptFormatSRT, TranscriptFormatVTT:
+		return &Transcription{Text: string(b)}, nil
+	}
+	var t Transcription
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
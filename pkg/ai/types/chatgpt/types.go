@@ -28,8 +28,12 @@ const (
 )
 
 type TextInputRequest struct {
-	Model ChatGPTAIModel `json:"model"`
-	Input string         `json:"input"`
+	Model           ChatGPTAIModel `json:"model"`
+	Input           string         `json:"input"`
+	Instructions    string         `json:"instructions,omitempty"`
+	MaxOutputTokens *int           `json:"max_output_tokens,omitempty"`
+	Temperature     *float64       `json:"temperature,omitempty"`
+	Stream          bool           `json:"stream,omitempty"`
 }
 
 func (t *TextInputRequest) Marshal() ([]byte, error) {
@@ -23,9 +23,12 @@ func (t *TextInputRequest) Marshal() ([]byte, error) {
 }
 
 type TextInputRequest struct {
-	Model     ClaudeAIModel             `json:"model"`
-	MaxTokens int                       `json:"max_tokens,omitempty"`
-	Messages  []TextInputRequestMessage `json:"messages"`
+	Model       ClaudeAIModel             `json:"model"`
+	MaxTokens   int                       `json:"max_tokens,omitempty"`
+	System      string                    `json:"system,omitempty"`
+	Temperature *float64                  `json:"temperature,omitempty"`
+	Messages    []TextInputRequestMessage `json:"messages"`
+	Stream      bool                      `json:"stream,omitempty"`
 }
 
 type TextInputRequestMessage struct {
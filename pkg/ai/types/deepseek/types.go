@@ -25,15 +25,15 @@ func (t *TextInputRequest) Marshal() ([]byte, error) {
 type TextInputRequest struct {
 	Messages         []TextInputRequestMessage       `json:"messages"`
 	Model            DeepSeekAIModel                 `json:"model"`
-	FrequencyPenalty *int                            `json:"frequency_penalty,omitempty"`
+	FrequencyPenalty *float64                        `json:"frequency_penalty,omitempty"`
 	MaxTokens        *int                            `json:"max_tokens,omitempty"`
-	PresencePenalty  *int                            `json:"presence_penalty,omitempty"`
+	PresencePenalty  *float64                        `json:"presence_penalty,omitempty"`
 	ResponseFormat   *TextInputRequestResponseFormat `json:"response_format,omitempty"`
 	Stop             interface{}                     `json:"stop,omitempty"`
 	Stream           bool                            `json:"stream,omitempty"`
 	StreamOptions    interface{}                     `json:"stream_options,omitempty"`
-	Temperature      *int                            `json:"temperature,omitempty"`
-	TopP             *int                            `json:"top_p,omitempty"`
+	Temperature      *float64                        `json:"temperature,omitempty"`
+	TopP             *float64                        `json:"top_p,omitempty"`
 	Tools            interface{}                     `json:"tools,omitempty"`
 	ToolChoice       *string                         `json:"tool_choice,omitempty"`
 	Logprobs         bool                            `json:"logprobs,omitempty"`
@@ -0,0 +1,166 @@
+package gemini
+
+import "encoding/json"
+
+type (
+	GeminiAIModel      string
+	GeminiAIRole       string
+	HarmCategory       string
+	HarmBlockThreshold string
+)
+
+const (
+	// models
+	GeminiAIModel2_5Pro       GeminiAIModel = "gemini-2.5-pro"
+	GeminiAIModel2_5Flash     GeminiAIModel = "gemini-2.5-flash"
+	GeminiAIModel2_5FlashLite GeminiAIModel = "gemini-2.5-flash-lite"
+	GeminiAIModel2_0Flash     GeminiAIModel = "gemini-2.0-flash"
+
+	// roles
+	GeminiAIRoleUser  GeminiAIRole = "user"
+	GeminiAIRoleModel GeminiAIRole = "model"
+
+	// harm categories
+	HarmCategoryHarassment       HarmCategory = "HARM_CATEGORY_HARASSMENT"
+	HarmCategoryHateSpeech       HarmCategory = "HARM_CATEGORY_HATE_SPEECH"
+	HarmCategorySexuallyExplicit HarmCategory = "HARM_CATEGORY_SEXUALLY_EXPLICIT"
+	HarmCategoryDangerousContent HarmCategory = "HARM_CATEGORY_DANGEROUS_CONTENT"
+	HarmCategoryCivicIntegrity   HarmCategory = "HARM_CATEGORY_CIVIC_INTEGRITY"
+
+	// harm block thresholds
+	HarmBlockThresholdLowAndAbove    HarmBlockThreshold = "BLOCK_LOW_AND_ABOVE"
+	HarmBlockThresholdMediumAndAbove HarmBlockThreshold = "BLOCK_MEDIUM_AND_ABOVE"
+	HarmBlockThresholdOnlyHigh       HarmBlockThreshold = "BLOCK_ONLY_HIGH"
+	HarmBlockThresholdNone           HarmBlockThreshold = "BLOCK_NONE"
+	HarmBlockThresholdOff            HarmBlockThreshold = "OFF"
+)
+
+func (t *TextInputRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// TextInputRequest is a `generateContent` request. The model is part of the
+// URL; the client removes it from the body it sends.
+type TextInputRequest struct {
+	Model             GeminiAIModel     `json:"model,omitempty"`
+	Contents          []Content         `json:"contents"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+	SafetySettings    []SafetySetting   `json:"safetySettings,omitempty"`
+	Tools             []Tool            `json:"tools,omitempty"`
+	ToolConfig        interface{}       `json:"toolConfig,omitempty"`
+	CachedContent     string            `json:"cachedContent,omitempty"`
+}
+
+type Content struct {
+	Role  GeminiAIRole `json:"role,omitempty"`
+	Parts []Part       `json:"parts"`
+}
+
+// Part holds one kind of data: text, inline data, a file reference, a
+// function call or a function response.
+type Part struct {
+	Text             string            `json:"text,omitempty"`
+	InlineData       *Blob             `json:"inlineData,omitempty"`
+	FileData         *FileData         `json:"fileData,omitempty"`
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+	// Thought marks the model's thinking summaries in responses.
+	Thought bool `json:"thought,omitempty"`
+}
+
+type Blob struct {
+	MimeType string `json:"mimeType"`
+	// Data is base64 encoded.
+	Data string `json:"data"`
+}
+
+type FileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileUri  string `json:"fileUri"`
+}
+
+type FunctionCall struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+type FunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations,omitempty"`
+	GoogleSearch         *struct{}             `json:"googleSearch,omitempty"`
+	CodeExecution        *struct{}             `json:"codeExecution,omitempty"`
+}
+
+type FunctionDeclaration struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Parameters  interface{} `json:"parameters,omitempty"`
+}
+
+type GenerationConfig struct {
+	StopSequences    []string        `json:"stopSequences,omitempty"`
+	ResponseMimeType string          `json:"responseMimeType,omitempty"`
+	ResponseSchema   interface{}     `json:"responseSchema,omitempty"`
+	CandidateCount   *int            `json:"candidateCount,omitempty"`
+	MaxOutputTokens  *int            `json:"maxOutputTokens,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"topP,omitempty"`
+	TopK             *int            `json:"topK,omitempty"`
+	Seed             *int            `json:"seed,omitempty"`
+	PresencePenalty  *float64        `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64        `json:"frequencyPenalty,omitempty"`
+	ThinkingConfig   *ThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type ThinkingConfig struct {
+	ThinkingBudget  *int `json:"thinkingBudget,omitempty"`
+	IncludeThoughts bool `json:"includeThoughts,omitempty"`
+}
+
+type SafetySetting struct {
+	Category  HarmCategory       `json:"category"`
+	Threshold HarmBlockThreshold `json:"threshold"`
+}
+
+func (t *TextInputResponse) Unmarshal(b []byte) error {
+	return json.Unmarshal(b, t)
+}
+
+type TextInputResponse struct {
+	Candidates     []Candidate     `json:"candidates"`
+	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  UsageMetadata   `json:"usageMetadata"`
+	ModelVersion   string          `json:"modelVersion"`
+	ResponseId     string          `json:"responseId"`
+}
+
+type Candidate struct {
+	Content       Content        `json:"content"`
+	FinishReason  string         `json:"finishReason"`
+	SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
+	Index         int            `json:"index"`
+}
+
+type SafetyRating struct {
+	Category    HarmCategory `json:"category"`
+	Probability string       `json:"probability"`
+	Blocked     bool         `json:"blocked,omitempty"`
+}
+
+type PromptFeedback struct {
+	BlockReason   string         `json:"blockReason,omitempty"`
+	SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
+}
+
+type UsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
+}
@@ -0,0 +1,140 @@
+package mistral
+
+import "encoding/json"
+
+type (
+	MistralAIModel string
+	MistralAIRole  string
+)
+
+const (
+	// models
+	MistralAIModelLarge     MistralAIModel = "mistral-large-latest"
+	MistralAIModelMedium    MistralAIModel = "mistral-medium-latest"
+	MistralAIModelSmall     MistralAIModel = "mistral-small-latest"
+	MistralAIModelNemo      MistralAIModel = "open-mistral-nemo"
+	MistralAIModelMinistral MistralAIModel = "ministral-8b-latest"
+	MistralAIModelCodestral MistralAIModel = "codestral-latest"
+
+	// roles
+	MistralAIRoleSystem    MistralAIRole = "system"
+	MistralAIRoleUser      MistralAIRole = "user"
+	MistralAIRoleAssistant MistralAIRole = "assistant"
+	MistralAIRoleTool      MistralAIRole = "tool"
+)
+
+func (t *TextInputRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// TextInputRequest is a `/v1/chat/completions` request.
+type TextInputRequest struct {
+	Model             MistralAIModel                  `json:"model"`
+	Messages          []TextInputRequestMessage       `json:"messages"`
+	Temperature       *float64                        `json:"temperature,omitempty"`
+	TopP              *float64                        `json:"top_p,omitempty"`
+	MaxTokens         *int                            `json:"max_tokens,omitempty"`
+	Stop              []string                        `json:"stop,omitempty"`
+	RandomSeed        *int                            `json:"random_seed,omitempty"`
+	ResponseFormat    *TextInputRequestResponseFormat `json:"response_format,omitempty"`
+	Tools             []Tool                          `json:"tools,omitempty"`
+	ToolChoice        interface{}                     `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool                           `json:"parallel_tool_calls,omitempty"`
+	PresencePenalty   *float64                        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty  *float64                        `json:"frequency_penalty,omitempty"`
+	N                 *int                            `json:"n,omitempty"`
+	// SafePrompt injects Mistral's safety system prompt.
+	SafePrompt bool `json:"safe_prompt,omitempty"`
+	Stream     bool `json:"stream,omitempty"`
+}
+
+type TextInputRequestResponseFormat struct {
+	// Type is "text", "json_object" or "json_schema".
+	Type       string      `json:"type"`
+	JSONSchema interface{} `json:"json_schema,omitempty"`
+}
+
+type TextInputRequestMessage struct {
+	Role       MistralAIRole `json:"role"`
+	Content    string        `json:"content"`
+	Name       string        `json:"name,omitempty"`
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallId string        `json:"tool_call_id,omitempty"`
+	// Prefix makes a trailing assistant message the start of the answer.
+	Prefix bool `json:"prefix,omitempty"`
+}
+
+type Tool struct {
+	// Type is "function".
+	Type     string   `json:"type"`
+	Function Function `json:"function"`
+}
+
+type Function struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Parameters  interface{} `json:"parameters"`
+}
+
+type ToolCall struct {
+	Id       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name string `json:"name"`
+	// Arguments is the JSON encoded arguments object.
+	Arguments string `json:"arguments"`
+}
+
+func (t *FIMRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// FIMRequest is a fill-in-the-middle `/v1/fim/completions` request: the
+// model generates the code between Prompt and Suffix.
+type FIMRequest struct {
+	Model       MistralAIModel `json:"model"`
+	Prompt      string         `json:"prompt"`
+	Suffix      string         `json:"suffix,omitempty"`
+	Temperature *float64       `json:"temperature,omitempty"`
+	TopP        *float64       `json:"top_p,omitempty"`
+	MaxTokens   *int           `json:"max_tokens,omitempty"`
+	MinTokens   *int           `json:"min_tokens,omitempty"`
+	Stop        []string       `json:"stop,omitempty"`
+	RandomSeed  *int           `json:"random_seed,omitempty"`
+	Stream      bool           `json:"stream,omitempty"`
+}
+
+func (t *TextInputResponse) Unmarshal(b []byte) error {
+	return json.Unmarshal(b, t)
+}
+
+// TextInputResponse is the response of both chat and FIM completions.
+type TextInputResponse struct {
+	Id      string                    `json:"id"`
+	Object  string                    `json:"object"`
+	Created int                       `json:"created"`
+	Model   MistralAIModel            `json:"model"`
+	Choices []TextInputResponseChoice `json:"choices"`
+	Usage   TextInputResponseUsage    `json:"usage"`
+}
+
+type TextInputResponseChoice struct {
+	Index        int                            `json:"index"`
+	Message      TextInputResponseChoiceMessage `json:"message"`
+	FinishReason string                         `json:"finish_reason"`
+}
+
+type TextInputResponseChoiceMessage struct {
+	Role      MistralAIRole `json:"role"`
+	Content   string        `json:"content"`
+	ToolCalls []ToolCall    `json:"tool_calls,omitempty"`
+}
+
+type TextInputResponseUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
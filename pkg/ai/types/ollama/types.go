@@ -0,0 +1,229 @@
+package ollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type OllamaAIRole string
+
+const (
+	// roles
+	OllamaAIRoleSystem    OllamaAIRole = "system"
+	OllamaAIRoleUser      OllamaAIRole = "user"
+	OllamaAIRoleAssistant OllamaAIRole = "assistant"
+	OllamaAIRoleTool      OllamaAIRole = "tool"
+)
+
+// Duration is a `keep_alive` value: how long the model stays loaded after
+// the request. Negative values keep it loaded until Ollama stops; zero
+// unloads it right away.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if d < 0 {
+		return []byte("-1"), nil
+	}
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		if v < 0 {
+			*d = -1
+			return nil
+		}
+		*d = Duration(v * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("invalid keep_alive %s", b)
+	}
+	return nil
+}
+
+func (t *TextInputRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// TextInputRequest is an `/api/chat` request. Model names are the local
+// tags, e.g. "llama3.2" or "qwen2.5:7b".
+type TextInputRequest struct {
+	Model    string                    `json:"model"`
+	Messages []TextInputRequestMessage `json:"messages"`
+	Tools    []Tool                    `json:"tools,omitempty"`
+	// Format is "json" or a JSON schema the answer must follow.
+	Format  interface{} `json:"format,omitempty"`
+	Options *Options    `json:"options,omitempty"`
+	// Think enables the thinking of reasoning models.
+	Think     *bool     `json:"think,omitempty"`
+	KeepAlive *Duration `json:"keep_alive,omitempty"`
+	// Stream is set by the client; Ollama streams unless it is false.
+	Stream bool `json:"stream"`
+}
+
+type TextInputRequestMessage struct {
+	Role    OllamaAIRole `json:"role"`
+	Content string       `json:"content"`
+	// Images are base64 encoded, for multimodal models.
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolName names the tool a tool message answers.
+	ToolName string `json:"tool_name,omitempty"`
+}
+
+// Options are the model parameters of a request, overriding those of the
+// Modelfile.
+type Options struct {
+	// NumCtx is the context window size in tokens.
+	NumCtx        *int     `json:"num_ctx,omitempty"`
+	NumPredict    *int     `json:"num_predict,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopK          *int     `json:"top_k,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	MinP          *float64 `json:"min_p,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
+	Seed          *int     `json:"seed,omitempty"`
+	Stop          []string `json:"stop,omitempty"`
+	NumGPU        *int     `json:"num_gpu,omitempty"`
+	NumThread     *int     `json:"num_thread,omitempty"`
+}
+
+type Tool struct {
+	// Type is "function".
+	Type     string   `json:"type"`
+	Function Function `json:"function"`
+}
+
+type Function struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Parameters  interface{} `json:"parameters"`
+}
+
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+func (t *TextInputResponse) Unmarshal(b []byte) error {
+	return json.Unmarshal(b, t)
+}
+
+// TextInputResponse is an `/api/chat` response, or one chunk of a streamed
+// one. The counts and durations are set on the last chunk.
+type TextInputResponse struct {
+	Model      string                   `json:"model"`
+	CreatedAt  time.Time                `json:"created_at"`
+	Message    TextInputResponseMessage `json:"message"`
+	Done       bool                     `json:"done"`
+	DoneReason string                   `json:"done_reason,omitempty"`
+	// Durations are in nanoseconds.
+	TotalDuration      int64 `json:"total_duration,omitempty"`
+	LoadDuration       int64 `json:"load_duration,omitempty"`
+	PromptEvalCount    int   `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64 `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int   `json:"eval_count,omitempty"`
+	EvalDuration       int64 `json:"eval_duration,omitempty"`
+}
+
+type TextInputResponseMessage struct {
+	Role      OllamaAIRole `json:"role"`
+	Content   string       `json:"content"`
+	Thinking  string       `json:"thinking,omitempty"`
+	ToolCalls []ToolCall   `json:"tool_calls,omitempty"`
+}
+
+// ListResponse is the `/api/tags` response: the models available locally.
+type ListResponse struct {
+	Models []ModelInfo `json:"models"`
+}
+
+type ModelInfo struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+type ModelDetails struct {
+	ParentModel       string   `json:"parent_model,omitempty"`
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families,omitempty"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+// PullRequest is an `/api/pull` request.
+type PullRequest struct {
+	Model string `json:"model"`
+	// Insecure allows pulling from registries without TLS.
+	Insecure bool `json:"insecure,omitempty"`
+	Stream   bool `json:"stream"`
+}
+
+// PullProgress is one status update of a pull. Total and Completed are
+// bytes of the layer being downloaded, if any.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ShowRequest is an `/api/show` request.
+type ShowRequest struct {
+	Model   string `json:"model"`
+	Verbose bool   `json:"verbose,omitempty"`
+}
+
+// ShowResponse is the metadata of a local model.
+type ShowResponse struct {
+	License    string       `json:"license,omitempty"`
+	Modelfile  string       `json:"modelfile,omitempty"`
+	Parameters string       `json:"parameters,omitempty"`
+	Template   string       `json:"template,omitempty"`
+	System     string       `json:"system,omitempty"`
+	Details    ModelDetails `json:"details"`
+	// ModelInfo holds the GGUF metadata, e.g. "llama.context_length".
+	ModelInfo    map[string]interface{} `json:"model_info,omitempty"`
+	Capabilities []string               `json:"capabilities,omitempty"`
+	ModifiedAt   time.Time              `json:"modified_at"`
+}
+
+// EmbedRequest is an `/api/embed` request.
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+	// Truncate cuts inputs to the context length; Ollama defaults to true.
+	Truncate   *bool     `json:"truncate,omitempty"`
+	Dimensions *int      `json:"dimensions,omitempty"`
+	Options    *Options  `json:"options,omitempty"`
+	KeepAlive  *Duration `json:"keep_alive,omitempty"`
+}
+
+type EmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float32 `json:"embeddings"`
+	TotalDuration   int64       `json:"total_duration,omitempty"`
+	LoadDuration    int64       `json:"load_duration,omitempty"`
+	PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+}
@@ -0,0 +1,138 @@
+package openaicompat
+
+import (
+	"encoding/json"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/chatgpt"
+)
+
+type Role string
+
+const (
+	// roles
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleSystem    Role = "system"
+)
+
+func (t *TextInputRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// TextInputRequest is a `/v1/chat/completions` request, limited to the
+// fields OpenAI-compatible servers commonly accept. Model names are free
+// form since they depend on the server.
+type TextInputRequest struct {
+	Model            string                          `json:"model"`
+	Messages         []TextInputRequestMessage       `json:"messages"`
+	MaxTokens        *int                            `json:"max_tokens,omitempty"`
+	Temperature      *float64                        `json:"temperature,omitempty"`
+	TopP             *float64                        `json:"top_p,omitempty"`
+	FrequencyPenalty *float64                        `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64                        `json:"presence_penalty,omitempty"`
+	Seed             *int                            `json:"seed,omitempty"`
+	Stop             interface{}                     `json:"stop,omitempty"`
+	ResponseFormat   *TextInputRequestResponseFormat `json:"response_format,omitempty"`
+	Tools            interface{}                     `json:"tools,omitempty"`
+	ToolChoice       interface{}                     `json:"tool_choice,omitempty"`
+	Stream           bool                            `json:"stream,omitempty"`
+	StreamOptions    interface{}                     `json:"stream_options,omitempty"`
+}
+
+type TextInputRequestResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema interface{} `json:"json_schema,omitempty"`
+}
+
+type TextInputRequestMessage struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+func (t *TextInputResponse) Unmarshal(b []byte) error {
+	return json.Unmarshal(b, t)
+}
+
+type TextInputResponse struct {
+	Id                string                    `json:"id"`
+	Object            string                    `json:"object"`
+	Created           int                       `json:"created"`
+	Model             string                    `json:"model"`
+	Choices           []TextInputResponseChoice `json:"choices"`
+	Usage             TextInputResponseUsage    `json:"usage"`
+	SystemFingerprint string                    `json:"system_fingerprint,omitempty"`
+}
+
+type TextInputResponseUsage struct {
+	PromptTokens        int                                       `json:"prompt_tokens"`
+	CompletionTokens    int                                       `json:"completion_tokens"`
+	TotalTokens         int                                       `json:"total_tokens"`
+	PromptTokensDetails TextInputResponseUsagePromptTokensDetails `json:"prompt_tokens_details"`
+}
+
+type TextInputResponseUsagePromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+type TextInputResponseChoice struct {
+	Index        int                            `json:"index"`
+	Message      TextInputResponseChoiceMessage `json:"message"`
+	FinishReason string                         `json:"finish_reason"`
+}
+
+type TextInputResponseChoiceMessage struct {
+	Role      Role        `json:"role"`
+	Content   string      `json:"content"`
+	ToolCalls interface{} `json:"tool_calls,omitempty"`
+}
+
+// ModelList is the response of `GET /v1/models`.
+type ModelList struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
+
+type Model struct {
+	Id      string `json:"id"`
+	Object  string `json:"object"`
+	Created int    `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+func (t *EmbeddingRequest) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// EmbeddingRequest is a `/v1/embeddings` request.
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+	// Dimensions is ignored or rejected by servers that do not support it.
+	Dimensions     *int   `json:"dimensions,omitempty"`
+	EncodingFormat string `json:"encoding_format,omitempty"`
+}
+
+func (t *EmbeddingResponse) Unmarshal(b []byte) error {
+	return json.Unmarshal(b, t)
+}
+
+type EmbeddingResponse struct {
+	Object string                 `json:"object"`
+	Data   []EmbeddingData        `json:"data"`
+	Model  string                 `json:"model"`
+	Usage  TextInputResponseUsage `json:"usage"`
+}
+
+type EmbeddingData struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// Whisper servers implement OpenAI's audio API, so its types are shared.
+type (
+	TranscriptionRequest = chatgpt.TranscriptionRequest
+	TranslationRequest   = chatgpt.TranslationRequest
+	Transcription        = chatgpt.Transcription
+	TranscriptFormat     = chatgpt.TranscriptFormat
+)
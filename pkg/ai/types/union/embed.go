@@ -0,0 +1,33 @@
+package union
+
+import (
+	"context"
+	"net/http"
+)
+
+// EmbedRequest asks for the embeddings of a batch of texts.
+type EmbedRequest struct {
+	Input []string
+	// Model defaults to the provider's embedding model.
+	Model string
+	// Dimensions shortens the vectors, for models that support it. Zero
+	// keeps the model's size.
+	Dimensions int
+	// Context controls cancellation and deadlines of the call. Defaults to
+	// context.Background() when nil.
+	Context context.Context
+	// Header holds extra HTTP headers sent with the provider requests.
+	Header http.Header
+}
+
+// EmbedResponse holds one vector per input, in input order.
+type EmbedResponse struct {
+	Embeddings [][]float32
+	Model      string
+	// Usage sums the token usage of all the requests made.
+	Usage    *Usage
+	Provider string
+	// Metadata describes the HTTP exchange of the last batch when the input
+	// was split into several requests.
+	Metadata *Metadata
+}
@@ -0,0 +1,50 @@
+package union
+
+import (
+	"errors"
+	"fmt"
+)
+
+// APIError is returned by the provider clients when the provider answers
+// with a non-2xx status. It carries the raw response body and the metadata
+// of the exchange so callers can inspect the request ID and rate limits.
+type APIError struct {
+	StatusCode int
+	Body       []byte
+	Metadata   *Metadata
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, string(e.Body))
+}
+
+// RequestError is returned by the provider clients when a request fails
+// without an error answer from the provider: it could not be sent, or its
+// response could not be read or decoded. Metadata holds what is known of
+// the exchange; the status and headers are set once a response arrived.
+type RequestError struct {
+	Err      error
+	Metadata *Metadata
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// MetadataOf returns the metadata carried by the *APIError or
+// *RequestError in err's chain, nil when there is none.
+func MetadataOf(err error) *Metadata {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Metadata
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.Metadata
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package union
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata describes the HTTP exchange behind a response or an error: the
+// provider request ID (useful for support tickets), the rate-limit state
+// reported by the provider, the server-side processing time, the HTTP status
+// and the client-observed latency. Cached is set when the response was
+// served from a cache rather than from the provider.
+type Metadata struct {
+	RequestID      string        `json:"request_id,omitempty"`
+	StatusCode     int           `json:"status_code"`
+	ProcessingTime time.Duration `json:"processing_time,omitempty"`
+	Latency        time.Duration `json:"latency"`
+	RateLimit      RateLimit     `json:"rate_limit"`
+	Cached         bool          `json:"cached,omitempty"`
+}
+
+// RateLimit holds the rate-limit headers returned by a provider. Reset times
+// are absolute, regardless of whether the provider sent them as a duration
+// (OpenAI) or a timestamp (Anthropic). Zero values mean the header was absent
+// or reported zero; a limit of zero is never reported, so RemainingRequests
+// is meaningful whenever LimitRequests is set.
+type RateLimit struct {
+	LimitRequests     int           `json:"limit_requests,omitempty"`
+	RemainingRequests int           `json:"remaining_requests,omitempty"`
+	ResetRequests     time.Time     `json:"reset_requests,omitzero"`
+	LimitTokens       int           `json:"limit_tokens,omitempty"`
+	RemainingTokens   int           `json:"remaining_tokens,omitempty"`
+	ResetTokens       time.Time     `json:"reset_tokens,omitzero"`
+	RetryAfter        time.Duration `json:"retry_after,omitempty"`
+}
+
+var requestIDHeaders = []string{
+	"x-request-id",
+	"request-id",
+	"x-ds-trace-id",
+	"apim-request-id",
+	"x-amzn-requestid",
+}
+
+// NewMetadata builds Metadata from the response headers and status of a
+// provider call. It understands the OpenAI (`x-ratelimit-*`) and Anthropic
+// (`anthropic-ratelimit-*`) header families; unknown headers are ignored.
+func NewMetadata(h http.Header, statusCode int, latency time.Duration) *Metadata {
+	now := time.Now()
+	m := &Metadata{
+		StatusCode: statusCode,
+		Latency:    latency,
+	}
+
+	for _, k := range requestIDHeaders {
+		if v := h.Get(k); v != "" {
+			m.RequestID = v
+			break
+		}
+	}
+
+	if v := h.Get("openai-processing-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil {
+			m.ProcessingTime = time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+
+	rl := &m.RateLimit
+
+	// openai
+	rl.LimitRequests = headerInt(h, "x-ratelimit-limit-requests")
+	rl.RemainingRequests = headerInt(h, "x-ratelimit-remaining-requests")
+	rl.ResetRequests = headerReset(h, "x-ratelimit-reset-requests", now)
+	rl.LimitTokens = headerInt(h, "x-ratelimit-limit-tokens")
+	rl.RemainingTokens = headerInt(h, "x-ratelimit-remaining-tokens")
+	rl.ResetTokens = headerReset(h, "x-ratelimit-reset-tokens", now)
+
+	// anthropic
+	if v, ok := headerIntOK(h, "anthropic-ratelimit-requests-limit"); ok {
+		rl.LimitRequests = v
+	}
+	if v, ok := headerIntOK(h, "anthropic-ratelimit-requests-remaining"); ok {
+		rl.RemainingRequests = v
+	}
+	if v := headerReset(h, "anthropic-ratelimit-requests-reset", now); !v.IsZero() {
+		rl.ResetRequests = v
+	}
+	if v, ok := headerIntOK(h, "anthropic-ratelimit-tokens-limit"); ok {
+		rl.LimitTokens = v
+	}
+	if v, ok := headerIntOK(h, "anthropic-ratelimit-tokens-remaining"); ok {
+		rl.RemainingTokens = v
+	}
+	if v := headerReset(h, "anthropic-ratelimit-tokens-reset", now); !v.IsZero() {
+		rl.ResetTokens = v
+	}
+
+	if v := h.Get("retry-after"); v != "" {
+		if s, err := strconv.Atoi(v); err == nil {
+			rl.RetryAfter = time.Duration(s) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil && t.After(now) {
+			rl.RetryAfter = t.Sub(now)
+		}
+	}
+
+	return m
+}
+
+func headerInt(h http.Header, key string) int {
+	v, _ := headerIntOK(h, key)
+	return v
+}
+
+// headerIntOK parses an integer header. ok is false when the header is
+// absent or malformed, so an explicit "0" is told apart from no header.
+func headerIntOK(h http.Header, key string) (int, bool) {
+	v, err := strconv.Atoi(strings.TrimSpace(h.Get(key)))
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// headerReset parses a reset header that is either a Go-style duration
+// ("1s", "6m0s", "20ms"), a number of seconds, or an RFC 3339 timestamp.
+func headerReset(h http.Header, key string, now time.Time) time.Time {
+	v := strings.TrimSpace(h.Get(key))
+	if v == "" {
+		return time.Time{}
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(d)
+	}
+	if s, err := strconv.ParseFloat(v, 64); err == nil {
+		return now.Add(time.Duration(s * float64(time.Second)))
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t
+	}
+	return time.Time{}
+}
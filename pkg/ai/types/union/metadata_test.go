@@ -0,0 +1,57 @@
+package union
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNewMetadataAnthropicOverridesOnPresence(t *testing.T) {
+	h := http.Header{}
+	h.Set("x-ratelimit-limit-requests", "100")
+	h.Set("x-ratelimit-remaining-requests", "50")
+	h.Set("anthropic-ratelimit-requests-limit", "10")
+	h.Set("anthropic-ratelimit-requests-remaining", "0")
+
+	rl := NewMetadata(h, http.StatusOK, 0).RateLimit
+	if rl.LimitRequests != 10 {
+		t.Errorf("LimitRequests = %d, want 10", rl.LimitRequests)
+	}
+	if rl.RemainingRequests != 0 {
+		t.Errorf("RemainingRequests = %d, want 0", rl.RemainingRequests)
+	}
+}
+
+func TestNewMetadataAnthropicAbsent(t *testing.T) {
+	h := http.Header{}
+	h.Set("x-ratelimit-limit-tokens", "1000")
+	h.Set("x-ratelimit-remaining-tokens", "900")
+
+	rl := NewMetadata(h, http.StatusOK, 0).RateLimit
+	if rl.LimitTokens != 1000 || rl.RemainingTokens != 900 {
+		t.Errorf("tokens = %d/%d, want 900/1000", rl.RemainingTokens, rl.LimitTokens)
+	}
+}
+
+func TestMetadataOf(t *testing.T) {
+	meta := &Metadata{StatusCode: http.StatusOK}
+	tests := []struct {
+		name string
+		err  error
+		want *Metadata
+	}{
+		{"nil", nil, nil},
+		{"plain", errors.New("boom"), nil},
+		{"api error", &APIError{StatusCode: 500, Metadata: meta}, meta},
+		{"request error", &RequestError{Err: errors.New("boom"), Metadata: meta}, meta},
+		{"wrapped", fmt.Errorf("ask: %w", &RequestError{Err: errors.New("boom"), Metadata: meta}), meta},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetadataOf(tt.err); got != tt.want {
+				t.Errorf("MetadataOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
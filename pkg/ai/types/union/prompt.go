@@ -0,0 +1,31 @@
+package union
+
+import "encoding/json"
+
+type PromptRole string
+
+const (
+	PromptRoleUser      PromptRole = "user"
+	PromptRoleAssistant PromptRole = "assistant"
+)
+
+// Prompt is a provider-neutral text request. Each provider translates it into
+// its own request type, using its default model unless Model is set.
+type Prompt struct {
+	// Model is a provider-specific model name. Leave empty when the prompt is
+	// sent to several providers.
+	Model       string          `json:"model,omitempty"`
+	System      string          `json:"system,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Temperature *float64        `json:"temperature,omitempty"`
+}
+
+type PromptMessage struct {
+	Role    PromptRole `json:"role"`
+	Content string     `json:"content"`
+}
+
+func (p *Prompt) Marshal() ([]byte, error) {
+	return json.Marshal(p)
+}
@@ -0,0 +1,20 @@
+package union
+
+// StreamEvent is one chunk of a streamed response.
+type StreamEvent struct {
+	// Delta is the text generated since the previous event.
+	Delta string `json:"delta,omitempty"`
+	// FinishReason is set on the event that ends the generation.
+	FinishReason string `json:"finish_reason,omitempty"`
+	// Usage is set on the event that reports token usage, usually the last.
+	Usage *Usage `json:"usage,omitempty"`
+	// Raw is the provider payload the event was decoded from.
+	Raw []byte `json:"-"`
+}
+
+// Stream is a streamed response. Recv returns io.EOF after the last event.
+// Close must be called when the caller is done with the stream.
+type Stream interface {
+	Recv() (*StreamEvent, error)
+	Close() error
+}
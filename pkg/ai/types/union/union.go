@@ -1,5 +1,11 @@
 package union
 
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
 type Responser interface {
 	Unmarshal(b []byte) error
 }
@@ -10,8 +16,49 @@ type Requester interface {
 
 type Response struct {
 	TextResponse Responser
+	// Metadata describes the HTTP exchange (request ID, rate limits, latency).
+	Metadata *Metadata
+	// Output is the generated text, concatenated across output items.
+	Output string
+	// FinishReason is the provider's reason for ending the generation.
+	FinishReason string
+	// Usage is the token usage reported by the provider, if any.
+	Usage *Usage
+	// Provider is the name of the provider that produced the response.
+	Provider string
+	// Attempts lists the failed attempts made before this response when the
+	// request went through a router.
+	Attempts []Attempt
 }
 
 type Request struct {
 	TextRequest Requester
+	// Prompt is a provider-neutral request. It is used when TextRequest is
+	// nil, and lets the same prompt be sent to any provider.
+	Prompt *Prompt
+	// Context controls cancellation and deadlines of the call. Defaults to
+	// context.Background() when nil.
+	Context context.Context
+	// Header holds extra HTTP headers sent with the provider request. They
+	// are applied last and can override the client's defaults.
+	Header http.Header
+	// Metadata carries free-form values through middlewares; it is not sent
+	// to the provider.
+	Metadata map[string]string
+}
+
+// SetHeader sets an extra HTTP header, allocating Header if needed.
+func (r *Request) SetHeader(key, value string) {
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	r.Header.Set(key, value)
+}
+
+// Attempt records a failed call to a provider.
+type Attempt struct {
+	Provider string        `json:"provider"`
+	Err      error         `json:"-"`
+	Error    string        `json:"error"`
+	Latency  time.Duration `json:"latency"`
 }
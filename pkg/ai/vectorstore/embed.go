@@ -0,0 +1,78 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/muraduiurie/gpt/pkg/ai/types/union"
+)
+
+// AddTexts embeds the texts of the documents with Options.Embedder and
+// stores them. Documents that already carry a vector are stored as they
+// are. The embedding usage is returned, nil when nothing was embedded.
+func (s *Store) AddTexts(ctx context.Context, docs ...Document) (*union.Usage, error) {
+	var (
+		texts []string
+		idx   []int
+	)
+	for i, d := range docs {
+		if len(d.Vector) > 0 {
+			continue
+		}
+		if d.Text == "" {
+			return nil, fmt.Errorf("document %q: text or vector is required", d.ID)
+		}
+		texts = append(texts, d.Text)
+		idx = append(idx, i)
+	}
+
+	var usage *union.Usage
+	if len(texts) > 0 {
+		resp, err := s.embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		usage = resp.Usage
+		docs = append([]Document(nil), docs...)
+		for i, v := range resp.Embeddings {
+			docs[idx[i]].Vector = v
+		}
+	}
+
+	return usage, s.Add(docs...)
+}
+
+// SearchText embeds text with Options.Embedder and searches the store for
+// it; see Search. q.Vector is ignored.
+func (s *Store) SearchText(ctx context.Context, text string, q Query) ([]Result, error) {
+	if text == "" {
+		return nil, errors.New("query text is required")
+	}
+	resp, err := s.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	q.Vector = resp.Embeddings[0]
+
+	return s.Search(q)
+}
+
+func (s *Store) embed(ctx context.Context, texts []string) (*union.EmbedResponse, error) {
+	if s.opts.Embedder == nil {
+		return nil, errors.New("no embedder configured")
+	}
+	resp, err := s.opts.Embedder.EmbedAI(&union.EmbedRequest{
+		Input:      texts,
+		Model:      s.opts.EmbeddingModel,
+		Dimensions: s.opts.EmbedDimensions,
+		Context:    ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("embed: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embed: got %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+	return resp, nil
+}
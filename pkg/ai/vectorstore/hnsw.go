@@ -0,0 +1,287 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+)
+
+// HNSWOptions tune the HNSW index. Zero fields take the defaults.
+type HNSWOptions struct {
+	// M is the number of neighbors of a node, 32 on the bottom layer.
+	// Defaults to 16; higher improves recall at the cost of memory.
+	M int
+	// EfConstruction is the candidate list size when inserting. Defaults
+	// to 200.
+	EfConstruction int
+	// EfSearch is the candidate list size when searching, at least K.
+	// Defaults to 64.
+	EfSearch int
+	// Seed seeds the level assignment, making the graph reproducible.
+	Seed int64
+}
+
+// hnsw is a Hierarchical Navigable Small World graph over store slots
+// (Malkov and Yashunin, 2016). Deleted nodes stay in the graph to keep it
+// navigable and are skipped in results.
+type hnsw struct {
+	m, m0          int
+	efConstruction int
+	efSearch       int
+	ml             float64
+	rng            *rand.Rand
+
+	nodes    []hnswNode
+	entry    int
+	maxLevel int
+
+	// dist is the distance between two nodes, qdist between a query and a
+	// node; smaller is closer.
+	dist  func(a, b int) float32
+	qdist func(q []float32, qNorm float32, b int) float32
+}
+
+type hnswNode struct {
+	// friends holds the neighbors of each layer the node is on.
+	friends [][]int
+	deleted bool
+}
+
+func newHNSW(o HNSWOptions, dist func(a, b int) float32, qdist func(q []float32, qNorm float32, b int) float32) *hnsw {
+	h := &hnsw{
+		m:              o.M,
+		efConstruction: o.EfConstruction,
+		efSearch:       o.EfSearch,
+		rng:            rand.New(rand.NewSource(o.Seed)),
+		entry:          -1,
+		dist:           dist,
+		qdist:          qdist,
+	}
+	if h.m <= 1 {
+		h.m = 16
+	}
+	if h.efConstruction <= 0 {
+		h.efConstruction = 200
+	}
+	if h.efSearch <= 0 {
+		h.efSearch = 64
+	}
+	h.m0 = 2 * h.m
+	h.ml = 1 / math.Log(float64(h.m))
+	return h
+}
+
+// add inserts the node of a slot; slots are added in order.
+func (h *hnsw) add(slot int) {
+	level := int(-math.Log(1-h.rng.Float64()) * h.ml)
+	h.nodes = append(h.nodes, hnswNode{friends: make([][]int, level+1)})
+	if h.entry < 0 {
+		h.entry, h.maxLevel = slot, level
+		return
+	}
+
+	d := func(b int) float32 { return h.dist(slot, b) }
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedy(d, ep, l)
+	}
+	eps := []int{ep}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		found := h.searchLayer(d, eps, h.efConstruction, l)
+		maxFriends := h.m
+		if l == 0 {
+			maxFriends = h.m0
+		}
+		friends := h.selectNeighbors(found, h.m)
+		h.nodes[slot].friends[l] = friends
+		for _, f := range friends {
+			ff := append(h.nodes[f].friends[l], slot)
+			if len(ff) > maxFriends {
+				ff = h.shrink(f, ff, maxFriends)
+			}
+			h.nodes[f].friends[l] = ff
+		}
+
+		eps = eps[:0]
+		for _, c := range found {
+			eps = append(eps, c.id)
+		}
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = slot, level
+	}
+}
+
+// remove marks the node of a slot deleted.
+func (h *hnsw) remove(slot int) {
+	h.nodes[slot].deleted = true
+}
+
+// search returns the k nearest accepted nodes to q, best first.
+func (h *hnsw) search(q []float32, k int, accept func(slot int) bool) []hit {
+	if h.entry < 0 {
+		return nil
+	}
+	qNorm := norm(q)
+	d := func(b int) float32 { return h.qdist(q, qNorm, b) }
+
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedy(d, ep, l)
+	}
+	found := h.searchLayer(d, []int{ep}, max(h.efSearch, k), 0)
+
+	out := make([]hit, 0, k)
+	for _, c := range found {
+		if h.nodes[c.id].deleted || !accept(c.id) {
+			continue
+		}
+		out = append(out, hit{slot: c.id, score: -c.d})
+		if len(out) == k {
+			break
+		}
+	}
+	return out
+}
+
+// greedy walks layer l from ep to the closest node it can reach.
+func (h *hnsw) greedy(d func(int) float32, ep, l int) int {
+	cur, curD := ep, d(ep)
+	for changed := true; changed; {
+		changed = false
+		for _, f := range h.nodes[cur].friends[l] {
+			if fd := d(f); fd < curD {
+				cur, curD, changed = f, fd, true
+			}
+		}
+	}
+	return cur
+}
+
+// searchLayer returns the ef closest nodes of layer l reachable from the
+// entry points, closest first.
+func (h *hnsw) searchLayer(d func(int) float32, eps []int, ef, l int) []candidate {
+	visited := make([]uint64, (len(h.nodes)+63)/64)
+	var (
+		candidates nearest
+		results    farthest
+	)
+	for _, ep := range eps {
+		visited[ep/64] |= 1 << (ep % 64)
+		c := candidate{id: ep, d: d(ep)}
+		heap.Push(&candidates, c)
+		heap.Push(&results, c)
+	}
+	for results.Len() > ef {
+		heap.Pop(&results)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(&candidates).(candidate)
+		if results.Len() >= ef && c.d > results[0].d {
+			break
+		}
+		for _, f := range h.nodes[c.id].friends[l] {
+			if visited[f/64]&(1<<(f%64)) != 0 {
+				continue
+			}
+			visited[f/64] |= 1 << (f % 64)
+			fd := d(f)
+			if results.Len() < ef || fd < results[0].d {
+				heap.Push(&candidates, candidate{id: f, d: fd})
+				heap.Push(&results, candidate{id: f, d: fd})
+				if results.Len() > ef {
+					heap.Pop(&results)
+				}
+			}
+		}
+	}
+
+	out := make([]candidate, results.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(&results).(candidate)
+	}
+	return out
+}
+
+// selectNeighbors picks up to m of the candidates, closest first, with the
+// heuristic of the paper: a candidate closer to a picked neighbor than to
+// the new node is skipped, which keeps links to distant clusters. Skipped
+// candidates fill the remaining places.
+func (h *hnsw) selectNeighbors(candidates []candidate, m int) []int {
+	picked := make([]int, 0, m)
+	var skipped []int
+	for _, c := range candidates {
+		if len(picked) == m {
+			break
+		}
+		keep := true
+		for _, p := range picked {
+			if h.dist(c.id, p) < c.d {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			picked = append(picked, c.id)
+		} else {
+			skipped = append(skipped, c.id)
+		}
+	}
+	for _, id := range skipped {
+		if len(picked) == m {
+			break
+		}
+		picked = append(picked, id)
+	}
+	return picked
+}
+
+// shrink reduces the neighbors of a node to its m closest ones. The
+// heuristic of selectNeighbors is not rerun: it costs m² distances on
+// every overflow for little gain in recall.
+func (h *hnsw) shrink(node int, friends []int, m int) []int {
+	candidates := make(nearest, len(friends))
+	for i, f := range friends {
+		candidates[i] = candidate{id: f, d: h.dist(node, f)}
+	}
+	heap.Init(&candidates)
+	out := friends[:0]
+	for len(out) < m {
+		out = append(out, heap.Pop(&candidates).(candidate).id)
+	}
+	return out
+}
+
+type candidate struct {
+	id int
+	d  float32
+}
+
+// nearest is a min-heap of candidates by distance.
+type nearest []candidate
+
+func (h nearest) Len() int            { return len(h) }
+func (h nearest) Less(i, j int) bool  { return h[i].d < h[j].d }
+func (h nearest) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nearest) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *nearest) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// farthest is a max-heap of candidates by distance.
+type farthest []candidate
+
+func (h farthest) Len() int            { return len(h) }
+func (h farthest) Less(i, j int) bool  { return h[i].d > h[j].d }
+func (h farthest) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *farthest) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *farthest) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
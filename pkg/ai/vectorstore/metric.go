@@ -0,0 +1,61 @@
+package vectorstore
+
+import (
+	"fmt"
+	"math"
+)
+
+// Metric is the similarity measure of a store.
+type Metric string
+
+const (
+	// Cosine compares directions: the score is the cosine of the angle
+	// between the vectors, in [-1, 1].
+	Cosine Metric = "cosine"
+	// Dot is the dot product, for vectors normalized by the model.
+	Dot Metric = "dot"
+	// Euclidean is the straight-line distance; the score is its negation,
+	// so higher still means more similar.
+	Euclidean Metric = "euclidean"
+)
+
+// scorer returns the score function of m. Scores are higher for more
+// similar vectors; norms are the precomputed lengths of the vectors.
+func (m Metric) scorer() (func(a, b []float32, normA, normB float32) float32, error) {
+	switch m {
+	case Cosine, "":
+		return func(a, b []float32, normA, normB float32) float32 {
+			if normA == 0 || normB == 0 {
+				return 0
+			}
+			return dot(a, b) / (normA * normB)
+		}, nil
+	case Dot:
+		return func(a, b []float32, _, _ float32) float32 {
+			return dot(a, b)
+		}, nil
+	case Euclidean:
+		return func(a, b []float32, _, _ float32) float32 {
+			var sum float32
+			for i := range a {
+				d := a[i] - b[i]
+				sum += d * d
+			}
+			return -float32(math.Sqrt(float64(sum)))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown metric %q: use %q, %q or %q", m, Cosine, Dot, Euclidean)
+	}
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func norm(v []float32) float32 {
+	return float32(math.Sqrt(float64(dot(v, v))))
+}
@@ -0,0 +1,114 @@
+package vectorstore
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// snapshotVersion is the version of the snapshot format.
+const snapshotVersion = 1
+
+// snapshot is the gob-encoded content of a snapshot file. The index is
+// rebuilt when loading.
+type snapshot struct {
+	Version    int
+	Metric     Metric
+	Dimensions int
+	Documents  []Document
+}
+
+// Encode writes a snapshot of the store to w.
+func (s *Store) Encode(w io.Writer) error {
+	s.mu.RLock()
+	snap := snapshot{
+		Version:    snapshotVersion,
+		Metric:     s.opts.Metric,
+		Dimensions: s.opts.Dimensions,
+		Documents:  make([]Document, 0, len(s.slots)),
+	}
+	for _, d := range s.docs {
+		if d != nil {
+			snap.Documents = append(snap.Documents, *d)
+		}
+	}
+	s.mu.RUnlock()
+
+	if err := gob.NewEncoder(w).Encode(&snap); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	return nil
+}
+
+// Save writes a snapshot of the store to a file. The file is replaced
+// atomically, so a failed save leaves the previous snapshot intact.
+func (s *Store) Save(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	if err = s.Encode(w); err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Decode returns a store holding the documents of a snapshot read from r.
+// The metric and the dimensions of the snapshot are used unless set in
+// opts, in which case they must match.
+func Decode(r io.Reader, opts Options) (*Store, error) {
+	var snap snapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	if opts.Metric == "" {
+		opts.Metric = snap.Metric
+	} else if opts.Metric != snap.Metric {
+		return nil, fmt.Errorf("snapshot metric is %q, not %q", snap.Metric, opts.Metric)
+	}
+	if opts.Dimensions == 0 {
+		opts.Dimensions = snap.Dimensions
+	} else if snap.Dimensions != 0 && opts.Dimensions != snap.Dimensions {
+		return nil, fmt.Errorf("snapshot vectors have %d dimensions, not %d", snap.Dimensions, opts.Dimensions)
+	}
+
+	s, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.Add(snap.Documents...); err != nil {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+	return s, nil
+}
+
+// Load returns a store holding the documents of a snapshot file; see
+// Decode.
+func Load(path string, opts Options) (*Store, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	return Decode(bufio.NewReader(f), opts)
+}
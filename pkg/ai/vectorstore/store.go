@@ -0,0 +1,373 @@
+// Package vectorstore is an in-memory vector store: documents with their
+// embedding and metadata, searched by similarity with a brute-force or an
+// HNSW index, and snapshotted to a local file. With an ai.Embedder, texts
+// are embedded as they are added and searched.
+package vectorstore
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/muraduiurie/gpt/pkg/ai"
+)
+
+// IndexKind selects how a store searches.
+type IndexKind string
+
+const (
+	// IndexFlat compares the query with every document: exact, and fast
+	// enough up to tens of thousands of documents.
+	IndexFlat IndexKind = "flat"
+	// IndexHNSW searches a Hierarchical Navigable Small World graph:
+	// approximate, but sublinear in the number of documents.
+	IndexHNSW IndexKind = "hnsw"
+)
+
+// Document is a stored vector with the text and metadata it stands for.
+type Document struct {
+	// ID identifies the document; adding a document with the ID of a
+	// stored one replaces it.
+	ID       string
+	Text     string
+	Vector   []float32
+	Metadata map[string]string
+}
+
+// Filter selects documents by metadata: a document matches when it has
+// every key with the same value. A nil Filter matches every document.
+type Filter map[string]string
+
+// clone returns a copy of d sharing no memory with it.
+func (d *Document) clone() Document {
+	c := *d
+	c.Vector = append([]float32(nil), d.Vector...)
+	if d.Metadata != nil {
+		c.Metadata = make(map[string]string, len(d.Metadata))
+		for k, v := range d.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return c
+}
+
+func (f Filter) match(d *Document) bool {
+	for k, v := range f {
+		if got, ok := d.Metadata[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Query is a similarity search.
+type Query struct {
+	Vector []float32
+	// K is the number of results. Defaults to 4.
+	K      int
+	Filter Filter
+	// MinScore drops results scoring below it when set.
+	MinScore *float32
+}
+
+// Result is a document found by a search, most similar first.
+type Result struct {
+	Document Document
+	// Score is the similarity to the query under the store metric; higher
+	// is more similar.
+	Score float32
+}
+
+// Options configure a store.
+type Options struct {
+	// Metric defaults to Cosine.
+	Metric Metric
+	// Index defaults to IndexFlat.
+	Index IndexKind
+	// HNSW tunes the HNSW index.
+	HNSW HNSWOptions
+	// Dimensions is the vector size. Zero takes the size of the first
+	// vector added.
+	Dimensions int
+	// Embedder embeds the texts of AddTexts and SearchText.
+	Embedder ai.Embedder
+	// EmbeddingModel is passed to Embedder; empty uses its default model.
+	EmbeddingModel string
+	// EmbedDimensions asks Embedder for shortened vectors, for models that
+	// support it. Zero keeps the model's size.
+	EmbedDimensions int
+}
+
+// Store is an in-memory vector store. It is safe for concurrent use.
+type Store struct {
+	opts  Options
+	score func(a, b []float32, normA, normB float32) float32
+
+	mu sync.RWMutex
+	// docs holds the documents by slot; deleted slots are nil until the
+	// store is compacted. The vectors stay, for the HNSW graph.
+	docs    []*Document
+	vectors [][]float32
+	norms   []float32
+	slots   map[string]int
+	deleted int
+	hnsw    *hnsw
+}
+
+// New returns an empty store.
+func New(opts Options) (*Store, error) {
+	score, err := opts.Metric.scorer()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Metric == "" {
+		opts.Metric = Cosine
+	}
+	switch opts.Index {
+	case "":
+		opts.Index = IndexFlat
+	case IndexFlat, IndexHNSW:
+	default:
+		return nil, fmt.Errorf("unknown index %q: use %q or %q", opts.Index, IndexFlat, IndexHNSW)
+	}
+
+	s := &Store{
+		opts:  opts,
+		score: score,
+		slots: map[string]int{},
+	}
+	s.reset()
+	return s, nil
+}
+
+// Len returns the number of documents.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.slots)
+}
+
+// Dimensions returns the vector size, zero while the store is empty and
+// Options.Dimensions is unset.
+func (s *Store) Dimensions() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.opts.Dimensions
+}
+
+// Add stores copies of documents, replacing those with the same ID. Every
+// document needs an ID and a vector of the store size.
+func (s *Store) Add(docs ...Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dimensions := s.opts.Dimensions
+	for i := range docs {
+		d := &docs[i]
+		if d.ID == "" {
+			return fmt.Errorf("document %d: id is required", i)
+		}
+		if len(d.Vector) == 0 {
+			return fmt.Errorf("document %q: vector is required", d.ID)
+		}
+		if dimensions == 0 {
+			dimensions = len(d.Vector)
+		}
+		if len(d.Vector) != dimensions {
+			return fmt.Errorf("document %q: vector has %d dimensions, want %d", d.ID, len(d.Vector), dimensions)
+		}
+	}
+	s.opts.Dimensions = dimensions
+
+	for _, d := range docs {
+		if slot, ok := s.slots[d.ID]; ok {
+			s.remove(slot)
+		}
+		doc := d.clone()
+		s.insert(&doc)
+	}
+	s.maybeCompact()
+	return nil
+}
+
+// Get returns a copy of the document of an ID.
+func (s *Store) Get(id string) (Document, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	slot, ok := s.slots[id]
+	if !ok {
+		return Document{}, false
+	}
+	return s.docs[slot].clone(), true
+}
+
+// Delete removes documents by ID; unknown IDs are ignored. It returns the
+// number of documents removed.
+func (s *Store) Delete(ids ...string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, id := range ids {
+		if slot, ok := s.slots[id]; ok {
+			s.remove(slot)
+			n++
+		}
+	}
+	s.maybeCompact()
+	return n
+}
+
+// maybeCompact compacts the store once deleted slots, left by Delete and
+// by documents replaced by Add, are the majority: the HNSW graph keeps
+// them for navigation until it is rebuilt.
+func (s *Store) maybeCompact() {
+	if s.deleted > 64 && s.deleted > len(s.slots) {
+		s.compact()
+	}
+}
+
+// Search returns copies of the documents most similar to the query vector,
+// most similar first. HNSW searches with a filter fall back to a brute-force
+// scan when the graph yields fewer than K matches.
+func (s *Store) Search(q Query) ([]Result, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(q.Vector) == 0 {
+		return nil, errors.New("query vector is required")
+	}
+	if s.opts.Dimensions != 0 && len(q.Vector) != s.opts.Dimensions {
+		return nil, fmt.Errorf("query vector has %d dimensions, want %d", len(q.Vector), s.opts.Dimensions)
+	}
+	k := q.K
+	if k <= 0 {
+		k = 4
+	}
+
+	var hits []hit
+	if s.hnsw != nil {
+		hits = s.hnsw.search(q.Vector, k, func(slot int) bool {
+			return q.Filter.match(s.docs[slot])
+		})
+		if len(hits) < k && len(q.Filter) > 0 && len(hits) < len(s.slots) {
+			hits = s.scan(q, k)
+		}
+	} else {
+		hits = s.scan(q, k)
+	}
+
+	results := make([]Result, 0, len(hits))
+	for _, h := range hits {
+		if q.MinScore != nil && h.score < *q.MinScore {
+			continue
+		}
+		results = append(results, Result{Document: s.docs[h.slot].clone(), Score: h.score})
+	}
+	return results, nil
+}
+
+// scan scores every matching document.
+func (s *Store) scan(q Query, k int) []hit {
+	qNorm := norm(q.Vector)
+	top := make(hits, 0, k+1)
+	for slot, d := range s.docs {
+		if d == nil || !q.Filter.match(d) {
+			continue
+		}
+		top.push(hit{slot: slot, score: s.score(q.Vector, d.Vector, qNorm, s.norms[slot])}, k)
+	}
+	return top.sorted()
+}
+
+// insert stores d in a new slot.
+func (s *Store) insert(d *Document) {
+	slot := len(s.docs)
+	s.docs = append(s.docs, d)
+	s.vectors = append(s.vectors, d.Vector)
+	s.norms = append(s.norms, norm(d.Vector))
+	s.slots[d.ID] = slot
+	if s.hnsw != nil {
+		s.hnsw.add(slot)
+	}
+}
+
+// remove frees a slot.
+func (s *Store) remove(slot int) {
+	delete(s.slots, s.docs[slot].ID)
+	s.docs[slot] = nil
+	s.deleted++
+	if s.hnsw != nil {
+		s.hnsw.remove(slot)
+	}
+}
+
+// compact drops the deleted slots and rebuilds the index.
+func (s *Store) compact() {
+	docs := s.docs
+	s.reset()
+	for _, d := range docs {
+		if d != nil {
+			s.insert(d)
+		}
+	}
+}
+
+func (s *Store) reset() {
+	s.docs = nil
+	s.vectors = nil
+	s.norms = nil
+	s.slots = map[string]int{}
+	s.deleted = 0
+	s.hnsw = nil
+	if s.opts.Index == IndexHNSW {
+		s.hnsw = newHNSW(s.opts.HNSW, func(a, b int) float32 {
+			return -s.score(s.vectors[a], s.vectors[b], s.norms[a], s.norms[b])
+		}, func(q []float32, qNorm float32, b int) float32 {
+			return -s.score(q, s.vectors[b], qNorm, s.norms[b])
+		})
+	}
+}
+
+// hit is a scored slot.
+type hit struct {
+	slot  int
+	score float32
+}
+
+// hits is a min-heap of hits by score, keeping the best ones.
+type hits []hit
+
+func (h hits) Len() int            { return len(h) }
+func (h hits) Less(i, j int) bool  { return h[i].score < h[j].score }
+func (h hits) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *hits) Push(x interface{}) { *h = append(*h, x.(hit)) }
+func (h *hits) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// push adds x, keeping the k best hits.
+func (h *hits) push(x hit, k int) {
+	if len(*h) < k {
+		heap.Push(h, x)
+		return
+	}
+	if x.score > (*h)[0].score {
+		(*h)[0] = x
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted empties the heap into a slice, best first.
+func (h *hits) sorted() []hit {
+	out := make([]hit, len(*h))
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(h).(hit)
+	}
+	return out
+}
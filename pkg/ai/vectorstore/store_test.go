@@ -0,0 +1,264 @@
+package vectorstore
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAddCompactsReplacedDocuments(t *testing.T) {
+	s, err := New(Options{Index: IndexHNSW})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := make([]Document, 10)
+	for i := range docs {
+		docs[i] = Document{ID: fmt.Sprint(i), Vector: []float32{float32(i + 1), 1}}
+	}
+
+	// replacing every document over and over leaves deleted slots behind
+	for range 50 {
+		if err := s.Add(docs...); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if s.Len() != len(docs) {
+		t.Errorf("Len = %d, want %d", s.Len(), len(docs))
+	}
+	if s.deleted > 64+len(docs) || len(s.docs) > 2*(64+len(docs)) {
+		t.Errorf("store holds %d slots, %d deleted; want it compacted", len(s.docs), s.deleted)
+	}
+
+	results, err := s.Search(Query{Vector: []float32{10, 1}, K: 1})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Document.ID != "9" {
+		t.Errorf("results = %+v, want document 9", results)
+	}
+}
+
+func TestDeleteCompacts(t *testing.T) {
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for i := range 200 {
+		id := fmt.Sprint(i)
+		ids = append(ids, id)
+		if err := s.Add(Document{ID: id, Vector: []float32{float32(i), 1}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := s.Delete(ids[:150]...); n != 150 {
+		t.Fatalf("Delete = %d, want 150", n)
+	}
+	if s.deleted != 0 || len(s.docs) != 50 {
+		t.Errorf("store holds %d slots, %d deleted; want it compacted", len(s.docs), s.deleted)
+	}
+	if _, ok := s.Get("199"); !ok {
+		t.Error("document 199 lost by compaction")
+	}
+}
+
+func TestAddCopiesDocuments(t *testing.T) {
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := Document{ID: "a", Vector: []float32{1, 0}, Metadata: map[string]string{"lang": "go"}}
+	if err := s.Add(d); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	d.Vector[0] = 0
+	d.Metadata["lang"] = "rust"
+
+	got, _ := s.Get("a")
+	if got.Vector[0] != 1 || got.Metadata["lang"] != "go" {
+		t.Errorf("stored document = %+v, changed through the added one", got)
+	}
+	got.Vector[0] = 0
+	got.Metadata["lang"] = "rust"
+
+	results, err := s.Search(Query{Vector: []float32{1, 0}, Filter: Filter{"lang": "go"}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Document.Vector[0] != 1 {
+		t.Fatalf("results = %+v, changed through Get", results)
+	}
+}
+
+// randomDocs returns n documents with random vectors of dim dimensions.
+func randomDocs(rng *rand.Rand, n, dim int) []Document {
+	docs := make([]Document, n)
+	for i := range docs {
+		docs[i] = Document{ID: fmt.Sprint(i), Vector: randomVector(rng, dim)}
+	}
+	return docs
+}
+
+func randomVector(rng *rand.Rand, dim int) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = rng.Float32()*2 - 1
+	}
+	return v
+}
+
+func TestHNSWRecall(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	docs := randomDocs(rng, 2000, 16)
+	flat, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph, err := New(Options{Index: IndexHNSW, HNSW: HNSWOptions{Seed: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := flat.Add(docs...); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.Add(docs...); err != nil {
+		t.Fatal(err)
+	}
+
+	const queries, k = 50, 10
+	found := 0
+	for range queries {
+		q := Query{Vector: randomVector(rng, 16), K: k}
+		exact, err := flat.Search(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		approx, err := graph.Search(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]bool{}
+		for _, r := range exact {
+			want[r.Document.ID] = true
+		}
+		for _, r := range approx {
+			if want[r.Document.ID] {
+				found++
+			}
+		}
+	}
+	if recall := float64(found) / (queries * k); recall < 0.9 {
+		t.Errorf("recall@%d = %.2f, want at least 0.9", k, recall)
+	}
+}
+
+func TestHNSWFilterFallsBackToScan(t *testing.T) {
+	s, err := New(Options{Metric: Euclidean, Index: IndexHNSW, HNSW: HNSWOptions{EfSearch: 8, Seed: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the rare documents are far from the query, out of reach of a search
+	// of the 8 nearest nodes
+	for i := range 300 {
+		if err := s.Add(Document{ID: fmt.Sprint(i), Vector: []float32{float32(i) / 300, 0}, Metadata: map[string]string{"kind": "common"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range 3 {
+		if err := s.Add(Document{ID: fmt.Sprint("rare", i), Vector: []float32{100 + float32(i), 0}, Metadata: map[string]string{"kind": "rare"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := s.Search(Query{Vector: []float32{0, 0}, K: 3, Filter: Filter{"kind": "rare"}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.Document.ID)
+	}
+	if fmt.Sprint(ids) != "[rare0 rare1 rare2]" {
+		t.Errorf("results = %v, want the rare documents closest first", ids)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	s, err := New(Options{Metric: Dot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := []Document{
+		{ID: "a", Text: "first", Vector: []float32{1, 0, 0}, Metadata: map[string]string{"lang": "go"}},
+		{ID: "b", Text: "second", Vector: []float32{0, 1, 0}},
+	}
+	if err := s.Add(docs...); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "store.snap")
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(path, Options{Index: IndexHNSW})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Len() != 2 || loaded.Dimensions() != 3 || loaded.opts.Metric != Dot {
+		t.Errorf("loaded %d documents of %d dimensions, metric %q", loaded.Len(), loaded.Dimensions(), loaded.opts.Metric)
+	}
+	for _, want := range docs {
+		got, ok := loaded.Get(want.ID)
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("Get(%q) = %+v, want %+v", want.ID, got, want)
+		}
+	}
+	results, err := loaded.Search(Query{Vector: []float32{0, 1, 0}, K: 1})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Document.ID != "b" {
+		t.Errorf("results = %+v, want document b", results)
+	}
+}
+
+func TestLoadRejectsMismatch(t *testing.T) {
+	s, err := New(Options{Metric: Cosine})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Document{ID: "a", Vector: []float32{1, 0, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "store.snap")
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	for name, opts := range map[string]Options{
+		"metric":     {Metric: Euclidean},
+		"dimensions": {Dimensions: 4},
+	} {
+		if _, err := Load(path, opts); err == nil {
+			t.Errorf("Load with a different %s succeeded", name)
+		}
+	}
+}
+
+func TestAddRejectsDimensions(t *testing.T) {
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Document{ID: "a", Vector: []float32{1, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Document{ID: "b", Vector: []float32{1, 0, 0}}); err == nil {
+		t.Error("Add of a vector with other dimensions succeeded")
+	}
+	if _, err := s.Search(Query{Vector: []float32{1}}); err == nil {
+		t.Error("Search with a vector of other dimensions succeeded")
+	}
+}